@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// runBackfill догружает в очередь заказы за прошедший период, например после простоя
+func runBackfill(ctx context.Context, d deps, args []string) error {
+	if len(args) == 0 || args[0] != "orders" {
+		return errUsage
+	}
+
+	flags := flag.NewFlagSet("backfill orders", flag.ContinueOnError)
+	sinceFlag := flags.String("since", "", "дата YYYY-MM-DD, с которой загрузить заказы")
+	mpFlag := flags.String("marketplace", "", "wb|ozon|yandex|megamarket, по умолчанию все")
+	accountFlag := flags.String("account", "", "имя кабинета, по умолчанию все")
+	if err := flags.Parse(args[1:]); err != nil || *sinceFlag == "" {
+		return errUsage
+	}
+
+	since, err := time.ParseInLocation(time.DateOnly, *sinceFlag, time.Local)
+	if err != nil {
+		return errors.Wrap(err, "parse --since")
+	}
+
+	mps, err := d.parseMarketplaces(*mpFlag)
+	if err != nil {
+		return err
+	}
+
+	if err = d.checkAccount(*accountFlag); err != nil {
+		return err
+	}
+
+	for _, mp := range mps {
+		store := &countingOrdersStore{Store: d.orderQueueStore}
+		updaters := d.newOrdersWorkers(mp, *accountFlag, store)
+		if len(updaters) == 0 {
+			continue
+		}
+
+		startedAt := time.Now()
+
+		for _, updater := range updaters {
+			if err = updater.Backfill(ctx, since); err != nil {
+				return errors.Wrapf(err, "backfill orders %s %s", mp, updater.Marketplace().Account())
+			}
+		}
+
+		fmt.Printf("backfill orders %s: orders=%d completed=%d duration=%s\n",
+			mp, store.orders, store.completed, time.Since(startedAt).Round(time.Millisecond))
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	// cardsListSeparator разделитель для составных частей и файлов моделей внутри одной ячейки CSV
+	cardsListSeparator = "|"
+	cardsImportBatch   = 1000
+)
+
+var cardsCSVHeader = []string{
+	"marketplace", "account", "article", "name", "photo", "is_composite", "articles", "files", "filament_grams", "print_hours",
+}
+
+// runCards выгружает и загружает карточки в CSV, чтобы править состав и файлы моделей в таблице
+func runCards(ctx context.Context, d deps, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	flags := flag.NewFlagSet("cards "+args[0], flag.ContinueOnError)
+	fileFlag := flags.String("file", "", "путь к CSV файлу, для export по умолчанию stdout")
+	mpFlag := flags.String("marketplace", "", "wb|ozon|yandex|megamarket|manual, по умолчанию все")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "export":
+		return exportCards(ctx, d, *mpFlag, *fileFlag)
+	case "import":
+		if *fileFlag == "" {
+			return errUsage
+		}
+		return importCards(ctx, d, *fileFlag)
+	default:
+		return errUsage
+	}
+}
+
+func exportCards(ctx context.Context, d deps, marketplace, path string) error {
+	if marketplace != "" {
+		if _, err := parseMarketplace(marketplace); err != nil {
+			return err
+		}
+	}
+
+	cards, err := d.cardStore.ListCards(ctx, marketplace)
+	if err != nil {
+		return errors.Wrap(err, "cardStore.ListCards")
+	}
+
+	var out io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return errors.Wrap(err, "os.Create")
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := csv.NewWriter(out)
+	if err = w.Write(cardsCSVHeader); err != nil {
+		return errors.Wrap(err, "csv.Write")
+	}
+
+	for _, c := range cards {
+		err = w.Write([]string{
+			c.Marketplace.String(),
+			c.GetAccount(),
+			c.Article,
+			c.Name,
+			c.Photo,
+			strconv.FormatBool(c.IsComposite),
+			strings.Join(c.Articles, cardsListSeparator),
+			strings.Join(c.Files, cardsListSeparator),
+			strconv.FormatFloat(c.FilamentGrams, 'f', -1, 64),
+			strconv.FormatFloat(c.PrintHours, 'f', -1, 64),
+		})
+		if err != nil {
+			return errors.Wrap(err, "csv.Write")
+		}
+	}
+
+	w.Flush()
+	if err = w.Error(); err != nil {
+		return errors.Wrap(err, "csv.Flush")
+	}
+
+	if path != "" {
+		fmt.Printf("cards export: cards=%d file=%s\n", len(cards), path)
+	}
+
+	return nil
+}
+
+func importCards(ctx context.Context, d deps, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "os.Open")
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = len(cardsCSVHeader)
+
+	header, err := r.Read()
+	if err != nil {
+		return errors.Wrap(err, "csv.Read header")
+	}
+	for i, name := range cardsCSVHeader {
+		if strings.TrimSpace(header[i]) != name {
+			return errors.Errorf("unexpected header %q, want %s", strings.Join(header, ","), strings.Join(cardsCSVHeader, ","))
+		}
+	}
+
+	type cardKey struct {
+		marketplace card.Marketplace
+		account     string
+		article     string
+	}
+
+	cards := make([]card.Card, 0, cardsImportBatch)
+	// seen строка, где карточка встретилась впервые: повтор в одном запросе ломает ON CONFLICT DO UPDATE
+	seen := make(map[cardKey]int)
+	total := 0
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "csv.Read")
+		}
+
+		c, err := parseCardRecord(record)
+		if err != nil {
+			return errors.Wrapf(err, "line %d", line)
+		}
+
+		key := cardKey{marketplace: c.Marketplace, account: c.Account, article: c.Article}
+		if first, ok := seen[key]; ok {
+			return errors.Errorf("line %d: card %s/%s/%s duplicates line %d", line, c.Marketplace, c.Account, c.Article, first)
+		}
+		seen[key] = line
+
+		cards = append(cards, c)
+
+		if len(cards) == cardsImportBatch {
+			if err = d.cardStore.UpsertCards(ctx, cards); err != nil {
+				return errors.Wrap(err, "cardStore.UpsertCards")
+			}
+			total += len(cards)
+			cards = cards[:0]
+		}
+	}
+
+	if err = d.cardStore.UpsertCards(ctx, cards); err != nil {
+		return errors.Wrap(err, "cardStore.UpsertCards")
+	}
+	total += len(cards)
+
+	fmt.Printf("cards import: cards=%d\n", total)
+
+	return nil
+}
+
+func parseCardRecord(record []string) (card.Card, error) {
+	mp, err := parseMarketplace(strings.TrimSpace(record[0]))
+	if err != nil {
+		return card.Card{}, err
+	}
+
+	account := strings.TrimSpace(record[1])
+	if account == "" {
+		account = card.DefaultAccount
+	}
+
+	article := strings.TrimSpace(record[2])
+	if article == "" {
+		return card.Card{}, errors.New("empty article")
+	}
+
+	isComposite := false
+	if value := strings.TrimSpace(record[5]); value != "" {
+		if isComposite, err = strconv.ParseBool(value); err != nil {
+			return card.Card{}, errors.Errorf("invalid is_composite %q", record[5])
+		}
+	}
+
+	articles := splitList(record[6])
+	if isComposite && len(articles) == 0 {
+		return card.Card{}, errors.New("composite card without articles")
+	}
+
+	filamentGrams, err := parseCost(record[8])
+	if err != nil {
+		return card.Card{}, errors.Wrap(err, "filament_grams")
+	}
+	printHours, err := parseCost(record[9])
+	if err != nil {
+		return card.Card{}, errors.Wrap(err, "print_hours")
+	}
+
+	return card.Card{
+		ID:          uuid.New(),
+		Marketplace: mp,
+		Account:     account,
+		Article:     article,
+		Name:        strings.TrimSpace(record[3]),
+		Photo:       strings.TrimSpace(record[4]),
+		IsComposite: isComposite,
+		Articles:    articles,
+		Files:       splitList(record[7]),
+
+		FilamentGrams: filamentGrams,
+		PrintHours:    printHours,
+	}, nil
+}
+
+// parseCost пустая ячейка - модель себестоимости не задана
+func parseCost(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	result, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil || result < 0 {
+		return 0, errors.Errorf("invalid value %q", value)
+	}
+
+	return result, nil
+}
+
+func splitList(value string) []string {
+	result := []string{}
+	for _, item := range strings.Split(value, cardsListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/megamarket"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/ozon"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/wb"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/yandex"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/config"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/orderqueue"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/marketplace"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers/ordersupdater"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// deps общие зависимости всех команд
+type deps struct {
+	cfg    config.Config
+	log    *slog.Logger
+	dbpool *pgxpool.Pool
+
+	// marketplaces адаптеры кабинетов включённых маркетплейсов в порядке из конфига
+	marketplaces []marketplace.Marketplace
+
+	cardStore       *card.Store
+	orderQueueStore *orderqueue.Store
+}
+
+func newDeps(cfg config.Config, dbpool *pgxpool.Pool, log *slog.Logger) deps {
+	d := deps{
+		cfg:             cfg,
+		log:             log,
+		dbpool:          dbpool,
+		cardStore:       card.New(dbpool, log),
+		orderQueueStore: orderqueue.New(dbpool, log),
+	}
+
+	if cfg.WbEnabled {
+		for _, account := range cfg.WbAccounts {
+			d.marketplaces = append(d.marketplaces, marketplace.NewWb(account.Name, wb.NewClient(account.Token, log), log))
+		}
+	}
+
+	if cfg.OzonEnabled {
+		for _, account := range cfg.OzonAccounts {
+			client := ozon.NewClient(account.Token, account.ClientID, log)
+			d.marketplaces = append(d.marketplaces, marketplace.NewOzon(account.Name, client, log))
+		}
+	}
+
+	if cfg.YandexEnabled {
+		for _, account := range cfg.YandexAccounts {
+			client := yandex.NewClient(account.Token, account.CampaignID, account.BusinessID, log)
+			d.marketplaces = append(d.marketplaces, marketplace.NewYandex(account.Name, client, log))
+		}
+	}
+
+	if cfg.MegamarketEnabled {
+		for _, account := range cfg.MegamarketAccounts {
+			client := megamarket.NewClient(account.Token, log)
+			d.marketplaces = append(d.marketplaces, marketplace.NewMegamarket(account.Name, client, log))
+		}
+	}
+
+	return d
+}
+
+// selectMarketplaces пустые mp и account - без фильтра
+func (d deps) selectMarketplaces(mp card.Marketplace, account string) []marketplace.Marketplace {
+	var result []marketplace.Marketplace
+	for _, m := range d.marketplaces {
+		if (mp == "" || m.Name() == mp) && (account == "" || m.Account() == account) {
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
+
+// checkAccount пустой account означает все кабинеты
+func (d deps) checkAccount(account string) error {
+	if account != "" && len(d.selectMarketplaces("", account)) == 0 {
+		return errors.Errorf("account %q is not configured", account)
+	}
+
+	return nil
+}
+
+// newOrdersWorkers по воркеру на каждый кабинет маркетплейса, пустой account - все кабинеты
+func (d deps) newOrdersWorkers(mp card.Marketplace, account string, store ordersupdater.OrdersStore) []ordersupdater.Worker {
+	mps := d.selectMarketplaces(mp, account)
+
+	result := make([]ordersupdater.Worker, 0, len(mps))
+	for _, m := range mps {
+		result = append(result, ordersupdater.NewWorker(m, store, d.cardStore, d.log))
+	}
+
+	return result
+}
+
+// enabledMarketplaces маркетплейсы, для которых в конфиге включены синхронизации
+func enabledMarketplaces(cfg config.Config) []card.Marketplace {
+	result := make([]card.Marketplace, 0, 4)
+	if cfg.WbEnabled {
+		result = append(result, card.MpWb)
+	}
+	if cfg.OzonEnabled {
+		result = append(result, card.MpOzon)
+	}
+	if cfg.YandexEnabled {
+		result = append(result, card.MpYandex)
+	}
+	if cfg.MegamarketEnabled {
+		result = append(result, card.MpMegamarket)
+	}
+
+	return result
+}
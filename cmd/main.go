@@ -3,87 +3,104 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
-	"strconv"
+	"os/signal"
+	"syscall"
+	// часовой пояс сводок бота нужен и в alpine-образе без tzdata
+	_ "time/tzdata"
 
-	"github.com/alleswebdev/marketplace-3d-factory/internal/app/api"
-	"github.com/alleswebdev/marketplace-3d-factory/internal/client/ozon"
-	"github.com/alleswebdev/marketplace-3d-factory/internal/client/wb"
-	"github.com/alleswebdev/marketplace-3d-factory/internal/client/yandex"
 	"github.com/alleswebdev/marketplace-3d-factory/internal/config"
-	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
-	"github.com/alleswebdev/marketplace-3d-factory/internal/db/orderqueue"
-	"github.com/alleswebdev/marketplace-3d-factory/internal/service/queue"
-	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers/cardsupdater"
-	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers/ozonordersupdater"
-	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers/suppliesupdater"
-	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers/wbordersupdater"
-	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers/yandexordersupdater"
-	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
 )
 
-func main() {
-	cfg := config.GetAppConfig()
-	_ = os.Setenv("MARKETPLACE_APP_HOST", cfg.Host)
-
-	app := fiber.New(fiber.Config{
-		Prefork:       false,
-		CaseSensitive: false,
-		StrictRouting: false,
-		ServerHeader:  "go-app",
-		AppName:       "Marketplace 3d factory",
-	})
+const usage = `usage: 3dfactory <command> [arguments]
 
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "http://127.0.0.1, http://localhost, http://127.0.0.1:4173, http://80.76.35.119",
-		AllowHeaders: "Origin, Content-Type, Accept",
-	}))
-	app.Static("/", "./web/factory-front/dist")
+commands:
+  serve                                                 run HTTP API and background workers (default)
+  migrate up|down|status                                manage database schema
+  sync cards|orders|supplies [--marketplace wb|ozon|yandex|megamarket] [--account name]
+                                                        run one sync pass and print a summary
+  backfill orders --since YYYY-MM-DD [--marketplace wb|ozon|yandex|megamarket] [--account name]
+                                                        load historical orders into the queue
+  cards export [--marketplace wb|ozon|yandex|megamarket|manual] [--file cards.csv]
+  cards import --file cards.csv                         upsert cards with parts, model files and cost model
+`
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+var errUsage = errors.New("invalid command line")
 
-	wbClient := wb.NewClient(cfg.WbToken)
-	ozonClient := ozon.NewClient(cfg.OzonToken, cfg.OzonClientID)
-	yandexClient := yandex.NewClient(cfg.YandexToken, cfg.YandexCompaignID, cfg.YandexBusinessID)
+func main() {
+	err := run(os.Args[1:])
+	if errors.Is(err, errUsage) {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
 
-	dbpool, err := pgxpool.New(ctx, cfg.DatabaseURL)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Unable to create connection pool: %v\n", err)
+		slog.Error("app stopped", logger.Err(err))
 		os.Exit(1)
 	}
-	defer dbpool.Close()
-
-	cardStore := card.New(dbpool)
-	orderQueueStore := orderqueue.New(dbpool)
+}
 
-	ordersUpdater := wbordersupdater.NewWorker(wbClient, orderQueueStore, cardStore)
-	go ordersUpdater.Run(ctx)
+func run(args []string) error {
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
 
-	ozonOrdersUpdater := ozonordersupdater.NewWorker(ozonClient, orderQueueStore, cardStore)
-	go ozonOrdersUpdater.Run(ctx)
+	if command == "help" || command == "-h" || command == "--help" {
+		fmt.Print(usage)
+		return nil
+	}
 
-	yandexOrdersUpdater := yandexordersupdater.NewWorker(yandexClient, orderQueueStore, cardStore)
-	go yandexOrdersUpdater.Run(ctx)
+	cfg, err := config.GetAppConfig()
+	if err != nil {
+		return errors.Wrap(err, "config.GetAppConfig")
+	}
+	_ = os.Setenv("MARKETPLACE_APP_HOST", cfg.Host)
 
-	suppliesUpdater := suppliesupdater.NewWorker(wbClient, ozonClient, orderQueueStore)
-	go suppliesUpdater.Run(ctx)
+	appLog, err := logger.New(os.Stderr, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		return errors.Wrap(err, "logger.New")
+	}
+	slog.SetDefault(appLog)
 
-	cardsUpdater := cardsupdater.NewWorker(wbClient, ozonClient, yandexClient, cardStore)
-	go cardsUpdater.Run(ctx)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	queueService := queue.New(cardStore, orderQueueStore)
-	appAPI := api.New(queueService)
-	app.Get("/api/v2/list-queue", appAPI.ListQueue)
-	app.Post("/api/v2/set-complete", appAPI.SetComplete)
-	app.Post("/api/v2/set-children-complete", appAPI.SetChildrenComplete)
-	app.Post("/api/v2/set-printing", appAPI.SetPrinting)
+	poolConfig, err := pgxpool.ParseConfig(cfg.DatabaseURL)
+	if err != nil {
+		return errors.Wrap(err, "pgxpool.ParseConfig")
+	}
+	if cfg.DBMaxConns > 0 {
+		poolConfig.MaxConns = cfg.DBMaxConns
+	}
+	if cfg.DBMinConns > 0 {
+		poolConfig.MinConns = cfg.DBMinConns
+	}
 
-	err = app.Listen(":" + strconv.Itoa(cfg.Port))
+	dbpool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
-		log.Fatal(err)
+		return errors.Wrap(err, "unable to create connection pool")
+	}
+	defer dbpool.Close()
+
+	d := newDeps(cfg, dbpool, appLog)
+
+	switch command {
+	case "serve":
+		return serve(ctx, stop, d)
+	case "migrate":
+		return runMigrate(ctx, d, args)
+	case "sync":
+		return runSync(ctx, d, args)
+	case "backfill":
+		return runBackfill(ctx, d, args)
+	case "cards":
+		return runCards(ctx, d, args)
+	default:
+		return errUsage
 	}
 }
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"context"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/migration"
+)
+
+func runMigrate(ctx context.Context, d deps, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	return migration.NewMigrator(d.dbpool, d.log).Run(ctx, args[0])
+}
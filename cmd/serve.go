@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/app/api"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/telegram"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/config"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/jobcursor"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/joblock"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/migration"
+	productstore "github.com/alleswebdev/marketplace-3d-factory/internal/db/product"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/report"
+	searchstore "github.com/alleswebdev/marketplace-3d-factory/internal/db/search"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/slaalert"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/webhook"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/analytics"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/export"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/health"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/inbound"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/manualorder"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/printdocs"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/product"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/queue"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/scan"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/search"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/sla"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/telegrambot"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/webhooks"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers/cardsupdater"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers/ordersupdater"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers/suppliesupdater"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// shutdownTimeout сколько ждать завершения запросов и синхронизаций после SIGTERM
+	shutdownTimeout = 30 * time.Second
+	// jobStaleAfter после этого срока без успешного запуска задачи /readyz отвечает 503
+	jobStaleAfter = 15 * time.Minute
+)
+
+// serve запускает HTTP API и фоновые синхронизации до SIGTERM
+func serve(ctx context.Context, stop context.CancelFunc, d deps) error {
+	cfg, appLog := d.cfg, d.log
+
+	if cfg.AutoMigrate {
+		if err := migration.NewMigrator(d.dbpool, appLog).Run(ctx, migration.CommandUp); err != nil {
+			return errors.Wrap(err, "auto migrate")
+		}
+	}
+
+	app := fiber.New(fiber.Config{
+		Prefork:       false,
+		CaseSensitive: false,
+		StrictRouting: false,
+		ServerHeader:  "go-app",
+		AppName:       "Marketplace 3d factory",
+	})
+
+	app.Use(requestid.New())
+	app.Use(api.RequestLogger(appLog))
+	app.Use(cors.New(cors.Config{
+		AllowOrigins: strings.Join(cfg.CORSOriginList(), ", "),
+		AllowHeaders: "Origin, Content-Type, Accept",
+	}))
+	app.Static("/", cfg.StaticDir)
+
+	jobLock := joblock.New(d.dbpool)
+
+	jobRunner := workers.NewRunner(appLog).WithLocker(jobLock).WithBroadcaster(jobLock)
+	go jobLock.Listen(ctx, appLog, jobRunner.HandleTrigger)
+
+	ordersJobOptions := workers.JobOptions{
+		Interval:   cfg.OrdersInterval,
+		Jitter:     0.1,
+		Timeout:    time.Minute,
+		MaxBackoff: 5 * time.Minute,
+	}
+	suppliesJobOptions := workers.JobOptions{
+		Interval:   cfg.SuppliesInterval,
+		Jitter:     0.1,
+		Timeout:    2 * time.Minute,
+		MaxBackoff: 5 * time.Minute,
+	}
+	cardsJobOptions := suppliesJobOptions
+	cardsJobOptions.Interval = cfg.CardsInterval
+
+	if len(d.marketplaces) == 0 {
+		appLog.WarnContext(ctx, "no marketplaces enabled, sync jobs are not started")
+	}
+
+	// заказы маркетплейсов с push-уведомлениями приходят по вебхуку, опрос только сверяет пропущенное
+	reconcileJobOptions := ordersJobOptions
+	reconcileJobOptions.Interval = cfg.WebhookReconcileInterval
+
+	for _, updater := range d.newOrdersWorkers("", "", d.orderQueueStore) {
+		mp := updater.Marketplace()
+
+		opts := ordersJobOptions
+		if cfg.WebhookToken != "" && (mp.Name() == card.MpOzon || mp.Name() == card.MpYandex) {
+			opts = reconcileJobOptions
+		}
+
+		jobRunner.Register(ordersupdater.JobName(mp.Name(), mp.Account()), updater.Update, opts)
+	}
+
+	if len(d.marketplaces) > 0 {
+		suppliesUpdater := suppliesupdater.NewWorker(d.marketplaces, d.orderQueueStore, appLog)
+		jobRunner.Register(suppliesupdater.JobName, suppliesUpdater.Update, suppliesJobOptions)
+
+		cardsUpdater := cardsupdater.NewWorker(d.marketplaces, d.cardStore, appLog)
+		jobRunner.Register(cardsupdater.JobName, cardsUpdater.Update, cardsJobOptions)
+	}
+
+	queueService := queue.New(d.cardStore, d.orderQueueStore, appLog)
+
+	outboxStore := webhook.New(d.dbpool)
+	consumers := make([]webhooks.Consumer, 0, len(cfg.WebhookEndpoints)+1)
+	for _, endpoint := range cfg.WebhookEndpoints {
+		consumers = append(consumers, webhooks.NewHTTPConsumer(webhooks.Endpoint(endpoint)))
+	}
+
+	notifiers := []sla.Notifier{sla.NewLogNotifier(appLog), sla.NewWebhookNotifier(outboxStore)}
+	var bot *telegrambot.Bot
+
+	if cfg.TelegramToken != "" {
+		bot = telegrambot.New(
+			telegram.NewClient(cfg.TelegramAPIURL, cfg.TelegramToken, appLog),
+			queueService,
+			d.cardStore,
+			d.orderQueueStore,
+			jobcursor.New(d.dbpool),
+			telegrambot.Config{ChatID: cfg.TelegramChatID, SummaryAt: cfg.TelegramSummaryAt(), Location: cfg.Location()},
+			appLog,
+		)
+		consumers = append(consumers, bot)
+		notifiers = append(notifiers, bot)
+
+		jobRunner.Register(telegrambot.UpdatesJobName, bot.PollUpdates, workers.JobOptions{
+			Interval:   time.Second,
+			Timeout:    time.Minute,
+			MaxBackoff: time.Minute,
+		})
+		jobRunner.Register(telegrambot.SummaryJobName, bot.SendSummary, workers.JobOptions{
+			Interval:   time.Minute,
+			Timeout:    30 * time.Second,
+			MaxBackoff: 5 * time.Minute,
+		})
+	}
+
+	slaRules := make([]sla.Rule, 0, len(cfg.SLARules))
+	for _, rule := range cfg.SLARules {
+		slaRules = append(slaRules, sla.Rule(rule))
+	}
+	slaEngine := sla.New(d.orderQueueStore, slaalert.New(d.dbpool, appLog), slaRules, notifiers, appLog)
+	if bot != nil {
+		bot.WithAlerts(slaEngine)
+	}
+	if len(slaRules) > 0 {
+		jobRunner.Register(sla.JobName, slaEngine.Update, workers.JobOptions{
+			Interval:   cfg.SLAInterval,
+			Jitter:     0.1,
+			Timeout:    time.Minute,
+			MaxBackoff: 5 * time.Minute,
+		})
+	}
+
+	// задача работает и без получателей, чтобы чистить outbox от событий триггера
+	dispatcher := webhooks.NewDispatcher(outboxStore, consumers, appLog)
+	jobRunner.Register(webhooks.JobName, dispatcher.Update, workers.JobOptions{
+		Interval:   cfg.WebhooksInterval,
+		Jitter:     0.1,
+		Timeout:    time.Minute,
+		MaxBackoff: 5 * time.Minute,
+	})
+
+	jobRunner.Start(ctx)
+
+	appAPI := api.New(queueService)
+	app.Get("/api/v2/list-queue", appAPI.ListQueue)
+	app.Post("/api/v2/set-complete", appAPI.SetComplete)
+	app.Post("/api/v2/set-children-complete", appAPI.SetChildrenComplete)
+	app.Post("/api/v2/set-printing", appAPI.SetPrinting)
+
+	manualOrderAPI := api.NewManualOrder(manualorder.New(d.orderQueueStore, appLog))
+	app.Post("/api/v2/manual-orders", manualOrderAPI.Create)
+	app.Put("/api/v2/manual-orders/:id", manualOrderAPI.Update)
+	app.Post("/api/v2/manual-orders/:id/cancel", manualOrderAPI.Cancel)
+
+	productAPI := api.NewProduct(product.New(productstore.New(d.dbpool, appLog), appLog))
+	app.Get("/api/v2/products", productAPI.List)
+	app.Post("/api/v2/products", productAPI.Create)
+	app.Get("/api/v2/products/suggestions", productAPI.Suggestions)
+	app.Post("/api/v2/products/unlink", productAPI.Unlink)
+	app.Put("/api/v2/products/:id", productAPI.Update)
+	app.Delete("/api/v2/products/:id", productAPI.Delete)
+	app.Post("/api/v2/products/:id/cards", productAPI.Link)
+
+	costs := report.Costs{MaterialPerKg: cfg.MaterialCostPerKg, MachineHour: cfg.MachineHourRate}
+	analyticsAPI := api.NewAnalytics(analytics.New(report.New(d.dbpool), cfg.Location(), costs))
+	app.Get("/api/v2/analytics/orders-per-day", analyticsAPI.OrdersPerDay)
+	app.Get("/api/v2/analytics/lead-time", analyticsAPI.LeadTime)
+	app.Get("/api/v2/analytics/top-articles", analyticsAPI.TopArticles)
+	app.Get("/api/v2/analytics/on-time", analyticsAPI.OnTime)
+	app.Get("/api/v2/analytics/margin-by-article", analyticsAPI.ArticleMargin)
+	app.Get("/api/v2/analytics/margin-by-marketplace", analyticsAPI.MarketplaceMargin)
+	app.Get("/api/v2/analytics/margin-by-product", analyticsAPI.ProductMargin)
+
+	printAPI := api.NewPrint(printdocs.New(queueService, cfg.Location()))
+	app.Get("/api/v2/print/list", printAPI.PrintList)
+	app.Get("/api/v2/print/packing-slip", printAPI.PackingSlip)
+	app.Get("/api/v2/print/labels", printAPI.Labels)
+
+	scanAPI := api.NewScan(scan.New(d.orderQueueStore, d.cardStore, appLog))
+	app.Post("/api/v2/scan", scanAPI.Scan)
+
+	searchAPI := api.NewSearch(search.New(searchstore.New(d.dbpool)))
+	app.Get("/api/v2/search", searchAPI.Search)
+
+	exportAPI := api.NewExport(export.New(d.orderQueueStore, cfg.Location()), appLog)
+	app.Get("/api/v2/export/queue", exportAPI.Queue)
+	app.Get("/api/v2/export/completed", exportAPI.Completed)
+
+	alertsAPI := api.NewAlerts(slaEngine)
+	app.Get("/api/v2/alerts", alertsAPI.ListAlerts)
+	app.Post("/api/v2/alerts/ack", alertsAPI.Acknowledge)
+
+	if cfg.WebhookToken != "" {
+		ozonAccounts, yandexAccounts := webhookAccounts(cfg)
+		webhookAPI := api.NewWebhook(inbound.New(jobRunner, ozonAccounts, yandexAccounts, appLog), cfg.WebhookToken)
+		app.Post("/api/v2/webhooks/ozon", webhookAPI.Ozon)
+		// Яндекс дописывает /notification к адресу из кабинета, поэтому токен у него в пути, а не в параметре
+		app.Post("/api/v2/webhooks/yandex/:token/notification", webhookAPI.Yandex)
+	}
+
+	prometheus.MustRegister(queue.NewCollector(d.orderQueueStore, appLog))
+	app.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+
+	expectedMigration, err := migration.ExpectedVersion()
+	if err != nil {
+		appLog.WarnContext(ctx, "migration.ExpectedVersion", logger.Err(err))
+	}
+
+	healthAPI := api.NewHealth(health.New(d.dbpool, migration.New(d.dbpool), expectedMigration, jobRunner, jobStaleAfter))
+	app.Get("/healthz", healthAPI.Healthz)
+	app.Get("/readyz", healthAPI.Readyz)
+
+	adminAPI := api.NewAdmin(jobRunner)
+	app.Get("/api/v2/admin/list-jobs", adminAPI.ListJobs)
+	app.Post("/api/v2/admin/pause-job", adminAPI.PauseJob)
+	app.Post("/api/v2/admin/resume-job", adminAPI.ResumeJob)
+	app.Post("/api/v2/admin/trigger-job", adminAPI.TriggerJob)
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(":" + strconv.Itoa(cfg.Port))
+	}()
+
+	select {
+	case err = <-listenErr:
+		stop()
+	case <-ctx.Done():
+		appLog.InfoContext(ctx, "shutting down")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if shutdownErr := app.ShutdownWithContext(shutdownCtx); shutdownErr != nil {
+		appLog.ErrorContext(shutdownCtx, "app.ShutdownWithContext", logger.Err(shutdownErr))
+	}
+
+	if shutdownErr := jobRunner.Shutdown(shutdownCtx); shutdownErr != nil {
+		appLog.ErrorContext(shutdownCtx, "jobRunner.Shutdown", logger.Err(shutdownErr))
+	}
+
+	jobLock.Close(shutdownCtx)
+
+	return errors.Wrap(err, "app.Listen")
+}
+
+// webhookAccounts client_id и campaign_id включённых кабинетов -> имя кабинета
+func webhookAccounts(cfg config.Config) (ozonAccounts, yandexAccounts map[string]string) {
+	ozonAccounts, yandexAccounts = map[string]string{}, map[string]string{}
+
+	if cfg.OzonEnabled {
+		for _, account := range cfg.OzonAccounts {
+			ozonAccounts[account.ClientID] = account.Name
+		}
+	}
+
+	if cfg.YandexEnabled {
+		for _, account := range cfg.YandexAccounts {
+			yandexAccounts[account.CampaignID] = account.Name
+		}
+	}
+
+	return ozonAccounts, yandexAccounts
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/orderqueue"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers/cardsupdater"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers/suppliesupdater"
+	"github.com/pkg/errors"
+)
+
+// runSync выполняет один проход синхронизации без HTTP сервера, удобно для cron и ручного запуска
+func runSync(ctx context.Context, d deps, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	target := args[0]
+
+	flags := flag.NewFlagSet("sync "+target, flag.ContinueOnError)
+	mpFlag := flags.String("marketplace", "", "wb|ozon|yandex|megamarket, по умолчанию все")
+	accountFlag := flags.String("account", "", "имя кабинета, по умолчанию все")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+
+	mps, err := d.parseMarketplaces(*mpFlag)
+	if err != nil {
+		return err
+	}
+
+	if err = d.checkAccount(*accountFlag); err != nil {
+		return err
+	}
+
+	for _, mp := range mps {
+		adapters := d.selectMarketplaces(mp, *accountFlag)
+		if len(adapters) == 0 {
+			continue
+		}
+
+		startedAt := time.Now()
+
+		var summary string
+		switch target {
+		case "cards":
+			store := &countingCardsStore{CardsStore: d.cardStore}
+			err = cardsupdater.NewWorker(adapters, store, d.log).Update(ctx)
+			summary = fmt.Sprintf("cards=%d", store.cards)
+		case "orders":
+			store := &countingOrdersStore{Store: d.orderQueueStore}
+			for _, updater := range d.newOrdersWorkers(mp, *accountFlag, store) {
+				if err = updater.Update(ctx); err != nil {
+					err = errors.Wrapf(err, "account %s", updater.Marketplace().Account())
+					break
+				}
+			}
+			summary = fmt.Sprintf("orders=%d", store.orders)
+		case "supplies":
+			store := &countingOrdersStore{Store: d.orderQueueStore}
+			err = suppliesupdater.NewWorker(adapters, store, d.log).Update(ctx)
+			summary = fmt.Sprintf("completed=%d", store.completed)
+		default:
+			return errUsage
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "sync %s %s", target, mp)
+		}
+
+		fmt.Printf("sync %s %s: %s duration=%s\n", target, mp, summary, time.Since(startedAt).Round(time.Millisecond))
+	}
+
+	return nil
+}
+
+// parseMarketplaces пустое значение означает все включённые в конфиге маркетплейсы
+func (d deps) parseMarketplaces(value string) ([]card.Marketplace, error) {
+	enabled := enabledMarketplaces(d.cfg)
+	if value == "" {
+		return enabled, nil
+	}
+
+	mp, err := parseMarketplace(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(enabled, mp) {
+		return nil, errors.Errorf("marketplace %q is disabled in config", value)
+	}
+
+	return []card.Marketplace{mp}, nil
+}
+
+func parseMarketplace(value string) (card.Marketplace, error) {
+	for _, mp := range []card.Marketplace{card.MpWb, card.MpOzon, card.MpYandex, card.MpMegamarket, card.MpManual} {
+		if mp.String() == value {
+			return mp, nil
+		}
+	}
+
+	return "", errors.Errorf("unknown marketplace %q", value)
+}
+
+// countingCardsStore считает карточки, переданные воркером в базу
+type countingCardsStore struct {
+	cardsupdater.CardsStore
+	cards int
+}
+
+func (s *countingCardsStore) AddCards(ctx context.Context, cards []card.Card) error {
+	s.cards += len(cards)
+	return s.CardsStore.AddCards(ctx, cards)
+}
+
+// countingOrdersStore считает заказы, переданные воркером в очередь, и закрытые заказы
+type countingOrdersStore struct {
+	*orderqueue.Store
+	orders    int
+	completed int
+}
+
+func (s *countingOrdersStore) AddOrders(ctx context.Context, orders []orderqueue.Order) error {
+	s.orders += len(orders)
+	return s.Store.AddOrders(ctx, orders)
+}
+
+func (s *countingOrdersStore) SetCompleteByOrderIDs(ctx context.Context, marketplace, account string, orderIDs []string) error {
+	s.completed += len(orderIDs)
+	return s.Store.SetCompleteByOrderIDs(ctx, marketplace, account, orderIDs)
+}
+
+func (s *countingOrdersStore) SetCancelledByOrderIDs(ctx context.Context, marketplace, account string, orderIDs []string) error {
+	s.completed += len(orderIDs)
+	return s.Store.SetCancelledByOrderIDs(ctx, marketplace, account, orderIDs)
+}
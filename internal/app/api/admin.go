@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+)
+
+type JobsService interface {
+	Jobs() []workers.JobState
+	Pause(name string) error
+	Resume(name string) error
+	Trigger(name string) error
+}
+
+type AdminAPI struct {
+	jobsService JobsService
+}
+
+func NewAdmin(jobs JobsService) AdminAPI {
+	return AdminAPI{jobsService: jobs}
+}
+
+type (
+	JobRequest struct {
+		Name string `json:"name"`
+	}
+
+	ListJobsResponse struct {
+		Jobs []workers.JobState `json:"jobs"`
+	}
+)
+
+func (a AdminAPI) ListJobs(c *fiber.Ctx) error {
+	return c.JSON(ListJobsResponse{Jobs: a.jobsService.Jobs()})
+}
+
+func (a AdminAPI) PauseJob(c *fiber.Ctx) error {
+	return a.handleJob(c, a.jobsService.Pause, "jobsService.Pause")
+}
+
+func (a AdminAPI) ResumeJob(c *fiber.Ctx) error {
+	return a.handleJob(c, a.jobsService.Resume, "jobsService.Resume")
+}
+
+func (a AdminAPI) TriggerJob(c *fiber.Ctx) error {
+	return a.handleJob(c, a.jobsService.Trigger, "jobsService.Trigger")
+}
+
+func (a AdminAPI) handleJob(c *fiber.Ctx, action func(name string) error, actionName string) error {
+	req := new(JobRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "BodyParser").Error())
+	}
+
+	if err := action(req.Name); err != nil {
+		if errors.Is(err, workers.ErrJobNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, errors.Wrap(err, actionName).Error())
+		}
+
+		if errors.Is(err, workers.ErrNotLeader) {
+			return fiber.NewError(fiber.StatusConflict, errors.Wrap(err, actionName).Error())
+		}
+
+		return fiber.NewError(fiber.StatusInternalServerError, errors.Wrap(err, actionName).Error())
+	}
+
+	return c.SendStatus(http.StatusOK)
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/slaalert"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+)
+
+type AlertsService interface {
+	List(ctx context.Context, withAcknowledged bool) ([]slaalert.Alert, error)
+	Acknowledge(ctx context.Context, id int64, by string) error
+}
+
+type AlertsAPI struct {
+	alertsService AlertsService
+}
+
+func NewAlerts(alerts AlertsService) AlertsAPI {
+	return AlertsAPI{alertsService: alerts}
+}
+
+type (
+	ListAlertsRequest struct {
+		WithAcknowledged bool `json:"withAcknowledged"`
+	}
+
+	AlertItem struct {
+		slaalert.Alert
+		AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	}
+
+	ListAlertsResponse struct {
+		Items []AlertItem `json:"items"`
+	}
+
+	AcknowledgeAlertRequest struct {
+		ID int64  `json:"id"`
+		By string `json:"by"`
+	}
+)
+
+func (a AlertsAPI) ListAlerts(c *fiber.Ctx) error {
+	req := new(ListAlertsRequest)
+	if err := c.QueryParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "QueryParser").Error())
+	}
+
+	alerts, err := a.alertsService.List(c.UserContext(), req.WithAcknowledged)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, errors.Wrap(err, "alertsService.List").Error())
+	}
+
+	items := make([]AlertItem, 0, len(alerts))
+	for _, alert := range alerts {
+		item := AlertItem{Alert: alert}
+		if alert.AcknowledgedAt.Valid {
+			item.AcknowledgedAt = &alert.AcknowledgedAt.Time
+		}
+		items = append(items, item)
+	}
+
+	return c.JSON(ListAlertsResponse{Items: items})
+}
+
+func (a AlertsAPI) Acknowledge(c *fiber.Ctx) error {
+	req := new(AcknowledgeAlertRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "BodyParser").Error())
+	}
+
+	if err := a.alertsService.Acknowledge(c.UserContext(), req.ID, req.By); err != nil {
+		if errors.Is(err, slaalert.ErrNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, errors.Wrap(err, "alertsService.Acknowledge").Error())
+		}
+
+		return fiber.NewError(fiber.StatusInternalServerError, errors.Wrap(err, "alertsService.Acknowledge").Error())
+	}
+
+	return c.SendStatus(http.StatusOK)
+}
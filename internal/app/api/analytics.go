@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/report"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/analytics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+)
+
+const formatCSV = "csv"
+
+type AnalyticsService interface {
+	DailyOrders(ctx context.Context, req analytics.Request) ([]report.DailyOrders, error)
+	LeadTime(ctx context.Context, req analytics.Request) ([]report.LeadTime, error)
+	TopArticles(ctx context.Context, req analytics.Request) ([]report.ArticleDemand, error)
+	OnTime(ctx context.Context, req analytics.Request) ([]report.OnTime, error)
+	ArticleMargin(ctx context.Context, req analytics.Request) ([]report.ArticleMargin, error)
+	MarketplaceMargin(ctx context.Context, req analytics.Request) ([]report.MarketplaceMargin, error)
+	ProductMargin(ctx context.Context, req analytics.Request) ([]report.ProductMargin, error)
+}
+
+type AnalyticsAPI struct {
+	analyticsService AnalyticsService
+}
+
+func NewAnalytics(analytics AnalyticsService) AnalyticsAPI {
+	return AnalyticsAPI{analyticsService: analytics}
+}
+
+type csvRecord interface {
+	CSVRecord() []string
+}
+
+func (a AnalyticsAPI) OrdersPerDay(c *fiber.Ctx) error {
+	return sendReport(c, "orders-per-day", report.DailyOrdersHeader, a.analyticsService.DailyOrders)
+}
+
+func (a AnalyticsAPI) LeadTime(c *fiber.Ctx) error {
+	return sendReport(c, "lead-time", report.LeadTimeHeader, a.analyticsService.LeadTime)
+}
+
+func (a AnalyticsAPI) TopArticles(c *fiber.Ctx) error {
+	return sendReport(c, "top-articles", report.ArticleDemandHeader, a.analyticsService.TopArticles)
+}
+
+func (a AnalyticsAPI) OnTime(c *fiber.Ctx) error {
+	return sendReport(c, "on-time", report.OnTimeHeader, a.analyticsService.OnTime)
+}
+
+func (a AnalyticsAPI) ArticleMargin(c *fiber.Ctx) error {
+	return sendReport(c, "margin-by-article", report.ArticleMarginHeader, a.analyticsService.ArticleMargin)
+}
+
+func (a AnalyticsAPI) ProductMargin(c *fiber.Ctx) error {
+	return sendReport(c, "margin-by-product", report.ProductMarginHeader, a.analyticsService.ProductMargin)
+}
+
+func (a AnalyticsAPI) MarketplaceMargin(c *fiber.Ctx) error {
+	return sendReport(c, "margin-by-marketplace", report.MarketplaceMarginHeader, a.analyticsService.MarketplaceMargin)
+}
+
+// sendReport ?format=csv отдаёт файл для таблиц, иначе JSON {"items": [...]}
+func sendReport[T csvRecord](
+	c *fiber.Ctx,
+	name string,
+	header []string,
+	build func(ctx context.Context, req analytics.Request) ([]T, error),
+) error {
+	req := analytics.Request{}
+	if err := c.QueryParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "QueryParser").Error())
+	}
+
+	rows, err := build(c.UserContext(), req)
+	if err != nil {
+		if errors.Is(err, analytics.ErrInvalidFilter) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		return fiber.NewError(fiber.StatusInternalServerError, errors.Wrap(err, "analyticsService."+name).Error())
+	}
+
+	if c.Query("format") != formatCSV {
+		return c.JSON(fiber.Map{"items": rows})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Attachment(name + ".csv")
+
+	w := csv.NewWriter(c.Response().BodyWriter())
+	if err = w.Write(header); err != nil {
+		return errors.Wrap(err, "csv.Write")
+	}
+	for _, row := range rows {
+		if err = w.Write(row.CSVRecord()); err != nil {
+			return errors.Wrap(err, "csv.Write")
+		}
+	}
+	w.Flush()
+
+	return errors.Wrap(w.Error(), "csv.Flush")
+}
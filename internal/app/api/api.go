@@ -13,7 +13,7 @@ type QueueService interface {
 	SetComplete(ctx context.Context, id string, state bool) error
 	SetPrinting(ctx context.Context, id string, state bool) error
 	SetChildrenComplete(ctx context.Context, id string, state bool) error
-	ListQueue(ctx context.Context, withParent, withChildren bool, marketplace string) ([]domain.QueueItem, error)
+	ListQueue(ctx context.Context, withParent, withChildren bool, marketplace, account string) ([]domain.QueueItem, error)
 }
 
 type FactoryAPI struct {
@@ -43,6 +43,7 @@ type (
 		WithParentComplete   bool   `json:"withParentComplete"`
 		WithChildrenComplete bool   `json:"withChildrenComplete"`
 		Marketplace          string `json:"marketplace"`
+		Account              string `json:"account"`
 	}
 )
 
@@ -53,7 +54,7 @@ func (a FactoryAPI) SetComplete(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "BodyParser").Error())
 	}
 
-	if err := a.queueService.SetComplete(c.Context(), req.ID, req.State); err != nil {
+	if err := a.queueService.SetComplete(c.UserContext(), req.ID, req.State); err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, errors.Wrap(err, "queueStore.SetComplete").Error())
 	}
 
@@ -67,7 +68,7 @@ func (a FactoryAPI) SetPrinting(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "BodyParser").Error())
 	}
 
-	if err := a.queueService.SetPrinting(c.Context(), req.ID, req.State); err != nil {
+	if err := a.queueService.SetPrinting(c.UserContext(), req.ID, req.State); err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, errors.Wrap(err, "queueStore.SetPrinting").Error())
 	}
 
@@ -81,7 +82,7 @@ func (a FactoryAPI) SetChildrenComplete(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "BodyParser").Error())
 	}
 
-	if err := a.queueService.SetChildrenComplete(c.Context(), req.ID, req.State); err != nil {
+	if err := a.queueService.SetChildrenComplete(c.UserContext(), req.ID, req.State); err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, errors.Wrap(err, "queueStore.SetComplete").Error())
 	}
 
@@ -94,7 +95,7 @@ func (a FactoryAPI) ListQueue(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "QueryParser").Error())
 	}
 
-	items, err := a.queueService.ListQueue(c.Context(), req.WithParentComplete, req.WithChildrenComplete, req.Marketplace)
+	items, err := a.queueService.ListQueue(c.UserContext(), req.WithParentComplete, req.WithChildrenComplete, req.Marketplace, req.Account)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, errors.Wrap(err, "ListQueue").Error())
 	}
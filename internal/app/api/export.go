@@ -0,0 +1,84 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/export"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+)
+
+// exportTimeout выгрузка пишется после возврата из обработчика, поэтому ограничивается своим таймаутом
+const exportTimeout = 10 * time.Minute
+
+type ExportService interface {
+	Queue(req export.QueueRequest) (export.File, error)
+	Completed(req export.CompletedRequest) (export.File, error)
+}
+
+type ExportAPI struct {
+	exportService ExportService
+	log           *slog.Logger
+}
+
+func NewExport(exports ExportService, log *slog.Logger) ExportAPI {
+	return ExportAPI{exportService: exports, log: log}
+}
+
+func (a ExportAPI) Queue(c *fiber.Ctx) error {
+	req := export.QueueRequest{}
+	if err := c.QueryParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "QueryParser").Error())
+	}
+
+	file, err := a.exportService.Queue(req)
+
+	return a.send(c, file, err)
+}
+
+func (a ExportAPI) Completed(c *fiber.Ctx) error {
+	req := export.CompletedRequest{}
+	if err := c.QueryParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "QueryParser").Error())
+	}
+
+	file, err := a.exportService.Completed(req)
+
+	return a.send(c, file, err)
+}
+
+// send параметры проверяются до ответа, а строки пишутся уже после отправки статуса,
+// поэтому ошибка посреди выгрузки только логируется и обрывает файл
+func (a ExportAPI) send(c *fiber.Ctx, file export.File, err error) error {
+	if err != nil {
+		if errors.Is(err, export.ErrInvalidRequest) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, file.ContentType)
+	c.Attachment(file.Name)
+
+	parent := c.UserContext()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(parent, exportTimeout)
+		defer cancel()
+
+		if err := file.Write(ctx, w); err != nil {
+			a.log.ErrorContext(ctx, "export interrupted", slog.String("file", file.Name), logger.Err(err))
+			return
+		}
+
+		if err := w.Flush(); err != nil {
+			a.log.WarnContext(ctx, "export flush", slog.String("file", file.Name), logger.Err(err))
+		}
+	})
+
+	return nil
+}
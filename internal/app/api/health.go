@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/health"
+	"github.com/gofiber/fiber/v2"
+)
+
+type HealthService interface {
+	Ready(ctx context.Context) health.Report
+}
+
+type HealthAPI struct {
+	healthService HealthService
+}
+
+func NewHealth(healthService HealthService) HealthAPI {
+	return HealthAPI{healthService: healthService}
+}
+
+// Healthz отвечает, пока процесс жив
+func (a HealthAPI) Healthz(c *fiber.Ctx) error {
+	return c.JSON(health.Report{Status: health.StatusOK})
+}
+
+// Readyz отвечает 503, если хотя бы одна зависимость не готова
+func (a HealthAPI) Readyz(c *fiber.Ctx) error {
+	report := a.healthService.Ready(c.UserContext())
+	if report.Status != health.StatusOK {
+		c.Status(http.StatusServiceUnavailable)
+	}
+
+	return c.JSON(report)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/orderqueue"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/domain"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/manualorder"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+)
+
+type ManualOrderService interface {
+	Create(ctx context.Context, req domain.ManualOrder) (string, error)
+	Update(ctx context.Context, id string, req domain.ManualOrder) error
+	Cancel(ctx context.Context, id string) error
+}
+
+type ManualOrderAPI struct {
+	manualOrderService ManualOrderService
+}
+
+func NewManualOrder(manualOrders ManualOrderService) ManualOrderAPI {
+	return ManualOrderAPI{manualOrderService: manualOrders}
+}
+
+type CreateManualOrderResponse struct {
+	ID string `json:"id"`
+}
+
+func (a ManualOrderAPI) Create(c *fiber.Ctx) error {
+	req := new(domain.ManualOrder)
+
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "BodyParser").Error())
+	}
+
+	id, err := a.manualOrderService.Create(c.UserContext(), *req)
+	if err != nil {
+		return manualOrderError(err, "manualOrderService.Create")
+	}
+
+	return c.Status(http.StatusCreated).JSON(CreateManualOrderResponse{ID: id})
+}
+
+func (a ManualOrderAPI) Update(c *fiber.Ctx) error {
+	req := new(domain.ManualOrder)
+
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "BodyParser").Error())
+	}
+
+	if err := a.manualOrderService.Update(c.UserContext(), c.Params("id"), *req); err != nil {
+		return manualOrderError(err, "manualOrderService.Update")
+	}
+
+	return c.SendStatus(http.StatusOK)
+}
+
+func (a ManualOrderAPI) Cancel(c *fiber.Ctx) error {
+	if err := a.manualOrderService.Cancel(c.UserContext(), c.Params("id")); err != nil {
+		return manualOrderError(err, "manualOrderService.Cancel")
+	}
+
+	return c.SendStatus(http.StatusOK)
+}
+
+func manualOrderError(err error, actionName string) error {
+	switch {
+	case errors.Is(err, manualorder.ErrInvalidOrder):
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, actionName).Error())
+	case errors.Is(err, orderqueue.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, errors.Wrap(err, actionName).Error())
+	case errors.Is(err, manualorder.ErrCancelled):
+		return fiber.NewError(fiber.StatusConflict, errors.Wrap(err, actionName).Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, errors.Wrap(err, actionName).Error())
+	}
+}
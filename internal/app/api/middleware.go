@@ -0,0 +1,48 @@
+package api
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/pkg/errors"
+)
+
+// RequestLogger кладёт request_id из middleware requestid в контекст запроса и пишет итог каждого запроса
+func RequestLogger(log *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		startedAt := time.Now()
+
+		requestID, _ := c.Locals(requestid.ConfigDefault.ContextKey).(string)
+		ctx := logger.WithAttrs(c.UserContext(), slog.String(logger.KeyRequestID, requestID))
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+		}
+
+		attrs := []any{
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(startedAt)),
+		}
+
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			log.ErrorContext(ctx, "http request", append(attrs, logger.Err(err))...)
+		case err != nil:
+			log.WarnContext(ctx, "http request", append(attrs, logger.Err(err))...)
+		default:
+			log.DebugContext(ctx, "http request", attrs...)
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/printdocs"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+)
+
+type PrintService interface {
+	PrintList(ctx context.Context, req printdocs.Request) ([]byte, error)
+	PackingSlip(ctx context.Context, req printdocs.Request) ([]byte, error)
+	Labels(ctx context.Context, req printdocs.LabelRequest) ([]byte, error)
+}
+
+type PrintAPI struct {
+	printService PrintService
+}
+
+func NewPrint(print PrintService) PrintAPI {
+	return PrintAPI{printService: print}
+}
+
+func (a PrintAPI) PrintList(c *fiber.Ctx) error {
+	return sendPDF(c, "print-list.pdf", a.printService.PrintList)
+}
+
+func (a PrintAPI) PackingSlip(c *fiber.Ctx) error {
+	return sendPDF(c, "packing-slip.pdf", a.printService.PackingSlip)
+}
+
+func (a PrintAPI) Labels(c *fiber.Ctx) error {
+	req := printdocs.LabelRequest{}
+	if err := c.QueryParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "QueryParser").Error())
+	}
+
+	if req.ID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "id is required")
+	}
+
+	doc, err := a.printService.Labels(c.UserContext(), req)
+	if err != nil {
+		if errors.Is(err, printdocs.ErrNoLabels) {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+
+		return fiber.NewError(fiber.StatusInternalServerError, errors.Wrap(err, "printService.Labels").Error())
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="labels-`+fileNamePart(req.ID)+`.pdf"`)
+
+	return c.Send(doc)
+}
+
+// sendPDF inline, чтобы браузер сразу открыл документ для печати
+func sendPDF(c *fiber.Ctx, name string, build func(ctx context.Context, req printdocs.Request) ([]byte, error)) error {
+	req := printdocs.Request{}
+	if err := c.QueryParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "QueryParser").Error())
+	}
+
+	doc, err := build(c.UserContext(), req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, errors.Wrap(err, "printService."+name).Error())
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="`+name+`"`)
+
+	return c.Send(doc)
+}
+
+// fileNamePart id приходит из запроса, в заголовок Content-Disposition попадают только безопасные символы
+func fileNamePart(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+
+		return '_'
+	}, value)
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	productstore "github.com/alleswebdev/marketplace-3d-factory/internal/db/product"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/domain"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/product"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type ProductService interface {
+	List(ctx context.Context) ([]product.Item, error)
+	Create(ctx context.Context, req domain.ProductMaster) (uuid.UUID, error)
+	Update(ctx context.Context, id string, req domain.ProductMaster) error
+	Delete(ctx context.Context, id string) error
+	Link(ctx context.Context, id string, cardIDs []uuid.UUID) (int64, error)
+	Unlink(ctx context.Context, cardIDs []uuid.UUID) (int64, error)
+	Suggestions(ctx context.Context, req product.SuggestionRequest) ([]productstore.Suggestion, error)
+}
+
+type ProductAPI struct {
+	productService ProductService
+}
+
+func NewProduct(products ProductService) ProductAPI {
+	return ProductAPI{productService: products}
+}
+
+type CreateProductResponse struct {
+	ID uuid.UUID `json:"id"`
+}
+
+type ProductCardsRequest struct {
+	CardIDs []uuid.UUID `json:"card_ids"`
+}
+
+type ProductCardsResponse struct {
+	Affected int64 `json:"affected"`
+}
+
+func (a ProductAPI) List(c *fiber.Ctx) error {
+	items, err := a.productService.List(c.UserContext())
+	if err != nil {
+		return productError(err, "productService.List")
+	}
+
+	return c.JSON(items)
+}
+
+func (a ProductAPI) Create(c *fiber.Ctx) error {
+	req := new(domain.ProductMaster)
+
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "BodyParser").Error())
+	}
+
+	id, err := a.productService.Create(c.UserContext(), *req)
+	if err != nil {
+		return productError(err, "productService.Create")
+	}
+
+	return c.Status(http.StatusCreated).JSON(CreateProductResponse{ID: id})
+}
+
+func (a ProductAPI) Update(c *fiber.Ctx) error {
+	req := new(domain.ProductMaster)
+
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "BodyParser").Error())
+	}
+
+	if err := a.productService.Update(c.UserContext(), c.Params("id"), *req); err != nil {
+		return productError(err, "productService.Update")
+	}
+
+	return c.SendStatus(http.StatusOK)
+}
+
+func (a ProductAPI) Delete(c *fiber.Ctx) error {
+	if err := a.productService.Delete(c.UserContext(), c.Params("id")); err != nil {
+		return productError(err, "productService.Delete")
+	}
+
+	return c.SendStatus(http.StatusOK)
+}
+
+func (a ProductAPI) Link(c *fiber.Ctx) error {
+	req := new(ProductCardsRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "BodyParser").Error())
+	}
+
+	affected, err := a.productService.Link(c.UserContext(), c.Params("id"), req.CardIDs)
+	if err != nil {
+		return productError(err, "productService.Link")
+	}
+
+	return c.JSON(ProductCardsResponse{Affected: affected})
+}
+
+func (a ProductAPI) Unlink(c *fiber.Ctx) error {
+	req := new(ProductCardsRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "BodyParser").Error())
+	}
+
+	affected, err := a.productService.Unlink(c.UserContext(), req.CardIDs)
+	if err != nil {
+		return productError(err, "productService.Unlink")
+	}
+
+	return c.JSON(ProductCardsResponse{Affected: affected})
+}
+
+func (a ProductAPI) Suggestions(c *fiber.Ctx) error {
+	req := product.SuggestionRequest{}
+	if err := c.QueryParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "QueryParser").Error())
+	}
+
+	suggestions, err := a.productService.Suggestions(c.UserContext(), req)
+	if err != nil {
+		return productError(err, "productService.Suggestions")
+	}
+
+	return c.JSON(suggestions)
+}
+
+func productError(err error, actionName string) error {
+	switch {
+	case errors.Is(err, product.ErrInvalidRequest):
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, actionName).Error())
+	case errors.Is(err, productstore.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, errors.Wrap(err, actionName).Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, errors.Wrap(err, actionName).Error())
+	}
+}
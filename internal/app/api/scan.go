@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/scan"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+)
+
+type ScanService interface {
+	Scan(ctx context.Context, code string) (scan.Result, error)
+}
+
+type ScanAPI struct {
+	scanService ScanService
+}
+
+func NewScan(scans ScanService) ScanAPI {
+	return ScanAPI{scanService: scans}
+}
+
+type ScanRequest struct {
+	Code string `json:"code"`
+}
+
+// Scan повторный скан той же позиции переводит её дальше, поэтому ответ показывает оба статуса
+func (a ScanAPI) Scan(c *fiber.Ctx) error {
+	req := new(ScanRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "BodyParser").Error())
+	}
+
+	result, err := a.scanService.Scan(c.UserContext(), req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, scan.ErrEmptyCode):
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		case errors.Is(err, scan.ErrNotFound):
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		default:
+			return fiber.NewError(fiber.StatusInternalServerError, errors.Wrap(err, "scanService.Scan").Error())
+		}
+	}
+
+	return c.JSON(result)
+}
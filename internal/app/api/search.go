@@ -0,0 +1,39 @@
+package api
+
+import (
+	"context"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/search"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+)
+
+type SearchService interface {
+	Search(ctx context.Context, req search.Request) (search.Result, error)
+}
+
+type SearchAPI struct {
+	searchService SearchService
+}
+
+func NewSearch(searches SearchService) SearchAPI {
+	return SearchAPI{searchService: searches}
+}
+
+func (a SearchAPI) Search(c *fiber.Ctx) error {
+	req := search.Request{}
+	if err := c.QueryParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, errors.Wrap(err, "QueryParser").Error())
+	}
+
+	result, err := a.searchService.Search(c.UserContext(), req)
+	if err != nil {
+		if errors.Is(err, search.ErrInvalidRequest) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		return fiber.NewError(fiber.StatusInternalServerError, errors.Wrap(err, "searchService.Search").Error())
+	}
+
+	return c.JSON(result)
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/ozon"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/yandex"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/inbound"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	// webhookTokenHeader токен можно передать заголовком, в пути или параметром token, если кабинет маркетплейса не умеет заголовки
+	webhookTokenHeader = "X-Webhook-Token"
+	webhookAppName     = "marketplace-3d-factory"
+	webhookAppVersion  = "1.0.0"
+)
+
+type WebhookService interface {
+	Ozon(ctx context.Context, msg ozon.PushMessage) error
+	Yandex(ctx context.Context, n yandex.Notification) error
+}
+
+// WebhookAPI принимает push-уведомления маркетплейсов, ответы в формате, который ждёт каждый маркетплейс
+type WebhookAPI struct {
+	webhookService WebhookService
+	token          string
+}
+
+func NewWebhook(webhooks WebhookService, token string) WebhookAPI {
+	return WebhookAPI{webhookService: webhooks, token: token}
+}
+
+func (a WebhookAPI) Ozon(c *fiber.Ctx) error {
+	ozonError := func(status int, code string, err error) error {
+		return c.Status(status).JSON(ozon.PushErrorResponse{Error: ozon.PushError{Code: code, Message: err.Error()}})
+	}
+
+	if !a.verify(c) {
+		return ozonError(http.StatusUnauthorized, "ERROR_UNKNOWN", errors.New("invalid webhook token"))
+	}
+
+	msg := ozon.PushMessage{}
+	if err := json.Unmarshal(c.Body(), &msg); err != nil {
+		return ozonError(http.StatusBadRequest, "ERROR_PARAMETER_VALUE_MISSED", errors.Wrap(err, "json.Unmarshal"))
+	}
+
+	if msg.MessageType == ozon.PushTypePing {
+		return c.JSON(ozon.PushPingResponse{Version: webhookAppVersion, Name: webhookAppName, Time: time.Now().UTC()})
+	}
+
+	if err := a.webhookService.Ozon(c.UserContext(), msg); err != nil {
+		if errors.Is(err, inbound.ErrUnknownAccount) {
+			return ozonError(http.StatusForbidden, "ERROR_UNKNOWN", err)
+		}
+
+		return ozonError(http.StatusInternalServerError, "ERROR_UNKNOWN", errors.Wrap(err, "webhookService.Ozon"))
+	}
+
+	return c.JSON(ozon.PushResultResponse{Result: true})
+}
+
+func (a WebhookAPI) Yandex(c *fiber.Ctx) error {
+	yandexError := func(status int, errType string, err error) error {
+		return c.Status(status).JSON(yandex.NotificationErrorResponse{Error: yandex.NotificationError{Type: errType, Message: err.Error()}})
+	}
+
+	if !a.verify(c) {
+		return yandexError(http.StatusUnauthorized, "UNKNOWN", errors.New("invalid webhook token"))
+	}
+
+	n := yandex.Notification{}
+	if err := json.Unmarshal(c.Body(), &n); err != nil {
+		return yandexError(http.StatusBadRequest, "WRONG_EVENT_FORMAT", errors.Wrap(err, "json.Unmarshal"))
+	}
+
+	if n.NotificationType == yandex.NotificationPing {
+		return c.JSON(yandex.NotificationPingResponse{Version: webhookAppVersion, Name: webhookAppName, Time: time.Now().UTC()})
+	}
+
+	if err := a.webhookService.Yandex(c.UserContext(), n); err != nil {
+		if errors.Is(err, inbound.ErrUnknownAccount) {
+			return yandexError(http.StatusForbidden, "UNKNOWN", err)
+		}
+
+		return yandexError(http.StatusInternalServerError, "UNKNOWN", errors.Wrap(err, "webhookService.Yandex"))
+	}
+
+	return c.SendStatus(http.StatusOK)
+}
+
+func (a WebhookAPI) verify(c *fiber.Ctx) bool {
+	token := c.Get(webhookTokenHeader)
+	if token == "" {
+		token = c.Params("token")
+	}
+	if token == "" {
+		token = c.Query("token")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1
+}
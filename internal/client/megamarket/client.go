@@ -0,0 +1,86 @@
+package megamarket
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/rest"
+	"github.com/pkg/errors"
+)
+
+const (
+	baseURL    = "https://api.megamarket.tech/api/market/v1"
+	searchPath = "/orderService/order/search"
+	getPath    = "/orderService/order/get"
+
+	// SearchLimit максимальное количество отправлений в ответе поиска, при достижении период нужно дробить
+	SearchLimit = 100
+	// GetBatchSize сколько отправлений можно запросить в одном order/get
+	GetBatchSize = 50
+)
+
+type Client struct {
+	httpClient *rest.Client
+	token      string
+}
+
+func NewClient(token string, log *slog.Logger) Client {
+	return Client{
+		httpClient: rest.NewClient(baseURL).WithLogger(log),
+		token:      token,
+	}
+}
+
+// WithBaseURL для тестов: клиент ходит в локальный сервер вместо API Мегамаркета
+func (c Client) WithBaseURL(baseURL string) Client {
+	c.httpClient = c.httpClient.WithBaseURL(baseURL)
+	return c
+}
+
+// SearchShipments номера отправлений, созданных в период [from, to], пустой statuses - в любом статусе
+func (c Client) SearchShipments(ctx context.Context, from, to time.Time, statuses []string) (SearchResponse, error) {
+	return doRequest[SearchRequest, SearchResponse](ctx, c, searchPath, SearchRequest{
+		Token:    c.token,
+		DateFrom: from,
+		DateTo:   to,
+		Statuses: statuses,
+		Count:    SearchLimit,
+	})
+}
+
+// GetShipments не больше GetBatchSize отправлений за запрос
+func (c Client) GetShipments(ctx context.Context, shipmentIDs []string) (GetResponse, error) {
+	return doRequest[GetRequest, GetResponse](ctx, c, getPath, GetRequest{
+		Token:     c.token,
+		Shipments: shipmentIDs,
+	})
+}
+
+func doRequest[Req, Resp any](ctx context.Context, c Client, path string, data Req) (Resp, error) {
+	var result Resp
+
+	resp, err := c.httpClient.DoRequest(ctx, http.MethodPost, path, request[Req]{Data: data})
+	if err != nil {
+		return result, errors.Wrap(err, "doRequest")
+	}
+	defer resp.Body.Close()
+
+	body, err := rest.ParseBody[response[Resp]](resp)
+	if err != nil {
+		return result, errors.Wrap(err, "rest.ParseBody")
+	}
+
+	if body.Success != 1 {
+		messages := make([]string, 0, len(body.Error))
+		for _, e := range body.Error {
+			messages = append(messages, e.Code+": "+e.Description)
+		}
+
+		return result, errors.Errorf("megamarket error: %s", strings.Join(messages, "; "))
+	}
+
+	return body.Data, nil
+}
@@ -0,0 +1,157 @@
+package megamarket
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixtureServer отвечает записанным ответом API и сохраняет тело последнего запроса
+func fixtureServer(t *testing.T, path, fixture string, lastBody *[]byte) *httptest.Server {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/market/v1"+path {
+			http.NotFound(w, r)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		if lastBody != nil {
+			*lastBody = body
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func newTestClient(srv *httptest.Server) Client {
+	return NewClient("secret-token", slog.New(slog.NewTextHandler(io.Discard, nil))).
+		WithBaseURL(srv.URL + "/api/market/v1")
+}
+
+func TestClient_SearchShipments(t *testing.T) {
+	var body []byte
+	srv := fixtureServer(t, searchPath, "search.json", &body)
+
+	from := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	to := from.Add(7 * 24 * time.Hour)
+	resp, err := newTestClient(srv).SearchShipments(context.Background(), from, to, []string{StatusNew, StatusConfirmed})
+	if err != nil {
+		t.Fatalf("SearchShipments: %v", err)
+	}
+
+	if want := []string{"9158103442", "9158107789"}; !slices.Equal(resp.Shipments, want) {
+		t.Errorf("shipments = %v, want %v", resp.Shipments, want)
+	}
+
+	var req request[SearchRequest]
+	if err = json.Unmarshal(body, &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	if req.Data.Token != "secret-token" {
+		t.Errorf("token = %q, want token in request body", req.Data.Token)
+	}
+	if req.Data.Count != SearchLimit {
+		t.Errorf("count = %d, want %d", req.Data.Count, SearchLimit)
+	}
+	if !req.Data.DateFrom.Equal(from) || !req.Data.DateTo.Equal(to) {
+		t.Errorf("period = [%s, %s], want [%s, %s]", req.Data.DateFrom, req.Data.DateTo, from, to)
+	}
+	if !slices.Equal(req.Data.Statuses, []string{StatusNew, StatusConfirmed}) {
+		t.Errorf("statuses = %v", req.Data.Statuses)
+	}
+}
+
+func TestClient_SearchShipmentsWithoutStatuses(t *testing.T) {
+	var body []byte
+	srv := fixtureServer(t, searchPath, "search.json", &body)
+
+	if _, err := newTestClient(srv).SearchShipments(context.Background(), time.Now().Add(-time.Hour), time.Now(), nil); err != nil {
+		t.Fatalf("SearchShipments: %v", err)
+	}
+
+	if strings.Contains(string(body), "statuses") {
+		t.Errorf("empty statuses must be omitted, body: %s", body)
+	}
+}
+
+func TestClient_GetShipments(t *testing.T) {
+	var body []byte
+	srv := fixtureServer(t, getPath, "get.json", &body)
+
+	resp, err := newTestClient(srv).GetShipments(context.Background(), []string{"9158103442", "9158107789"})
+	if err != nil {
+		t.Fatalf("GetShipments: %v", err)
+	}
+
+	if len(resp.Shipments) != 2 {
+		t.Fatalf("got %d shipments, want 2", len(resp.Shipments))
+	}
+
+	shipment := resp.Shipments[0]
+	if shipment.ShipmentID != "9158103442" || shipment.OrderCode != "MM-4523961" {
+		t.Errorf("shipment = %s/%s", shipment.ShipmentID, shipment.OrderCode)
+	}
+	if want := time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC); !shipment.ShipmentDateFrom.Equal(want) {
+		t.Errorf("shipmentDateFrom = %s, want %s", shipment.ShipmentDateFrom, want)
+	}
+	if len(shipment.Items) != 3 {
+		t.Fatalf("got %d items, want 3", len(shipment.Items))
+	}
+
+	item := shipment.Items[0]
+	if item.OfferID != "dragon-blue" || item.Status != StatusConfirmed || item.FinalPrice != 1161 {
+		t.Errorf("item = %+v", item)
+	}
+	if item.GoodsData.Name != "Фигурка дракон синий 3D" {
+		t.Errorf("goods name = %q", item.GoodsData.Name)
+	}
+
+	var req request[GetRequest]
+	if err = json.Unmarshal(body, &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	if req.Data.Token != "secret-token" || len(req.Data.Shipments) != 2 {
+		t.Errorf("request = %+v", req.Data)
+	}
+}
+
+func TestClient_ErrorEnvelope(t *testing.T) {
+	srv := fixtureServer(t, searchPath, "error.json", nil)
+
+	_, err := newTestClient(srv).SearchShipments(context.Background(), time.Now().Add(-time.Hour), time.Now(), nil)
+	if err == nil {
+		t.Fatal("expected error for success=0")
+	}
+
+	if !strings.Contains(err.Error(), "AUTHENTICATION_ERROR: Неверный токен") {
+		t.Errorf("error = %q, want code and description", err)
+	}
+}
+
+func TestClient_HTTPError(t *testing.T) {
+	srv := fixtureServer(t, "/unknown", "search.json", nil)
+
+	if _, err := newTestClient(srv).GetShipments(context.Background(), []string{"1"}); err == nil {
+		t.Fatal("expected error for http 404")
+	}
+}
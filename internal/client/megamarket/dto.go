@@ -0,0 +1,82 @@
+package megamarket
+
+import "time"
+
+// Статусы позиций отправления
+const (
+	StatusNew              = "NEW"
+	StatusConfirmed        = "CONFIRMED"
+	StatusPacked           = "PACKED"
+	StatusPackingExpired   = "PACKING_EXPIRED"
+	StatusShipped          = "SHIPPED"
+	StatusDelivered        = "DELIVERED"
+	StatusMerchantCanceled = "MERCHANT_CANCELED"
+	StatusCustomerCanceled = "CUSTOMER_CANCELED"
+	StatusShippingExpired  = "SHIPPING_EXPIRED"
+	StatusCustomerRejected = "CUSTOMER_REJECTED"
+	StatusMerchantRejected = "MERCHANT_REJECTED"
+	StatusConfirmedExpired = "CONFIRMED_EXPIRED"
+)
+
+// request конверт всех запросов API: токен передаётся в теле
+type request[T any] struct {
+	Data T        `json:"data"`
+	Meta struct{} `json:"meta"`
+}
+
+// response конверт всех ответов API, success=0 означает ошибку при http 200
+type response[T any] struct {
+	Success int   `json:"success"`
+	Data    T     `json:"data"`
+	Error   []Err `json:"error"`
+}
+
+type Err struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+type SearchRequest struct {
+	Token    string    `json:"token"`
+	DateFrom time.Time `json:"dateFrom"`
+	DateTo   time.Time `json:"dateTo"`
+	Statuses []string  `json:"statuses,omitempty"`
+	Count    int       `json:"count"`
+}
+
+type SearchResponse struct {
+	Shipments []string `json:"shipments"`
+}
+
+type GetRequest struct {
+	Token     string   `json:"token"`
+	Shipments []string `json:"shipments"`
+}
+
+type GetResponse struct {
+	Shipments []Shipment `json:"shipments"`
+}
+
+type Shipment struct {
+	ShipmentID       string    `json:"shipmentId"`
+	OrderCode        string    `json:"orderCode"`
+	CreationDate     time.Time `json:"creationDate"`
+	ShipmentDateFrom time.Time `json:"shipmentDateFrom"`
+	ShipmentDateTo   time.Time `json:"shipmentDateTo"`
+	Items            []Item    `json:"items"`
+}
+
+type Item struct {
+	ItemIndex  string  `json:"itemIndex"`
+	Status     string  `json:"status"`
+	SubStatus  string  `json:"subStatus"`
+	Price      float64 `json:"price"`
+	FinalPrice float64 `json:"finalPrice"`
+	Quantity   int     `json:"quantity"`
+	OfferID    string  `json:"offerId"`
+	GoodsID    string  `json:"goodsId"`
+	GoodsData  struct {
+		Name         string `json:"name"`
+		CategoryName string `json:"categoryName"`
+	} `json:"goodsData"`
+}
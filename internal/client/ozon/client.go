@@ -2,6 +2,7 @@ package ozon
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -13,16 +14,18 @@ const (
 	baseURL         = "https://api-seller.ozon.ru"
 	listPath        = "/v3/product/list"
 	infoListPath    = "/v3/product/info/list"
+	attributesPath  = "/v4/product/info/attributes"
 	postingListPath = "/v3/posting/fbs/unfulfilled/list"
+	fbsListPath     = "/v3/posting/fbs/list"
 )
 
 type Client struct {
 	*rest.Client
 }
 
-func NewClient(apiKey, clientID string) Client {
+func NewClient(apiKey, clientID string, log *slog.Logger) Client {
 	return Client{
-		Client: rest.NewClient(baseURL).WithClientID(clientID).WithApiKey(apiKey),
+		Client: rest.NewClient(baseURL).WithClientID(clientID).WithApiKey(apiKey).WithLogger(log),
 	}
 }
 
@@ -56,6 +59,25 @@ func (c Client) GetProductInfoList(ctx context.Context, productIDs []int64) (Pro
 	return result, nil
 }
 
+// GetProductAttributes характеристики товаров, значения атрибутов без названий - только id атрибута
+func (c Client) GetProductAttributes(ctx context.Context, productIDs []int64) (ProductAttributesResponse, error) {
+	resp, err := c.DoRequest(ctx, http.MethodPost, attributesPath, ProductAttributesRequest{
+		Filter: ProductAttributesFilter{ProductID: productIDs, Visibility: "ALL"},
+		Limit:  len(productIDs),
+	})
+	if err != nil {
+		return ProductAttributesResponse{}, errors.Wrap(err, "doRequest")
+	}
+	defer resp.Body.Close()
+
+	result, err := rest.ParseBody[ProductAttributesResponse](resp)
+	if err != nil {
+		return ProductAttributesResponse{}, errors.Wrap(err, "rest.ParseBody")
+	}
+
+	return result, nil
+}
+
 func (c Client) GetUnfulfilledList(ctx context.Context, status string) (UnfulfilledListResponse, error) {
 	const monthDuration = time.Hour * 24 * 30
 	resp, err := c.DoRequest(ctx, http.MethodPost, postingListPath, UnfulfilledListRequest{
@@ -66,6 +88,7 @@ func (c Client) GetUnfulfilledList(ctx context.Context, status string) (Unfulfil
 			CutoffTo:   time.Now().Add(monthDuration),
 			Status:     status,
 		},
+		With: UnfulfilledListRequestWith{Barcodes: true, FinancialData: true},
 	})
 	if err != nil {
 		return UnfulfilledListResponse{}, errors.Wrap(err, "doRequest")
@@ -79,3 +102,28 @@ func (c Client) GetUnfulfilledList(ctx context.Context, status string) (Unfulfil
 
 	return result, nil
 }
+
+// GetPostingList возвращает отправления FBS в любом статусе, созданные в интервале [since, to]
+func (c Client) GetPostingList(ctx context.Context, since, to time.Time, offset int) (PostingListResponse, error) {
+	resp, err := c.DoRequest(ctx, http.MethodPost, fbsListPath, PostingListRequest{
+		Dir:    "ASC",
+		Limit:  1000,
+		Offset: offset,
+		Filter: PostingListRequestFilter{
+			Since: since,
+			To:    to,
+		},
+		With: PostingListRequestWith{Barcodes: true, FinancialData: true},
+	})
+	if err != nil {
+		return PostingListResponse{}, errors.Wrap(err, "doRequest")
+	}
+	defer resp.Body.Close()
+
+	result, err := rest.ParseBody[PostingListResponse](resp)
+	if err != nil {
+		return PostingListResponse{}, errors.Wrap(err, "rest.ParseBody")
+	}
+
+	return result, nil
+}
@@ -10,6 +10,7 @@ const (
 	StatusDelivering        = "delivering"
 	StatusArbitration       = "arbitration"
 	StatusNotAccepted       = "not_accepted"
+	StatusCancelled         = "cancelled"
 )
 
 type ProductListResponse struct {
@@ -167,19 +168,13 @@ type ProductListInfoResponse struct {
 			Count   int `json:"count"`
 			ModelId int `json:"model_id"`
 		} `json:"model_info"`
-		Name         string   `json:"name"`
-		OfferId      string   `json:"offer_id"`
-		OldPrice     string   `json:"old_price"`
-		Price        string   `json:"price"`
-		PrimaryImage []string `json:"primary_image"`
-		Sources      []struct {
-			CreatedAt    time.Time `json:"created_at"`
-			QuantCode    string    `json:"quant_code"`
-			ShipmentType string    `json:"shipment_type"`
-			Sku          int       `json:"sku"`
-			Source       string    `json:"source"`
-		} `json:"sources"`
-		Statuses struct {
+		Name         string          `json:"name"`
+		OfferId      string          `json:"offer_id"`
+		OldPrice     string          `json:"old_price"`
+		Price        string          `json:"price"`
+		PrimaryImage []string        `json:"primary_image"`
+		Sources      []ProductSource `json:"sources"`
+		Statuses     struct {
 			IsCreated         bool      `json:"is_created"`
 			ModerateStatus    string    `json:"moderate_status"`
 			Status            string    `json:"status"`
@@ -209,6 +204,43 @@ type ProductListInfoResponse struct {
 		VolumeWeight float64 `json:"volume_weight"`
 	} `json:"items"`
 }
+type ProductSource struct {
+	CreatedAt    time.Time `json:"created_at"`
+	QuantCode    string    `json:"quant_code"`
+	ShipmentType string    `json:"shipment_type"`
+	Sku          int       `json:"sku"`
+	Source       string    `json:"source"`
+}
+
+type ProductAttributesRequest struct {
+	Filter ProductAttributesFilter `json:"filter"`
+	Limit  int                     `json:"limit"`
+	LastID string                  `json:"last_id,omitempty"`
+}
+
+type ProductAttributesFilter struct {
+	ProductID  []int64 `json:"product_id"`
+	Visibility string  `json:"visibility"`
+}
+
+type ProductAttributesResponse struct {
+	Result []ProductAttributes `json:"result"`
+	Total  int                 `json:"total"`
+	LastID string              `json:"last_id"`
+}
+
+type ProductAttributes struct {
+	ID         int64  `json:"id"`
+	OfferID    string `json:"offer_id"`
+	Attributes []struct {
+		ID     int64 `json:"id"`
+		Values []struct {
+			DictionaryValueID int64  `json:"dictionary_value_id"`
+			Value             string `json:"value"`
+		} `json:"values"`
+	} `json:"attributes"`
+}
+
 type UnfulfilledListRequest struct {
 	Dir    string `json:"dir"`
 	Limit  int    `json:"limit"`
@@ -234,124 +266,126 @@ type UnfulfilledListRequestWith struct {
 	Translit      bool `json:"translit"`
 }
 
+type Posting struct {
+	PostingNumber  string `json:"posting_number"`
+	OrderID        int64  `json:"order_id"`
+	OrderNumber    string `json:"order_number"`
+	Status         string `json:"status"`
+	DeliveryMethod struct {
+		ID            int64  `json:"id"`
+		Name          string `json:"name"`
+		WarehouseID   int64  `json:"warehouse_id"`
+		Warehouse     string `json:"warehouse"`
+		TplProviderID int    `json:"tpl_provider_id"`
+		TplProvider   string `json:"tpl_provider"`
+	} `json:"delivery_method"`
+	TrackingNumber     string      `json:"tracking_number"`
+	TplIntegrationType string      `json:"tpl_integration_type"`
+	InProcessAt        time.Time   `json:"in_process_at"`
+	ShipmentDate       time.Time   `json:"shipment_date"`
+	DeliveringDate     interface{} `json:"delivering_date"`
+	Cancellation       struct {
+		CancelReasonID           int    `json:"cancel_reason_id"`
+		CancelReason             string `json:"cancel_reason"`
+		CancellationType         string `json:"cancellation_type"`
+		CancelledAfterShip       bool   `json:"cancelled_after_ship"`
+		AffectCancellationRating bool   `json:"affect_cancellation_rating"`
+		CancellationInitiator    string `json:"cancellation_initiator"`
+	} `json:"cancellation"`
+	Customer interface{} `json:"customer"`
+	Products []struct {
+		Price         string   `json:"price"`
+		OfferID       string   `json:"offer_id"`
+		Name          string   `json:"name"`
+		Sku           int      `json:"sku"`
+		Quantity      int      `json:"quantity"`
+		MandatoryMark []string `json:"mandatory_mark"`
+		CurrencyCode  string   `json:"currency_code"`
+	} `json:"products"`
+	Addressee interface{} `json:"addressee"`
+	Barcodes  struct {
+		UpperBarcode string `json:"upper_barcode"`
+		LowerBarcode string `json:"lower_barcode"`
+	} `json:"barcodes"`
+	AnalyticsData struct {
+		Region               string    `json:"region"`
+		City                 string    `json:"city"`
+		DeliveryType         string    `json:"delivery_type"`
+		IsPremium            bool      `json:"is_premium"`
+		PaymentTypeGroupName string    `json:"payment_type_group_name"`
+		WarehouseID          int64     `json:"warehouse_id"`
+		Warehouse            string    `json:"warehouse"`
+		TplProviderID        int       `json:"tpl_provider_id"`
+		TplProvider          string    `json:"tpl_provider"`
+		DeliveryDateBegin    time.Time `json:"delivery_date_begin"`
+		DeliveryDateEnd      time.Time `json:"delivery_date_end"`
+		IsLegal              bool      `json:"is_legal"`
+	} `json:"analytics_data"`
+	FinancialData struct {
+		Products []struct {
+			CommissionAmount     float64     `json:"commission_amount"`
+			CommissionPercent    float64     `json:"commission_percent"`
+			Payout               float64     `json:"payout"`
+			ProductID            int         `json:"product_id"`
+			OldPrice             float64     `json:"old_price"`
+			Price                float64     `json:"price"`
+			TotalDiscountValue   float64     `json:"total_discount_value"`
+			TotalDiscountPercent float64     `json:"total_discount_percent"`
+			Actions              []string    `json:"actions"`
+			Picking              interface{} `json:"picking"`
+			Quantity             int         `json:"quantity"`
+			ClientPrice          string      `json:"client_price"`
+			ItemServices         struct {
+				MarketplaceServiceItemFulfillment                float64 `json:"marketplace_service_item_fulfillment"`
+				MarketplaceServiceItemPickup                     float64 `json:"marketplace_service_item_pickup"`
+				MarketplaceServiceItemDropoffPvz                 float64 `json:"marketplace_service_item_dropoff_pvz"`
+				MarketplaceServiceItemDropoffSc                  float64 `json:"marketplace_service_item_dropoff_sc"`
+				MarketplaceServiceItemDropoffFf                  float64 `json:"marketplace_service_item_dropoff_ff"`
+				MarketplaceServiceItemDirectFlowTrans            float64 `json:"marketplace_service_item_direct_flow_trans"`
+				MarketplaceServiceItemReturnFlowTrans            float64 `json:"marketplace_service_item_return_flow_trans"`
+				MarketplaceServiceItemDelivToCustomer            float64 `json:"marketplace_service_item_deliv_to_customer"`
+				MarketplaceServiceItemReturnNotDelivToCustomer   float64 `json:"marketplace_service_item_return_not_deliv_to_customer"`
+				MarketplaceServiceItemReturnPartGoodsCustomer    float64 `json:"marketplace_service_item_return_part_goods_customer"`
+				MarketplaceServiceItemReturnAfterDelivToCustomer float64 `json:"marketplace_service_item_return_after_deliv_to_customer"`
+			} `json:"item_services"`
+			CurrencyCode string `json:"currency_code"`
+		} `json:"products"`
+		PostingServices struct {
+			MarketplaceServiceItemFulfillment                float64 `json:"marketplace_service_item_fulfillment"`
+			MarketplaceServiceItemPickup                     float64 `json:"marketplace_service_item_pickup"`
+			MarketplaceServiceItemDropoffPvz                 float64 `json:"marketplace_service_item_dropoff_pvz"`
+			MarketplaceServiceItemDropoffSc                  float64 `json:"marketplace_service_item_dropoff_sc"`
+			MarketplaceServiceItemDropoffFf                  float64 `json:"marketplace_service_item_dropoff_ff"`
+			MarketplaceServiceItemDirectFlowTrans            float64 `json:"marketplace_service_item_direct_flow_trans"`
+			MarketplaceServiceItemReturnFlowTrans            float64 `json:"marketplace_service_item_return_flow_trans"`
+			MarketplaceServiceItemDelivToCustomer            float64 `json:"marketplace_service_item_deliv_to_customer"`
+			MarketplaceServiceItemReturnNotDelivToCustomer   float64 `json:"marketplace_service_item_return_not_deliv_to_customer"`
+			MarketplaceServiceItemReturnPartGoodsCustomer    float64 `json:"marketplace_service_item_return_part_goods_customer"`
+			MarketplaceServiceItemReturnAfterDelivToCustomer float64 `json:"marketplace_service_item_return_after_deliv_to_customer"`
+		} `json:"posting_services"`
+		ClusterFrom string `json:"cluster_from"`
+		ClusterTo   string `json:"cluster_to"`
+	} `json:"financial_data"`
+	IsExpress    bool `json:"is_express"`
+	Requirements struct {
+		ProductsRequiringGtd           []interface{} `json:"products_requiring_gtd"`
+		ProductsRequiringCountry       []interface{} `json:"products_requiring_country"`
+		ProductsRequiringMandatoryMark []interface{} `json:"products_requiring_mandatory_mark"`
+		ProductsRequiringRnpt          []interface{} `json:"products_requiring_rnpt"`
+		ProductsRequiringJwUin         []interface{} `json:"products_requiring_jw_uin"`
+	} `json:"requirements"`
+	ParentPostingNumber string   `json:"parent_posting_number"`
+	AvailableActions    []string `json:"available_actions"`
+	MultiBoxQty         int      `json:"multi_box_qty"`
+	IsMultibox          bool     `json:"is_multibox"`
+	Substatus           string   `json:"substatus"`
+	PrrOption           string   `json:"prr_option"`
+}
+
 type UnfulfilledListResponse struct {
 	Result struct {
-		Postings []struct {
-			PostingNumber  string `json:"posting_number"`
-			OrderID        int64  `json:"order_id"`
-			OrderNumber    string `json:"order_number"`
-			Status         string `json:"status"`
-			DeliveryMethod struct {
-				ID            int64  `json:"id"`
-				Name          string `json:"name"`
-				WarehouseID   int64  `json:"warehouse_id"`
-				Warehouse     string `json:"warehouse"`
-				TplProviderID int    `json:"tpl_provider_id"`
-				TplProvider   string `json:"tpl_provider"`
-			} `json:"delivery_method"`
-			TrackingNumber     string      `json:"tracking_number"`
-			TplIntegrationType string      `json:"tpl_integration_type"`
-			InProcessAt        time.Time   `json:"in_process_at"`
-			ShipmentDate       time.Time   `json:"shipment_date"`
-			DeliveringDate     interface{} `json:"delivering_date"`
-			Cancellation       struct {
-				CancelReasonID           int    `json:"cancel_reason_id"`
-				CancelReason             string `json:"cancel_reason"`
-				CancellationType         string `json:"cancellation_type"`
-				CancelledAfterShip       bool   `json:"cancelled_after_ship"`
-				AffectCancellationRating bool   `json:"affect_cancellation_rating"`
-				CancellationInitiator    string `json:"cancellation_initiator"`
-			} `json:"cancellation"`
-			Customer interface{} `json:"customer"`
-			Products []struct {
-				Price         string   `json:"price"`
-				OfferID       string   `json:"offer_id"`
-				Name          string   `json:"name"`
-				Sku           int      `json:"sku"`
-				Quantity      int      `json:"quantity"`
-				MandatoryMark []string `json:"mandatory_mark"`
-				CurrencyCode  string   `json:"currency_code"`
-			} `json:"products"`
-			Addressee interface{} `json:"addressee"`
-			Barcodes  struct {
-				UpperBarcode string `json:"upper_barcode"`
-				LowerBarcode string `json:"lower_barcode"`
-			} `json:"barcodes"`
-			AnalyticsData struct {
-				Region               string    `json:"region"`
-				City                 string    `json:"city"`
-				DeliveryType         string    `json:"delivery_type"`
-				IsPremium            bool      `json:"is_premium"`
-				PaymentTypeGroupName string    `json:"payment_type_group_name"`
-				WarehouseID          int64     `json:"warehouse_id"`
-				Warehouse            string    `json:"warehouse"`
-				TplProviderID        int       `json:"tpl_provider_id"`
-				TplProvider          string    `json:"tpl_provider"`
-				DeliveryDateBegin    time.Time `json:"delivery_date_begin"`
-				DeliveryDateEnd      time.Time `json:"delivery_date_end"`
-				IsLegal              bool      `json:"is_legal"`
-			} `json:"analytics_data"`
-			FinancialData struct {
-				Products []struct {
-					CommissionAmount     int         `json:"commission_amount"`
-					CommissionPercent    int         `json:"commission_percent"`
-					Payout               int         `json:"payout"`
-					ProductID            int         `json:"product_id"`
-					OldPrice             int         `json:"old_price"`
-					Price                int         `json:"price"`
-					TotalDiscountValue   int         `json:"total_discount_value"`
-					TotalDiscountPercent float64     `json:"total_discount_percent"`
-					Actions              []string    `json:"actions"`
-					Picking              interface{} `json:"picking"`
-					Quantity             int         `json:"quantity"`
-					ClientPrice          string      `json:"client_price"`
-					ItemServices         struct {
-						MarketplaceServiceItemFulfillment                int `json:"marketplace_service_item_fulfillment"`
-						MarketplaceServiceItemPickup                     int `json:"marketplace_service_item_pickup"`
-						MarketplaceServiceItemDropoffPvz                 int `json:"marketplace_service_item_dropoff_pvz"`
-						MarketplaceServiceItemDropoffSc                  int `json:"marketplace_service_item_dropoff_sc"`
-						MarketplaceServiceItemDropoffFf                  int `json:"marketplace_service_item_dropoff_ff"`
-						MarketplaceServiceItemDirectFlowTrans            int `json:"marketplace_service_item_direct_flow_trans"`
-						MarketplaceServiceItemReturnFlowTrans            int `json:"marketplace_service_item_return_flow_trans"`
-						MarketplaceServiceItemDelivToCustomer            int `json:"marketplace_service_item_deliv_to_customer"`
-						MarketplaceServiceItemReturnNotDelivToCustomer   int `json:"marketplace_service_item_return_not_deliv_to_customer"`
-						MarketplaceServiceItemReturnPartGoodsCustomer    int `json:"marketplace_service_item_return_part_goods_customer"`
-						MarketplaceServiceItemReturnAfterDelivToCustomer int `json:"marketplace_service_item_return_after_deliv_to_customer"`
-					} `json:"item_services"`
-					CurrencyCode string `json:"currency_code"`
-				} `json:"products"`
-				PostingServices struct {
-					MarketplaceServiceItemFulfillment                int `json:"marketplace_service_item_fulfillment"`
-					MarketplaceServiceItemPickup                     int `json:"marketplace_service_item_pickup"`
-					MarketplaceServiceItemDropoffPvz                 int `json:"marketplace_service_item_dropoff_pvz"`
-					MarketplaceServiceItemDropoffSc                  int `json:"marketplace_service_item_dropoff_sc"`
-					MarketplaceServiceItemDropoffFf                  int `json:"marketplace_service_item_dropoff_ff"`
-					MarketplaceServiceItemDirectFlowTrans            int `json:"marketplace_service_item_direct_flow_trans"`
-					MarketplaceServiceItemReturnFlowTrans            int `json:"marketplace_service_item_return_flow_trans"`
-					MarketplaceServiceItemDelivToCustomer            int `json:"marketplace_service_item_deliv_to_customer"`
-					MarketplaceServiceItemReturnNotDelivToCustomer   int `json:"marketplace_service_item_return_not_deliv_to_customer"`
-					MarketplaceServiceItemReturnPartGoodsCustomer    int `json:"marketplace_service_item_return_part_goods_customer"`
-					MarketplaceServiceItemReturnAfterDelivToCustomer int `json:"marketplace_service_item_return_after_deliv_to_customer"`
-				} `json:"posting_services"`
-				ClusterFrom string `json:"cluster_from"`
-				ClusterTo   string `json:"cluster_to"`
-			} `json:"financial_data"`
-			IsExpress    bool `json:"is_express"`
-			Requirements struct {
-				ProductsRequiringGtd           []interface{} `json:"products_requiring_gtd"`
-				ProductsRequiringCountry       []interface{} `json:"products_requiring_country"`
-				ProductsRequiringMandatoryMark []interface{} `json:"products_requiring_mandatory_mark"`
-				ProductsRequiringRnpt          []interface{} `json:"products_requiring_rnpt"`
-				ProductsRequiringJwUin         []interface{} `json:"products_requiring_jw_uin"`
-			} `json:"requirements"`
-			ParentPostingNumber string   `json:"parent_posting_number"`
-			AvailableActions    []string `json:"available_actions"`
-			MultiBoxQty         int      `json:"multi_box_qty"`
-			IsMultibox          bool     `json:"is_multibox"`
-			Substatus           string   `json:"substatus"`
-			PrrOption           string   `json:"prr_option"`
-		} `json:"postings"`
-		Count int `json:"count"`
+		Postings []Posting `json:"postings"`
+		Count    int       `json:"count"`
 	} `json:"result"`
 }
 
@@ -361,3 +395,29 @@ type ProductListRequest struct {
 	LastId string `json:"last_id"`
 	Limit  int    `json:"limit"`
 }
+
+type PostingListRequest struct {
+	Dir    string                   `json:"dir"`
+	Limit  int                      `json:"limit"`
+	Offset int                      `json:"offset"`
+	Filter PostingListRequestFilter `json:"filter"`
+	With   PostingListRequestWith   `json:"with"`
+}
+
+type PostingListRequestWith struct {
+	Barcodes      bool `json:"barcodes"`
+	FinancialData bool `json:"financial_data"`
+}
+
+type PostingListRequestFilter struct {
+	Since  time.Time `json:"since"`
+	To     time.Time `json:"to"`
+	Status string    `json:"status,omitempty"`
+}
+
+type PostingListResponse struct {
+	Result struct {
+		Postings []Posting `json:"postings"`
+		HasNext  bool      `json:"has_next"`
+	} `json:"result"`
+}
@@ -0,0 +1,42 @@
+package ozon
+
+import "time"
+
+// типы push-уведомлений Ozon, остальные типы сервису не интересны
+const (
+	PushTypePing             = "TYPE_PING"
+	PushTypeNewPosting       = "TYPE_NEW_POSTING"
+	PushTypePostingCancelled = "TYPE_POSTING_CANCELLED"
+	PushTypeStateChanged     = "TYPE_STATE_CHANGED"
+)
+
+// PushMessage общее тело push-уведомления, поля заполнены в зависимости от MessageType
+type PushMessage struct {
+	MessageType   string    `json:"message_type"`
+	Time          time.Time `json:"time"`
+	PostingNumber string    `json:"posting_number"`
+	NewState      string    `json:"new_state"`
+	SellerID      int64     `json:"seller_id"`
+	WarehouseID   int64     `json:"warehouse_id"`
+}
+
+// PushPingResponse ответ на проверку адреса из личного кабинета
+type PushPingResponse struct {
+	Version string    `json:"version"`
+	Name    string    `json:"name"`
+	Time    time.Time `json:"time"`
+}
+
+type PushResultResponse struct {
+	Result bool `json:"result"`
+}
+
+type PushErrorResponse struct {
+	Error PushError `json:"error"`
+}
+
+type PushError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details"`
+}
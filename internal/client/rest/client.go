@@ -5,15 +5,18 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/alleswebdev/marketplace-3d-factory/internal/metrics"
 	"github.com/pkg/errors"
 )
 
 type Client struct {
 	httpClient http.Client
 	baseURL    string
+	log        *slog.Logger
 
 	apiKey      string
 	clientID    string
@@ -27,9 +30,21 @@ func NewClient(baseURL string) *Client {
 			Timeout: 30 * time.Second,
 		},
 		baseURL: baseURL,
+		log:     slog.Default(),
 	}
 }
 
+// WithBaseURL адрес API, для тестов - локальный сервер с записанными ответами
+func (c *Client) WithBaseURL(value string) *Client {
+	c.baseURL = value
+	return c
+}
+
+func (c *Client) WithLogger(log *slog.Logger) *Client {
+	c.log = log
+	return c
+}
+
 func (c *Client) WithApiKey(value string) *Client {
 	c.apiKey = value
 	return c
@@ -56,11 +71,22 @@ func (c *Client) DoRequest(ctx context.Context, method, path string, body interf
 		return nil, errors.Wrap(err, "makeRequest")
 	}
 
+	startedAt := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		metrics.ObserveAPIRequest(req.URL.Host, method, 0, time.Since(startedAt))
 		return nil, errors.Wrap(err, "httpClient.Do")
 	}
 
+	metrics.ObserveAPIRequest(req.URL.Host, method, resp.StatusCode, time.Since(startedAt))
+	c.log.DebugContext(ctx, "marketplace request",
+		slog.String("host", req.URL.Host),
+		slog.String("method", method),
+		slog.String("path", req.URL.Path),
+		slog.Int("status", resp.StatusCode),
+		slog.Duration("duration", time.Since(startedAt)),
+	)
+
 	return resp, nil
 }
 
@@ -0,0 +1,102 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/metrics"
+	"github.com/pkg/errors"
+)
+
+const (
+	// httpTimeout больше таймаута long polling в getUpdates
+	httpTimeout = time.Minute
+)
+
+// Client ходит в Bot API напрямую, а не через rest.Client: токен входит в путь запроса и не должен попадать в логи
+type Client struct {
+	httpClient http.Client
+	baseURL    string
+	token      string
+	log        *slog.Logger
+}
+
+// NewClient baseURL обычно https://api.telegram.org, для тестов - адрес локального фейкового Bot API
+func NewClient(baseURL, token string, log *slog.Logger) Client {
+	return Client{
+		httpClient: http.Client{Timeout: httpTimeout},
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		log:        log,
+	}
+}
+
+func (c Client) SendMessage(ctx context.Context, req SendMessageRequest) (Message, error) {
+	return call[Message](ctx, c, "sendMessage", req)
+}
+
+func (c Client) SendPhoto(ctx context.Context, req SendPhotoRequest) (Message, error) {
+	return call[Message](ctx, c, "sendPhoto", req)
+}
+
+func (c Client) GetUpdates(ctx context.Context, req GetUpdatesRequest) ([]Update, error) {
+	return call[[]Update](ctx, c, "getUpdates", req)
+}
+
+func (c Client) AnswerCallbackQuery(ctx context.Context, req AnswerCallbackQueryRequest) error {
+	_, err := call[bool](ctx, c, "answerCallbackQuery", req)
+	return err
+}
+
+func call[T any](ctx context.Context, c Client, method string, data any) (T, error) {
+	var result T
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return result, errors.Wrap(err, "json.Marshal")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/bot"+c.token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return result, errors.Wrap(err, "http.NewRequestWithContext")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	startedAt := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		metrics.ObserveAPIRequest(req.URL.Host, http.MethodPost, 0, time.Since(startedAt))
+		// в тексте ошибки net/http есть полный адрес вместе с токеном
+		return result, errors.Errorf("httpClient.Do %s: %s", method, strings.ReplaceAll(err.Error(), c.token, "***"))
+	}
+	defer resp.Body.Close()
+
+	metrics.ObserveAPIRequest(req.URL.Host, http.MethodPost, resp.StatusCode, time.Since(startedAt))
+	c.log.DebugContext(ctx, "telegram request",
+		slog.String("method", method),
+		slog.Int("status", resp.StatusCode),
+		slog.Duration("duration", time.Since(startedAt)),
+	)
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return result, errors.Wrap(err, "io.ReadAll")
+	}
+
+	var parsed response[T]
+	if err = json.Unmarshal(raw, &parsed); err != nil {
+		return result, errors.Wrapf(err, "json.Unmarshal, http status:%d", resp.StatusCode)
+	}
+
+	if !parsed.Ok {
+		return result, &APIError{Code: parsed.ErrorCode, Description: parsed.Description, RetryAfter: parsed.Parameters.RetryAfter}
+	}
+
+	return parsed.Result, nil
+}
@@ -0,0 +1,97 @@
+package telegram
+
+import "fmt"
+
+const ParseModeHTML = "HTML"
+
+type response[T any] struct {
+	Ok          bool   `json:"ok"`
+	Result      T      `json:"result"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// APIError ответ Bot API с ok=false
+type APIError struct {
+	Code        int
+	Description string
+	RetryAfter  int
+}
+
+func (e *APIError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("telegram: %d %s, retry after %ds", e.Code, e.Description, e.RetryAfter)
+	}
+
+	return fmt.Sprintf("telegram: %d %s", e.Code, e.Description)
+}
+
+type (
+	InlineKeyboardMarkup struct {
+		InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+	}
+
+	InlineKeyboardButton struct {
+		Text         string `json:"text"`
+		CallbackData string `json:"callback_data,omitempty"`
+	}
+
+	SendMessageRequest struct {
+		ChatID      int64                 `json:"chat_id"`
+		Text        string                `json:"text"`
+		ParseMode   string                `json:"parse_mode,omitempty"`
+		ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	}
+
+	// SendPhotoRequest Photo - URL картинки, Telegram скачивает её сам
+	SendPhotoRequest struct {
+		ChatID      int64                 `json:"chat_id"`
+		Photo       string                `json:"photo"`
+		Caption     string                `json:"caption,omitempty"`
+		ParseMode   string                `json:"parse_mode,omitempty"`
+		ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	}
+
+	GetUpdatesRequest struct {
+		Offset         int64    `json:"offset,omitempty"`
+		Timeout        int      `json:"timeout,omitempty"`
+		AllowedUpdates []string `json:"allowed_updates,omitempty"`
+	}
+
+	AnswerCallbackQueryRequest struct {
+		CallbackQueryID string `json:"callback_query_id"`
+		Text            string `json:"text,omitempty"`
+		ShowAlert       bool   `json:"show_alert,omitempty"`
+	}
+)
+
+type (
+	Update struct {
+		UpdateID      int64          `json:"update_id"`
+		CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+	}
+
+	CallbackQuery struct {
+		ID      string   `json:"id"`
+		From    User     `json:"from"`
+		Message *Message `json:"message,omitempty"`
+		Data    string   `json:"data"`
+	}
+
+	User struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	}
+
+	Chat struct {
+		ID int64 `json:"id"`
+	}
+
+	Message struct {
+		MessageID int64 `json:"message_id"`
+		Chat      Chat  `json:"chat"`
+	}
+)
@@ -3,8 +3,10 @@ package wb
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/alleswebdev/marketplace-3d-factory/internal/client/rest"
 	"github.com/pkg/errors"
@@ -14,6 +16,7 @@ const (
 	marketplaceApiUrl = "https://marketplace-api.wildberries.ru"
 	contentApiUrl     = "https://content-api.wildberries.ru"
 	newOrdersPath     = "/api/v3/orders/new"
+	ordersPath        = "/api/v3/orders?limit=1000&next=%d&dateFrom=%d"
 	supplyOrdersPath  = "/api/v3/supplies/%s/orders"
 	ordersStatusPath  = "/api/v3/orders/status"
 	getCardsPath      = "/content/v2/get/cards/list?locale=ru"
@@ -24,10 +27,10 @@ type Client struct {
 	contentClient     *rest.Client
 }
 
-func NewClient(token string) Client {
+func NewClient(token string, log *slog.Logger) Client {
 	return Client{
-		marketplaceClient: rest.NewClient(marketplaceApiUrl).WithToken(token),
-		contentClient:     rest.NewClient(contentApiUrl).WithToken(token),
+		marketplaceClient: rest.NewClient(marketplaceApiUrl).WithToken(token).WithLogger(log),
+		contentClient:     rest.NewClient(contentApiUrl).WithToken(token).WithLogger(log),
 	}
 }
 
@@ -46,6 +49,23 @@ func (c Client) GetNewOrders(ctx context.Context) (OrdersResponse, error) {
 	return result, nil
 }
 
+// GetOrders возвращает сборочные задания, созданные начиная с dateFrom, постранично через next
+func (c Client) GetOrders(ctx context.Context, dateFrom time.Time, next int) (OrdersResponse, error) {
+	path := fmt.Sprintf(ordersPath, next, dateFrom.Unix())
+	resp, err := c.marketplaceClient.DoRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return OrdersResponse{}, errors.Wrap(err, "doRequest")
+	}
+	defer resp.Body.Close()
+
+	result, err := rest.ParseBody[OrdersResponse](resp)
+	if err != nil {
+		return OrdersResponse{}, errors.Wrap(err, "rest.ParseBody")
+	}
+
+	return result, nil
+}
+
 func (c Client) GetCardsList(ctx context.Context, cursor CardListCursor) (CardsListResponse, error) {
 	resp, err := c.contentClient.DoRequest(ctx, http.MethodPost, getCardsPath, CardListRequest{
 		CardListSettings: CardListSettings{
@@ -4,6 +4,11 @@ import (
 	"time"
 )
 
+const (
+	SupplierStatusNew     = "new"
+	SupplierStatusConfirm = "confirm"
+)
+
 type Order struct {
 	Address               interface{} `json:"address"`
 	DeliveryType          string      `json:"deliveryType"`
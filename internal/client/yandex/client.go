@@ -2,7 +2,10 @@ package yandex
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/alleswebdev/marketplace-3d-factory/internal/client/rest"
 	"github.com/pkg/errors"
@@ -18,9 +21,9 @@ type Client struct {
 	campaignID string
 }
 
-func NewClient(token, campaignID, businessID string) Client {
+func NewClient(token, campaignID, businessID string, log *slog.Logger) Client {
 	return Client{
-		httpClient: rest.NewClient(baseURL).WithBearerToken(token),
+		httpClient: rest.NewClient(baseURL).WithBearerToken(token).WithLogger(log),
 		businessID: businessID,
 		campaignID: campaignID,
 	}
@@ -57,3 +60,20 @@ func (c Client) GetOrders(ctx context.Context, status string) (OrdersDTO, error)
 
 	return result, nil
 }
+
+// GetOrdersSince возвращает заказы в любом статусе начиная с fromDate, page начинается с 1
+func (c Client) GetOrdersSince(ctx context.Context, fromDate time.Time, page int) (OrdersDTO, error) {
+	path := "/campaigns/" + c.campaignID + "/orders?fromDate=" + fromDate.Format("02-01-2006") + "&page=" + strconv.Itoa(page)
+	resp, err := c.httpClient.DoRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return OrdersDTO{}, errors.Wrap(err, "doRequest")
+	}
+	defer resp.Body.Close()
+
+	result, err := rest.ParseBody[OrdersDTO](resp)
+	if err != nil {
+		return OrdersDTO{}, errors.Wrap(err, "rest.ParseBody")
+	}
+
+	return result, nil
+}
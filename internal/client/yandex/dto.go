@@ -2,6 +2,12 @@ package yandex
 
 import "time"
 
+const (
+	StatusProcessing = "PROCESSING"
+	SubstatusShipped = "SHIPPED"
+	StatusCancelled  = "CANCELLED"
+)
+
 type OfferMappingsDTO struct {
 	Status string `json:"status"`
 	Result struct {
@@ -87,57 +93,66 @@ type OrdersDTO struct {
 		PagesCount  int `json:"pagesCount"`
 		PageSize    int `json:"pageSize"`
 	} `json:"pager"`
-	Orders []struct {
-		Id                            int     `json:"id"`
-		Status                        string  `json:"status"`
-		Substatus                     string  `json:"substatus"`
-		CreationDate                  string  `json:"creationDate"`
-		UpdatedAt                     string  `json:"updatedAt"`
-		Currency                      string  `json:"currency"`
-		ItemsTotal                    float64 `json:"itemsTotal"`
-		DeliveryTotal                 float64 `json:"deliveryTotal"`
-		BuyerItemsTotal               float64 `json:"buyerItemsTotal"`
-		BuyerTotal                    float64 `json:"buyerTotal"`
-		BuyerItemsTotalBeforeDiscount float64 `json:"buyerItemsTotalBeforeDiscount"`
-		BuyerTotalBeforeDiscount      float64 `json:"buyerTotalBeforeDiscount"`
-		PaymentType                   string  `json:"paymentType"`
-		PaymentMethod                 string  `json:"paymentMethod"`
-		Fake                          bool    `json:"fake"`
-		Items                         []struct {
-			Id                       int     `json:"id"`
-			OfferId                  string  `json:"offerId"`
-			OfferName                string  `json:"offerName"`
-			Price                    float64 `json:"price"`
-			BuyerPrice               float64 `json:"buyerPrice"`
-			BuyerPriceBeforeDiscount float64 `json:"buyerPriceBeforeDiscount"`
-			PriceBeforeDiscount      float64 `json:"priceBeforeDiscount"`
-			Count                    int     `json:"count"`
-			Vat                      string  `json:"vat"`
-			ShopSku                  string  `json:"shopSku"`
-			Promos                   []struct {
-				Type    string  `json:"type"`
-				Subsidy float64 `json:"subsidy"`
-			} `json:"promos,omitempty"`
-			Subsidies []struct {
-				Type   string  `json:"type"`
-				Amount float64 `json:"amount"`
-			} `json:"subsidies,omitempty"`
-		} `json:"items"`
+	Orders []Order `json:"orders"`
+	Paging struct {
+	} `json:"paging"`
+}
+
+type Order struct {
+	Id                            int     `json:"id"`
+	Status                        string  `json:"status"`
+	Substatus                     string  `json:"substatus"`
+	CreationDate                  string  `json:"creationDate"`
+	UpdatedAt                     string  `json:"updatedAt"`
+	Currency                      string  `json:"currency"`
+	ItemsTotal                    float64 `json:"itemsTotal"`
+	DeliveryTotal                 float64 `json:"deliveryTotal"`
+	BuyerItemsTotal               float64 `json:"buyerItemsTotal"`
+	BuyerTotal                    float64 `json:"buyerTotal"`
+	BuyerItemsTotalBeforeDiscount float64 `json:"buyerItemsTotalBeforeDiscount"`
+	BuyerTotalBeforeDiscount      float64 `json:"buyerTotalBeforeDiscount"`
+	PaymentType                   string  `json:"paymentType"`
+	PaymentMethod                 string  `json:"paymentMethod"`
+	Fake                          bool    `json:"fake"`
+	Items                         []struct {
+		Id                       int     `json:"id"`
+		OfferId                  string  `json:"offerId"`
+		OfferName                string  `json:"offerName"`
+		Price                    float64 `json:"price"`
+		BuyerPrice               float64 `json:"buyerPrice"`
+		BuyerPriceBeforeDiscount float64 `json:"buyerPriceBeforeDiscount"`
+		PriceBeforeDiscount      float64 `json:"priceBeforeDiscount"`
+		Count                    int     `json:"count"`
+		Vat                      string  `json:"vat"`
+		ShopSku                  string  `json:"shopSku"`
+		Promos                   []struct {
+			Type    string  `json:"type"`
+			Subsidy float64 `json:"subsidy"`
+		} `json:"promos,omitempty"`
 		Subsidies []struct {
 			Type   string  `json:"type"`
 			Amount float64 `json:"amount"`
 		} `json:"subsidies,omitempty"`
-		Delivery struct {
-			Type                string `json:"type"`
-			ServiceName         string `json:"serviceName"`
-			DeliveryPartnerType string `json:"deliveryPartnerType"`
-			Dates               struct {
-				FromDate string `json:"fromDate"`
-				ToDate   string `json:"toDate"`
-				FromTime string `json:"fromTime"`
-				ToTime   string `json:"toTime"`
-			} `json:"dates"`
-			Region struct {
+	} `json:"items"`
+	Subsidies []struct {
+		Type   string  `json:"type"`
+		Amount float64 `json:"amount"`
+	} `json:"subsidies,omitempty"`
+	Delivery struct {
+		Type                string `json:"type"`
+		ServiceName         string `json:"serviceName"`
+		DeliveryPartnerType string `json:"deliveryPartnerType"`
+		Dates               struct {
+			FromDate string `json:"fromDate"`
+			ToDate   string `json:"toDate"`
+			FromTime string `json:"fromTime"`
+			ToTime   string `json:"toTime"`
+		} `json:"dates"`
+		Region struct {
+			Id     int    `json:"id"`
+			Name   string `json:"name"`
+			Type   string `json:"type"`
+			Parent struct {
 				Id     int    `json:"id"`
 				Name   string `json:"name"`
 				Type   string `json:"type"`
@@ -154,55 +169,48 @@ type OrdersDTO struct {
 							Name   string `json:"name"`
 							Type   string `json:"type"`
 							Parent struct {
-								Id     int    `json:"id"`
-								Name   string `json:"name"`
-								Type   string `json:"type"`
-								Parent struct {
-									Id   int    `json:"id"`
-									Name string `json:"name"`
-									Type string `json:"type"`
-								} `json:"parent,omitempty"`
+								Id   int    `json:"id"`
+								Name string `json:"name"`
+								Type string `json:"type"`
 							} `json:"parent,omitempty"`
-						} `json:"parent"`
+						} `json:"parent,omitempty"`
 					} `json:"parent"`
 				} `json:"parent"`
-			} `json:"region"`
-			Address struct {
-				Country    string `json:"country"`
-				Postcode   string `json:"postcode"`
-				City       string `json:"city"`
-				Street     string `json:"street"`
-				House      string `json:"house"`
-				Entrance   string `json:"entrance,omitempty"`
-				Entryphone string `json:"entryphone,omitempty"`
-				Floor      string `json:"floor,omitempty"`
-				Apartment  string `json:"apartment,omitempty"`
-				Gps        struct {
-					Latitude  float64 `json:"latitude"`
-					Longitude float64 `json:"longitude"`
-				} `json:"gps"`
-			} `json:"address"`
-			DeliveryServiceId int     `json:"deliveryServiceId"`
-			LiftPrice         float64 `json:"liftPrice"`
-			Shipments         []struct {
+			} `json:"parent"`
+		} `json:"region"`
+		Address struct {
+			Country    string `json:"country"`
+			Postcode   string `json:"postcode"`
+			City       string `json:"city"`
+			Street     string `json:"street"`
+			House      string `json:"house"`
+			Entrance   string `json:"entrance,omitempty"`
+			Entryphone string `json:"entryphone,omitempty"`
+			Floor      string `json:"floor,omitempty"`
+			Apartment  string `json:"apartment,omitempty"`
+			Gps        struct {
+				Latitude  float64 `json:"latitude"`
+				Longitude float64 `json:"longitude"`
+			} `json:"gps"`
+		} `json:"address"`
+		DeliveryServiceId int     `json:"deliveryServiceId"`
+		LiftPrice         float64 `json:"liftPrice"`
+		Shipments         []struct {
+			Id           int    `json:"id"`
+			ShipmentDate string `json:"shipmentDate"`
+			Boxes        []struct {
 				Id           int    `json:"id"`
-				ShipmentDate string `json:"shipmentDate"`
-				Boxes        []struct {
-					Id           int    `json:"id"`
-					FulfilmentId string `json:"fulfilmentId"`
-				} `json:"boxes"`
-			} `json:"shipments"`
-			OutletCode string `json:"outletCode,omitempty"`
-		} `json:"delivery"`
-		Buyer struct {
-			Type string `json:"type"`
-		} `json:"buyer"`
-		TaxSystem       string `json:"taxSystem"`
-		CancelRequested bool   `json:"cancelRequested"`
-		Notes           string `json:"notes,omitempty"`
-	} `json:"orders"`
-	Paging struct {
-	} `json:"paging"`
+				FulfilmentId string `json:"fulfilmentId"`
+			} `json:"boxes"`
+		} `json:"shipments"`
+		OutletCode string `json:"outletCode,omitempty"`
+	} `json:"delivery"`
+	Buyer struct {
+		Type string `json:"type"`
+	} `json:"buyer"`
+	TaxSystem       string `json:"taxSystem"`
+	CancelRequested bool   `json:"cancelRequested"`
+	Notes           string `json:"notes,omitempty"`
 }
 
 type GetProductListRequest struct {
@@ -0,0 +1,35 @@
+package yandex
+
+import "time"
+
+// типы уведомлений Push API Маркета, остальные типы сервису не интересны
+const (
+	NotificationPing               = "PING"
+	NotificationOrderCreated       = "ORDER_CREATED"
+	NotificationOrderCancelled     = "ORDER_CANCELLED"
+	NotificationOrderStatusUpdated = "ORDER_STATUS_UPDATED"
+)
+
+type Notification struct {
+	NotificationType string `json:"notificationType"`
+	CampaignID       int64  `json:"campaignId"`
+	OrderID          int64  `json:"orderId"`
+	Status           string `json:"status"`
+	Substatus        string `json:"substatus"`
+}
+
+// NotificationPingResponse ответ на проверку адреса из кабинета
+type NotificationPingResponse struct {
+	Version string    `json:"version"`
+	Name    string    `json:"name"`
+	Time    time.Time `json:"time"`
+}
+
+type NotificationErrorResponse struct {
+	Error NotificationError `json:"error"`
+}
+
+type NotificationError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
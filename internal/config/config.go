@@ -1,11 +1,32 @@
 package config
 
 import (
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/webhook"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 )
 
+const (
+	// EnvPrefix переменные окружения вида FACTORY_WB_TOKEN перекрывают значения из values.yaml
+	EnvPrefix = "FACTORY"
+	// DefaultAccount имя кабинета, собранного из одиночных полей WbToken, OzonToken, YandexToken, MegamarketToken
+	DefaultAccount = "default"
+
+	// minWebhookTokenLen токен виден в адресе уведомлений, короткий легко подобрать
+	minWebhookTokenLen = 16
+)
+
 type Config struct {
 	Port         int
 	Host         string
@@ -18,25 +39,480 @@ type Config struct {
 	YandexToken      string
 	YandexBusinessID string
 	YandexCompaignID string
+
+	MegamarketToken string
+
+	// WbAccounts, OzonAccounts, YandexAccounts, MegamarketAccounts кабинеты продавца, в env задаются JSON массивом
+	WbAccounts         []WbAccount
+	OzonAccounts       []OzonAccount
+	YandexAccounts     []YandexAccount
+	MegamarketAccounts []MegamarketAccount
+
+	// WbEnabled, OzonEnabled, YandexEnabled, MegamarketEnabled если не заданы явно, маркетплейс включается при наличии кабинетов
+	WbEnabled         bool
+	OzonEnabled       bool
+	YandexEnabled     bool
+	MegamarketEnabled bool
+
+	// LogLevel debug|info|warn|error
+	LogLevel string
+	// LogFormat text|json
+	LogFormat string
+
+	// Timezone граница суток для сводок бота и отчётов, например Europe/Moscow
+	Timezone string
+
+	// AutoMigrate накатывает встроенные миграции при старте сервера
+	AutoMigrate bool
+
+	OrdersInterval   time.Duration
+	SuppliesInterval time.Duration
+	CardsInterval    time.Duration
+
+	// WebhookToken включает приём push-уведомлений Ozon и Яндекса, передаётся в адресе уведомлений
+	WebhookToken string
+	// WebhookReconcileInterval с включёнными уведомлениями заказы Ozon и Яндекса опрашиваются только для сверки
+	WebhookReconcileInterval time.Duration
+
+	// WebhookEndpoints получатели исходящих вебхуков о событиях очереди, в env JSON массивом
+	WebhookEndpoints []WebhookEndpoint
+	// WebhooksInterval как часто доставлять накопленные события
+	WebhooksInterval time.Duration
+
+	// TelegramToken включает бота для операторов, сообщения уходят в чат TelegramChatID
+	TelegramToken  string
+	TelegramChatID int64
+	// TelegramAPIURL адрес Bot API, для тестов можно указать локальный фейковый сервер
+	TelegramAPIURL string
+	// TelegramSummaryTime время ежедневной сводки в формате 15:04 в часовом поясе Timezone
+	TelegramSummaryTime string
+
+	// MaterialCostPerKg цена филамента за килограмм, MachineHourRate стоимость часа работы принтера:
+	// себестоимость штуки = граммы карточки × MaterialCostPerKg / 1000 + часы печати × MachineHourRate, в рублях
+	MaterialCostPerKg float64
+	MachineHourRate   float64
+
+	// SLARules правила оповещений о заказах, которые не успевают к отгрузке, в env JSON массивом
+	SLARules    []SLARule
+	SLAInterval time.Duration
+
+	// CORSOrigins список через запятую
+	CORSOrigins string
+	StaticDir   string
+
+	// DBMaxConns, DBMinConns 0 оставляет значения pgxpool по умолчанию
+	DBMaxConns int32
+	DBMinConns int32
+}
+
+type (
+	WbAccount struct {
+		Name  string
+		Token string
+	}
+
+	OzonAccount struct {
+		Name     string
+		Token    string
+		ClientID string
+	}
+
+	YandexAccount struct {
+		Name       string
+		Token      string
+		CampaignID string
+		BusinessID string
+	}
+
+	MegamarketAccount struct {
+		Name  string
+		Token string
+	}
+
+	// SLARule условия складываются через И, нужно хотя бы одно из OlderThan и ShipmentWithin.
+	// Notifiers: log, webhook, telegram, по умолчанию log
+	SLARule struct {
+		Name           string
+		Marketplace    string
+		OlderThan      time.Duration
+		ShipmentWithin time.Duration
+		NotPrinting    bool
+		Notifiers      []string
+	}
+
+	// WebhookEndpoint пустой Events - все события
+	WebhookEndpoint struct {
+		Name   string
+		URL    string
+		Secret string
+		Events []string
+	}
+)
+
+var knownMarketplaces = []card.Marketplace{card.MpWb, card.MpOzon, card.MpYandex, card.MpMegamarket, card.MpManual}
+
+// envKeys ключ конфига -> имя переменной окружения без префикса
+var envKeys = map[string]string{
+	"Port":                     "PORT",
+	"Host":                     "HOST",
+	"Name":                     "NAME",
+	"WbToken":                  "WB_TOKEN",
+	"OzonToken":                "OZON_TOKEN",
+	"OzonClientID":             "OZON_CLIENT_ID",
+	"DatabaseURL":              "DATABASE_URL",
+	"YandexToken":              "YANDEX_TOKEN",
+	"YandexBusinessID":         "YANDEX_BUSINESS_ID",
+	"YandexCompaignID":         "YANDEX_CAMPAIGN_ID",
+	"MegamarketToken":          "MEGAMARKET_TOKEN",
+	"WbAccounts":               "WB_ACCOUNTS",
+	"OzonAccounts":             "OZON_ACCOUNTS",
+	"YandexAccounts":           "YANDEX_ACCOUNTS",
+	"MegamarketAccounts":       "MEGAMARKET_ACCOUNTS",
+	"WbEnabled":                "WB_ENABLED",
+	"OzonEnabled":              "OZON_ENABLED",
+	"YandexEnabled":            "YANDEX_ENABLED",
+	"MegamarketEnabled":        "MEGAMARKET_ENABLED",
+	"LogLevel":                 "LOG_LEVEL",
+	"LogFormat":                "LOG_FORMAT",
+	"AutoMigrate":              "AUTO_MIGRATE",
+	"OrdersInterval":           "ORDERS_INTERVAL",
+	"SuppliesInterval":         "SUPPLIES_INTERVAL",
+	"CardsInterval":            "CARDS_INTERVAL",
+	"WebhookToken":             "WEBHOOK_TOKEN",
+	"WebhookReconcileInterval": "WEBHOOK_RECONCILE_INTERVAL",
+	"WebhookEndpoints":         "WEBHOOK_ENDPOINTS",
+	"WebhooksInterval":         "WEBHOOKS_INTERVAL",
+	"TelegramToken":            "TELEGRAM_TOKEN",
+	"TelegramChatID":           "TELEGRAM_CHAT_ID",
+	"TelegramAPIURL":           "TELEGRAM_API_URL",
+	"TelegramSummaryTime":      "TELEGRAM_SUMMARY_TIME",
+	"Timezone":                 "TIMEZONE",
+	"MaterialCostPerKg":        "MATERIAL_COST_PER_KG",
+	"MachineHourRate":          "MACHINE_HOUR_RATE",
+	"SLARules":                 "SLA_RULES",
+	"SLAInterval":              "SLA_INTERVAL",
+	"CORSOrigins":              "CORS_ORIGINS",
+	"StaticDir":                "STATIC_DIR",
+	"DBMaxConns":               "DB_MAX_CONNS",
+	"DBMinConns":               "DB_MIN_CONNS",
 }
 
-func GetAppConfig() Config {
+// GetAppConfig читает configs/values.yaml, если он есть, и переменные окружения с префиксом FACTORY_
+func GetAppConfig() (Config, error) {
 	cfg := Config{}
 
-	viper.SetConfigName("values")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("../configs")
-	viper.AddConfigPath("./configs")
-	viper.AddConfigPath(".")
-	err := viper.ReadInConfig()
+	v := viper.New()
+	v.SetConfigName("values")
+	v.SetConfigType("yaml")
+	v.AddConfigPath("../configs")
+	v.AddConfigPath("./configs")
+	v.AddConfigPath(".")
+
+	v.SetDefault("Port", 80)
+	v.SetDefault("LogLevel", "info")
+	v.SetDefault("LogFormat", "text")
+	v.SetDefault("OrdersInterval", 10*time.Second)
+	v.SetDefault("SuppliesInterval", 5*time.Second)
+	v.SetDefault("CardsInterval", 5*time.Second)
+	v.SetDefault("WebhookReconcileInterval", 5*time.Minute)
+	v.SetDefault("WebhooksInterval", 5*time.Second)
+	v.SetDefault("TelegramAPIURL", "https://api.telegram.org")
+	v.SetDefault("TelegramSummaryTime", "09:00")
+	v.SetDefault("Timezone", "Europe/Moscow")
+	v.SetDefault("SLAInterval", time.Minute)
+	v.SetDefault("CORSOrigins", "http://127.0.0.1, http://localhost, http://127.0.0.1:4173, http://80.76.35.119")
+	v.SetDefault("StaticDir", "./web/factory-front/dist")
+
+	for key, env := range envKeys {
+		if err := v.BindEnv(key, EnvPrefix+"_"+env); err != nil {
+			return cfg, errors.Wrap(err, "viper.BindEnv")
+		}
+	}
+
+	err := v.ReadInConfig()
+	var notFound viper.ConfigFileNotFoundError
+	if err != nil && !errors.As(err, &notFound) {
+		return cfg, errors.Wrap(err, "read config file")
+	}
+
+	decodeHook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		jsonStringToSliceHook,
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	))
+	if err = v.Unmarshal(&cfg, decodeHook); err != nil {
+		return cfg, errors.Wrap(err, "unmarshal config")
+	}
+
+	cfg.addDefaultAccounts()
+
+	for i := range cfg.SLARules {
+		if len(cfg.SLARules[i].Notifiers) == 0 {
+			cfg.SLARules[i].Notifiers = []string{"log"}
+		}
+	}
+
+	if !v.IsSet("WbEnabled") {
+		cfg.WbEnabled = len(cfg.WbAccounts) > 0
+	}
+	if !v.IsSet("OzonEnabled") {
+		cfg.OzonEnabled = len(cfg.OzonAccounts) > 0
+	}
+	if !v.IsSet("YandexEnabled") {
+		cfg.YandexEnabled = len(cfg.YandexAccounts) > 0
+	}
+	if !v.IsSet("MegamarketEnabled") {
+		cfg.MegamarketEnabled = len(cfg.MegamarketAccounts) > 0
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// Validate возвращает все найденные ошибки сразу, чтобы не исправлять конфиг по одной
+func (c Config) Validate() error {
+	var errs []error
+	fail := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s (%s_%s): %s", key, EnvPrefix, envKeys[key], fmt.Sprintf(format, args...)))
+	}
+
+	if c.Port <= 0 || c.Port > 65535 {
+		fail("Port", "must be in 1..65535, got %d", c.Port)
+	}
+
+	if c.DatabaseURL == "" {
+		fail("DatabaseURL", "is required")
+	}
+
+	if c.WbEnabled && len(c.WbAccounts) == 0 {
+		fail("WbAccounts", "at least one account or WbToken is required when wb is enabled")
+	}
+	wbNames := map[string]bool{}
+	for i, account := range c.WbAccounts {
+		checkAccountName(fail, "WbAccounts", i, account.Name, wbNames)
+		if account.Token == "" {
+			fail("WbAccounts", "account %q: Token is required", account.Name)
+		}
+	}
+
+	if c.OzonEnabled && len(c.OzonAccounts) == 0 {
+		fail("OzonAccounts", "at least one account or OzonToken is required when ozon is enabled")
+	}
+	ozonNames := map[string]bool{}
+	for i, account := range c.OzonAccounts {
+		checkAccountName(fail, "OzonAccounts", i, account.Name, ozonNames)
+		if account.Token == "" || account.ClientID == "" {
+			fail("OzonAccounts", "account %q: Token and ClientID are required", account.Name)
+		}
+	}
+
+	if c.YandexEnabled && len(c.YandexAccounts) == 0 {
+		fail("YandexAccounts", "at least one account or YandexToken is required when yandex is enabled")
+	}
+	yandexNames := map[string]bool{}
+	for i, account := range c.YandexAccounts {
+		checkAccountName(fail, "YandexAccounts", i, account.Name, yandexNames)
+		if account.Token == "" || account.CampaignID == "" || account.BusinessID == "" {
+			fail("YandexAccounts", "account %q: Token, CampaignID and BusinessID are required", account.Name)
+		}
+	}
+
+	if c.MegamarketEnabled && len(c.MegamarketAccounts) == 0 {
+		fail("MegamarketAccounts", "at least one account or MegamarketToken is required when megamarket is enabled")
+	}
+	megamarketNames := map[string]bool{}
+	for i, account := range c.MegamarketAccounts {
+		checkAccountName(fail, "MegamarketAccounts", i, account.Name, megamarketNames)
+		if account.Token == "" {
+			fail("MegamarketAccounts", "account %q: Token is required", account.Name)
+		}
+	}
+
+	for key, interval := range map[string]time.Duration{
+		"OrdersInterval":           c.OrdersInterval,
+		"SuppliesInterval":         c.SuppliesInterval,
+		"CardsInterval":            c.CardsInterval,
+		"WebhookReconcileInterval": c.WebhookReconcileInterval,
+		"WebhooksInterval":         c.WebhooksInterval,
+		"SLAInterval":              c.SLAInterval,
+	} {
+		if interval < time.Second {
+			fail(key, "must be at least 1s, got %s", interval)
+		}
+	}
+
+	if c.WebhookToken != "" && len(c.WebhookToken) < minWebhookTokenLen {
+		fail("WebhookToken", "must be at least %d characters", minWebhookTokenLen)
+	}
+
+	endpointNames := map[string]bool{}
+	for i, endpoint := range c.WebhookEndpoints {
+		checkName(fail, "WebhookEndpoints", "endpoint", i, endpoint.Name, endpointNames)
+		if u, err := url.Parse(endpoint.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("WebhookEndpoints", "endpoint %q: invalid URL %q", endpoint.Name, endpoint.URL)
+		}
+		if endpoint.Secret == "" {
+			fail("WebhookEndpoints", "endpoint %q: Secret is required", endpoint.Name)
+		}
+		for _, event := range endpoint.Events {
+			if !slices.Contains(webhook.EventTypes, event) {
+				fail("WebhookEndpoints", "endpoint %q: unknown event %q, want one of %s", endpoint.Name, event, strings.Join(webhook.EventTypes, ", "))
+			}
+		}
+	}
+
+	if c.TelegramToken != "" {
+		if c.TelegramChatID == 0 {
+			fail("TelegramChatID", "is required when TelegramToken is set")
+		}
+		if u, err := url.Parse(c.TelegramAPIURL); err != nil || u.Scheme == "" || u.Host == "" {
+			fail("TelegramAPIURL", "invalid URL %q", c.TelegramAPIURL)
+		}
+		if _, err := time.Parse("15:04", c.TelegramSummaryTime); err != nil {
+			fail("TelegramSummaryTime", "must be in 15:04 format, got %q", c.TelegramSummaryTime)
+		}
+	}
+
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		fail("Timezone", "unknown timezone %q", c.Timezone)
+	}
+
+	if c.MaterialCostPerKg < 0 {
+		fail("MaterialCostPerKg", "must not be negative, got %v", c.MaterialCostPerKg)
+	}
+	if c.MachineHourRate < 0 {
+		fail("MachineHourRate", "must not be negative, got %v", c.MachineHourRate)
+	}
+
+	ruleNames := map[string]bool{}
+	for i, rule := range c.SLARules {
+		checkName(fail, "SLARules", "rule", i, rule.Name, ruleNames)
+		if rule.OlderThan <= 0 && rule.ShipmentWithin <= 0 {
+			fail("SLARules", "rule %q: OlderThan or ShipmentWithin is required", rule.Name)
+		}
+		if rule.Marketplace != "" && !slices.Contains(knownMarketplaces, card.Marketplace(rule.Marketplace)) {
+			fail("SLARules", "rule %q: unknown marketplace %q", rule.Name, rule.Marketplace)
+		}
+		for _, notifier := range rule.Notifiers {
+			switch notifier {
+			case "log", "webhook":
+			case "telegram":
+				if c.TelegramToken == "" {
+					fail("SLARules", "rule %q: telegram notifier requires TelegramToken", rule.Name)
+				}
+			default:
+				fail("SLARules", "rule %q: unknown notifier %q, want log, webhook or telegram", rule.Name, notifier)
+			}
+		}
+	}
+
+	for _, origin := range c.CORSOriginList() {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" {
+			fail("CORSOrigins", "invalid origin %q", origin)
+		}
+	}
+
+	if c.DBMaxConns < 0 || c.DBMinConns < 0 {
+		fail("DBMaxConns", "pool sizes must not be negative")
+	} else if c.DBMaxConns > 0 && c.DBMinConns > c.DBMaxConns {
+		fail("DBMinConns", "must not exceed DBMaxConns %d, got %d", c.DBMaxConns, c.DBMinConns)
+	}
+
+	return errors.Wrap(stderrors.Join(errs...), "invalid config")
+}
+
+// checkAccountName имя кабинета входит в QR-код позиции очереди, где поля разделены двоеточием
+func checkAccountName(fail func(key, format string, args ...any), key string, idx int, name string, seen map[string]bool) {
+	checkName(fail, key, "account", idx, name, seen)
+	if strings.Contains(name, ":") {
+		fail(key, "account %q: Name must not contain ':'", name)
+	}
+}
+
+// checkName noun - что называется в сообщении об ошибке: account, endpoint, rule
+func checkName(fail func(key, format string, args ...any), key, noun string, idx int, name string, seen map[string]bool) {
+	switch {
+	case name == "":
+		fail(key, "%s #%d: Name is required", noun, idx)
+	case seen[name]:
+		fail(key, "duplicate %s name %q", noun, name)
+	}
+	seen[name] = true
+}
+
+// addDefaultAccounts одиночные поля из старых конфигов превращаются в кабинет default, если список кабинетов не задан
+func (c *Config) addDefaultAccounts() {
+	if len(c.WbAccounts) == 0 && c.WbToken != "" {
+		c.WbAccounts = []WbAccount{{Name: DefaultAccount, Token: c.WbToken}}
+	}
+
+	if len(c.OzonAccounts) == 0 && c.OzonToken != "" {
+		c.OzonAccounts = []OzonAccount{{Name: DefaultAccount, Token: c.OzonToken, ClientID: c.OzonClientID}}
+	}
+
+	if len(c.YandexAccounts) == 0 && c.YandexToken != "" {
+		c.YandexAccounts = []YandexAccount{{
+			Name:       DefaultAccount,
+			Token:      c.YandexToken,
+			CampaignID: c.YandexCompaignID,
+			BusinessID: c.YandexBusinessID,
+		}}
+	}
+
+	if len(c.MegamarketAccounts) == 0 && c.MegamarketToken != "" {
+		c.MegamarketAccounts = []MegamarketAccount{{Name: DefaultAccount, Token: c.MegamarketToken}}
+	}
+}
+
+// jsonStringToSliceHook позволяет задать список кабинетов в переменной окружения JSON массивом
+func jsonStringToSliceHook(from reflect.Type, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String || to.Kind() != reflect.Slice || to.Elem().Kind() != reflect.Struct {
+		return data, nil
+	}
+
+	raw := strings.TrimSpace(data.(string))
+	if raw == "" {
+		return []any{}, nil
+	}
+
+	var result []map[string]any
+	if err := json.Unmarshal([]byte(raw), &result); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+
+	return result, nil
+}
+
+// Location часовой пояс Timezone, до Validate может вернуть UTC
+func (c Config) Location() *time.Location {
+	location, err := time.LoadLocation(c.Timezone)
 	if err != nil {
-		panic(fmt.Errorf("fatal error config file: %w", err))
+		return time.UTC
 	}
 
-	err = viper.Unmarshal(&cfg)
+	return location
+}
+
+// TelegramSummaryAt время сводки от начала суток, формат проверяет Validate
+func (c Config) TelegramSummaryAt() time.Duration {
+	at, err := time.Parse("15:04", c.TelegramSummaryTime)
 	if err != nil {
-		panic(fmt.Errorf("fatal unmarshal config file: %w", err))
+		return 0
+	}
+
+	return time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute
+}
+
+// CORSOriginList origins без пробелов и пустых элементов
+func (c Config) CORSOriginList() []string {
+	result := make([]string, 0)
+	for _, origin := range strings.Split(c.CORSOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			result = append(result, origin)
+		}
 	}
 
-	return cfg
+	return result
 }
@@ -4,21 +4,55 @@ import (
 	"database/sql"
 
 	"github.com/google/uuid"
-
-	"github.com/alleswebdev/marketplace-3d-factory/internal/client/wb"
 )
 
 type Card struct {
-	ID          uuid.UUID    `db:"id"`
-	Name        string       `db:"name"`
-	Article     string       `db:"article"`
-	Articles    []string     `db:"articles"`
-	Files       []string     `db:"files"`
-	Marketplace Marketplace  `db:"marketplace"`
-	IsComposite bool         `db:"is_composite"`
-	Photo       string       `db:"photo"`
-	CreatedAt   sql.NullTime `db:"created_at"`
-	UpdatedAt   sql.NullTime `db:"updated_at"`
+	ID          uuid.UUID   `db:"id"`
+	Name        string      `db:"name"`
+	Article     string      `db:"article"`
+	Articles    []string    `db:"articles"`
+	Files       []string    `db:"files"`
+	Marketplace Marketplace `db:"marketplace"`
+	Account     string      `db:"account"`
+	IsComposite bool        `db:"is_composite"`
+	Photo       string      `db:"photo"`
+	// Barcodes штрихкоды и sku товара из каталога маркетплейса, по ним сканер находит заказ
+	Barcodes []string `db:"barcodes"`
+	// FilamentGrams, PrintHours модель себестоимости одной штуки, заполняется вручную
+	FilamentGrams float64 `db:"filament_grams"`
+	PrintHours    float64 `db:"print_hours"`
+	// ProductID мастер-товар, общий для карточек одной модели на разных маркетплейсах
+	ProductID uuid.NullUUID `db:"product_id"`
+	CreatedAt sql.NullTime  `db:"created_at"`
+	UpdatedAt sql.NullTime  `db:"updated_at"`
+	// Variants заполняет синхронизация каталога, nil - маркетплейс не отдаёт варианты и сохранённые не трогаются
+	Variants []Variant `db:"-"`
+}
+
+// Variant отдельный sku карточки, по цвету и материалу выбирается филамент для печати
+type Variant struct {
+	Marketplace Marketplace `db:"marketplace" json:"marketplace"`
+	Account     string      `db:"account" json:"account"`
+	SKU         string      `db:"sku" json:"sku"`
+	Article     string      `db:"article" json:"article"`
+	// Model объединяет варианты одной модели в разных карточках
+	Model     string       `db:"model" json:"model"`
+	Size      string       `db:"size" json:"size"`
+	Color     string       `db:"color" json:"color"`
+	Material  string       `db:"material" json:"material"`
+	CreatedAt sql.NullTime `db:"created_at" json:"-"`
+	UpdatedAt sql.NullTime `db:"updated_at" json:"-"`
+}
+
+// DefaultAccount кабинет, к которому относятся данные, загруженные до поддержки нескольких кабинетов
+const DefaultAccount = "default"
+
+func (c Card) GetAccount() string {
+	if len(c.Account) == 0 {
+		return DefaultAccount
+	}
+
+	return c.Account
 }
 
 type Marketplace string
@@ -27,30 +61,12 @@ const (
 	MpWb     Marketplace = "wb"
 	MpOzon   Marketplace = "ozon"
 	MpYandex Marketplace = "yandex"
+	// MpMegamarket СберМегаМаркет
+	MpMegamarket Marketplace = "megamarket"
+	// MpManual заказы с сайта, из телеграма и на заказ, заводятся вручную через API
+	MpManual Marketplace = "manual"
 )
 
 func (m Marketplace) String() string {
 	return string(m)
 }
-
-// nolint
-func ConvertCards(wbCards []wb.Card) []Card {
-	result := make([]Card, 0, len(wbCards))
-	for _, item := range wbCards {
-		convertItem := Card{
-			ID:          uuid.MustParse(item.NmUUID),
-			Name:        item.Title,
-			Article:     item.VendorCode,
-			Marketplace: MpWb,
-			IsComposite: false,
-		}
-
-		if len(item.Photos) > 0 {
-			convertItem.Photo = item.Photos[0].Big
-		}
-
-		result = append(result, convertItem)
-	}
-
-	return result
-}
@@ -3,11 +3,15 @@ package card
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"slices"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db"
 )
 
 const (
@@ -21,27 +25,54 @@ const (
 	articlesColumn    = "articles"
 	filesColumn       = "files"
 	marketplaceColumn = "marketplace"
+	accountColumn     = "account"
 	isCompositeColumn = "is_composite"
+	filamentColumn    = "filament_grams"
+	printHoursColumn  = "print_hours"
+	barcodesColumn    = "barcodes"
+	productIDColumn   = "product_id"
+
+	variantsTableName = "card_variants"
+	skuColumn         = "sku"
+	modelColumn       = "model"
+	sizeColumn        = "size"
+	colorColumn       = "color"
+	materialColumn    = "material"
 )
 
 type Store struct {
 	dbPool *pgxpool.Pool
+	log    *slog.Logger
 }
 
-func New(dbPool *pgxpool.Pool) *Store {
-	return &Store{dbPool: dbPool}
+func New(dbPool *pgxpool.Pool, log *slog.Logger) *Store {
+	return &Store{dbPool: dbPool, log: log}
 }
 
+// AddCards у существующих карточек обновляются только штрихкоды, остальные поля заполняются вручную.
+// Варианты карточек синхронизируются в той же транзакции
 func (s *Store) AddCards(ctx context.Context, cards []Card) error {
-	suffix := fmt.Sprintf(`ON CONFLICT(%s, %s) DO NOTHING`, articleColumn, marketplaceColumn)
+	if len(cards) == 0 {
+		return nil
+	}
+
+	suffix := fmt.Sprintf(`ON CONFLICT(%[1]s, %[2]s, %[3]s) DO UPDATE SET %[4]s = EXCLUDED.%[4]s
+		WHERE %[5]s.%[4]s IS DISTINCT FROM EXCLUDED.%[4]s`,
+		articleColumn, marketplaceColumn, accountColumn, barcodesColumn, tableName,
+	)
 
 	qb := sq.Insert(tableName).
-		Columns(idColumn, nameColumn, articleColumn, photoColumn, marketplaceColumn).
+		Columns(idColumn, nameColumn, articleColumn, photoColumn, marketplaceColumn, accountColumn, barcodesColumn).
 		Suffix(suffix).
 		PlaceholderFormat(sq.Dollar)
 
-	for _, item := range cards {
-		qb = qb.Values(item.ID, item.Name, item.Article, item.Photo, item.Marketplace)
+	for _, item := range uniqueCards(cards) {
+		barcodes := item.Barcodes
+		if barcodes == nil {
+			barcodes = []string{}
+		}
+
+		qb = qb.Values(item.ID, item.Name, item.Article, item.Photo, item.Marketplace, item.GetAccount(), barcodes)
 	}
 
 	query, args, err := qb.ToSql()
@@ -49,17 +80,167 @@ func (s *Store) AddCards(ctx context.Context, cards []Card) error {
 		return errors.Wrap(err, "sq.ToSql")
 	}
 
-	_, err = s.dbPool.Exec(ctx, query, args...)
+	return db.TransactionWrapper(ctx, s.dbPool, func(ctx context.Context, tx db.Conn) error {
+		tag, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return errors.Wrap(err, "tx.Exec")
+		}
+
+		s.log.DebugContext(ctx, "cards added", slog.Int("received", len(cards)), slog.Int64("affected", tag.RowsAffected()))
+
+		return s.syncVariants(ctx, tx, cards)
+	})
+}
+
+// uniqueCards склеивает карточки с одинаковым артикулом в кабинете, например размеры WB, объединяя штрихкоды:
+// ON CONFLICT DO UPDATE не может изменить одну строку дважды за запрос
+func uniqueCards(cards []Card) []Card {
+	type key struct {
+		article     string
+		marketplace Marketplace
+		account     string
+	}
+
+	result := make([]Card, 0, len(cards))
+	positions := make(map[key]int, len(cards))
+	for _, c := range cards {
+		k := key{article: c.Article, marketplace: c.Marketplace, account: c.GetAccount()}
+		pos, ok := positions[k]
+		if !ok {
+			positions[k] = len(result)
+			c.Barcodes = slices.Clone(c.Barcodes)
+			result = append(result, c)
+			continue
+		}
+
+		for _, barcode := range c.Barcodes {
+			if !slices.Contains(result[pos].Barcodes, barcode) {
+				result[pos].Barcodes = append(result[pos].Barcodes, barcode)
+			}
+		}
+	}
+
+	return result
+}
+
+// syncVariants обновляет варианты карточек и удаляет те, которых больше нет в каталоге.
+// Карточки с Variants == nil пропускаются: их маркетплейс варианты не отдаёт
+func (s *Store) syncVariants(ctx context.Context, tx db.Conn, cards []Card) error {
+	type scope struct {
+		marketplace Marketplace
+		account     string
+	}
+
+	byKey := make(map[string]Variant)
+	articles := make(map[scope][]string)
+	skus := make(map[scope][]string)
+	for _, c := range cards {
+		if c.Variants == nil {
+			continue
+		}
+
+		key := scope{marketplace: c.Marketplace, account: c.GetAccount()}
+		articles[key] = append(articles[key], c.Article)
+
+		for _, v := range c.Variants {
+			v.Marketplace, v.Account, v.Article = c.Marketplace, c.GetAccount(), c.Article
+			// один sku может прийти дважды, а ON CONFLICT не обновляет строку два раза за запрос
+			byKey[string(v.Marketplace)+"/"+v.Account+"/"+v.SKU] = v
+			skus[key] = append(skus[key], v.SKU)
+		}
+	}
+
+	if len(byKey) > 0 {
+		qb := sq.Insert(variantsTableName).
+			Columns(marketplaceColumn, accountColumn, skuColumn, articleColumn, modelColumn, sizeColumn, colorColumn, materialColumn).
+			Suffix(fmt.Sprintf(`ON CONFLICT(%[1]s, %[2]s, %[3]s) DO UPDATE SET
+				%[4]s = EXCLUDED.%[4]s,
+				%[5]s = EXCLUDED.%[5]s,
+				%[6]s = EXCLUDED.%[6]s,
+				%[7]s = EXCLUDED.%[7]s,
+				%[8]s = EXCLUDED.%[8]s,
+				%[9]s = now()
+			WHERE (%[10]s.%[4]s, %[10]s.%[5]s, %[10]s.%[6]s, %[10]s.%[7]s, %[10]s.%[8]s)
+				IS DISTINCT FROM (EXCLUDED.%[4]s, EXCLUDED.%[5]s, EXCLUDED.%[6]s, EXCLUDED.%[7]s, EXCLUDED.%[8]s)`,
+				marketplaceColumn, accountColumn, skuColumn,
+				articleColumn, modelColumn, sizeColumn, colorColumn, materialColumn, updatedAtColumn, variantsTableName,
+			)).
+			PlaceholderFormat(sq.Dollar)
 
-	return errors.Wrap(err, "dbPool.Exec")
+		for _, v := range byKey {
+			qb = qb.Values(v.Marketplace, v.Account, v.SKU, v.Article, v.Model, v.Size, v.Color, v.Material)
+		}
+
+		query, args, err := qb.ToSql()
+		if err != nil {
+			return errors.Wrap(err, "sq.ToSql")
+		}
+
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "tx.Exec")
+		}
+	}
+
+	for key, scopeArticles := range articles {
+		qb := sq.Delete(variantsTableName).
+			Where(sq.Eq{marketplaceColumn: key.marketplace}).
+			Where(sq.Eq{accountColumn: key.account}).
+			Where(sq.Eq{articleColumn: scopeArticles}).
+			PlaceholderFormat(sq.Dollar)
+
+		if len(skus[key]) > 0 {
+			qb = qb.Where(sq.NotEq{skuColumn: skus[key]})
+		}
+
+		query, args, err := qb.ToSql()
+		if err != nil {
+			return errors.Wrap(err, "sq.ToSql")
+		}
+
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "tx.Exec")
+		}
+	}
+
+	return nil
 }
 
-func (s *Store) GetByArticlesMap(ctx context.Context, articles []string) (map[string]Card, error) {
+// GetVariantsByArticles пустой account ищет варианты во всех кабинетах маркетплейса
+func (s *Store) GetVariantsByArticles(ctx context.Context, mp Marketplace, account string, articles []string) ([]Variant, error) {
 	qb := sq.Select("*").
-		From(tableName).
+		From(variantsTableName).
 		Where(sq.Eq{articleColumn: articles}).
+		Where(sq.Eq{marketplaceColumn: mp}).
+		OrderBy(accountColumn, articleColumn, skuColumn).
 		PlaceholderFormat(sq.Dollar)
 
+	if len(account) > 0 {
+		qb = qb.Where(sq.Eq{accountColumn: account})
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "sq.ToSql")
+	}
+
+	var items []Variant
+	err = pgxscan.Select(ctx, s.dbPool, &items, query, args...)
+
+	return items, errors.Wrap(err, "pgxscan.Select")
+}
+
+// GetByArticlesMap пустой account ищет карточки во всех кабинетах маркетплейса.
+// У привязанных к мастер-товару карточек состав, файлы и себестоимость берутся из мастера
+func (s *Store) GetByArticlesMap(ctx context.Context, mp Marketplace, account string, articles []string) (map[string]Card, error) {
+	qb := selectWithProduct().
+		Where(sq.Eq{tableName + "." + articleColumn: articles}).
+		Where(sq.Eq{tableName + "." + marketplaceColumn: mp}).
+		PlaceholderFormat(sq.Dollar)
+
+	if len(account) > 0 {
+		qb = qb.Where(sq.Eq{tableName + "." + accountColumn: account})
+	}
+
 	query, args, err := qb.ToSql()
 	if err != nil {
 		return nil, errors.Wrap(err, "sq.ToSql")
@@ -78,3 +259,92 @@ func (s *Store) GetByArticlesMap(ctx context.Context, articles []string) (map[st
 
 	return byArticlesMap, nil
 }
+
+// selectWithProduct колонки карточки, где поля мастер-товара перекрывают поля самой карточки.
+// Незаполненные поля мастера NULL, по ним и у непривязанных карточек COALESCE берёт значение карточки
+func selectWithProduct() sq.SelectBuilder {
+	qb := sq.Select()
+	for _, column := range []string{
+		idColumn, nameColumn, articleColumn, photoColumn, marketplaceColumn, accountColumn,
+		barcodesColumn, productIDColumn, createdAtColumn, updatedAtColumn,
+	} {
+		qb = qb.Column(tableName + "." + column)
+	}
+
+	for _, column := range []string{articlesColumn, filesColumn, isCompositeColumn, filamentColumn, printHoursColumn} {
+		qb = qb.Column(fmt.Sprintf("COALESCE(products.%[1]s, %[2]s.%[1]s) AS %[1]s", column, tableName))
+	}
+
+	return qb.From(tableName).LeftJoin("products ON products.id = " + tableName + "." + productIDColumn)
+}
+
+// ListCards собственные значения карточек без мастер-товара, выгрузка в CSV редактирует именно их
+func (s *Store) ListCards(ctx context.Context, marketplace string) ([]Card, error) {
+	qb := sq.Select("*").
+		From(tableName).
+		OrderBy(marketplaceColumn, accountColumn, articleColumn).
+		PlaceholderFormat(sq.Dollar)
+
+	if len(marketplace) > 0 {
+		qb = qb.Where(sq.Eq{marketplaceColumn: marketplace})
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "sq.ToSql")
+	}
+
+	var items []Card
+	err = pgxscan.Select(ctx, s.dbPool, &items, query, args...)
+
+	return items, errors.Wrap(err, "pgxscan.Select")
+}
+
+// UpsertCards в отличие от AddCards перезаписывает ручные поля: состав, файлы моделей, признак составной карточки
+// и модель себестоимости
+func (s *Store) UpsertCards(ctx context.Context, cards []Card) error {
+	if len(cards) == 0 {
+		return nil
+	}
+
+	suffix := fmt.Sprintf(`ON CONFLICT(%[1]s, %[2]s, %[3]s) DO UPDATE SET
+		%[4]s = EXCLUDED.%[4]s,
+		%[5]s = EXCLUDED.%[5]s,
+		%[6]s = EXCLUDED.%[6]s,
+		%[7]s = EXCLUDED.%[7]s,
+		%[8]s = EXCLUDED.%[8]s,
+		%[9]s = EXCLUDED.%[9]s,
+		%[10]s = EXCLUDED.%[10]s`,
+		articleColumn, marketplaceColumn, accountColumn,
+		nameColumn, photoColumn, articlesColumn, filesColumn, isCompositeColumn, filamentColumn, printHoursColumn,
+	)
+
+	qb := sq.Insert(tableName).
+		Columns(
+			idColumn, nameColumn, articleColumn, photoColumn, marketplaceColumn, accountColumn,
+			articlesColumn, filesColumn, isCompositeColumn, filamentColumn, printHoursColumn,
+		).
+		Suffix(suffix).
+		PlaceholderFormat(sq.Dollar)
+
+	for _, item := range cards {
+		qb = qb.Values(
+			item.ID, item.Name, item.Article, item.Photo, item.Marketplace, item.GetAccount(),
+			item.Articles, item.Files, item.IsComposite, item.FilamentGrams, item.PrintHours,
+		)
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "sq.ToSql")
+	}
+
+	tag, err := s.dbPool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "dbPool.Exec")
+	}
+
+	s.log.InfoContext(ctx, "cards upserted", slog.Int64("affected", tag.RowsAffected()))
+
+	return nil
+}
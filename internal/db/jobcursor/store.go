@@ -0,0 +1,70 @@
+// Package jobcursor хранит состояние фоновых задач между рестартами и сменой ведущего экземпляра
+package jobcursor
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	tableName       = "job_cursors"
+	nameColumn      = "name"
+	valueColumn     = "value"
+	updatedAtColumn = "updated_at"
+)
+
+type Store struct {
+	dbPool *pgxpool.Pool
+}
+
+func New(dbPool *pgxpool.Pool) *Store {
+	return &Store{dbPool: dbPool}
+}
+
+// Get значение курсора задачи, пустая строка - курсора ещё нет
+func (s *Store) Get(ctx context.Context, name string) (string, error) {
+	qb := sq.Select(valueColumn).
+		From(tableName).
+		Where(sq.Eq{nameColumn: name}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return "", errors.Wrap(err, "sq.ToSql")
+	}
+
+	var value string
+	if err = pgxscan.Get(ctx, s.dbPool, &value, query, args...); err != nil {
+		if pgxscan.NotFound(err) {
+			return "", nil
+		}
+
+		return "", errors.Wrap(err, "pgxscan.Get")
+	}
+
+	return value, nil
+}
+
+func (s *Store) Save(ctx context.Context, name, value string) error {
+	qb := sq.Insert(tableName).
+		Columns(nameColumn, valueColumn, updatedAtColumn).
+		Values(name, value, sq.Expr("now()")).
+		Suffix(fmt.Sprintf(`ON CONFLICT (%[1]s) DO UPDATE SET %[2]s = excluded.%[2]s, %[3]s = excluded.%[3]s`,
+			nameColumn, valueColumn, updatedAtColumn,
+		)).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "sq.ToSql")
+	}
+
+	_, err = s.dbPool.Exec(ctx, query, args...)
+
+	return errors.Wrap(err, "dbPool.Exec")
+}
@@ -0,0 +1,85 @@
+// Package joblock выбирает ведущий экземпляр приложения для каждой фоновой задачи
+// через сессионные advisory-блокировки Postgres
+package joblock
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// lockNamespace отделяет блокировки задач от других advisory-блокировок в базе
+const lockNamespace = 3245
+
+// Store держит блокировки на одном выделенном соединении: пока соединение живо,
+// задачи принадлежат этому экземпляру, при его падении Postgres снимает блокировки сам
+type Store struct {
+	dbPool *pgxpool.Pool
+
+	mu   sync.Mutex
+	conn *pgxpool.Conn
+	held map[string]struct{}
+}
+
+func New(dbPool *pgxpool.Pool) *Store {
+	return &Store{dbPool: dbPool}
+}
+
+// TryLock возвращает true, если задача принадлежит этому экземпляру
+func (s *Store) TryLock(ctx context.Context, name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := s.dbPool.Acquire(ctx)
+		if err != nil {
+			return false, errors.Wrap(err, "dbPool.Acquire")
+		}
+
+		s.conn = conn
+		s.held = make(map[string]struct{})
+	}
+
+	if _, ok := s.held[name]; ok {
+		if _, err := s.conn.Exec(ctx, `SELECT 1`); err != nil {
+			s.reset(ctx)
+			return false, errors.Wrap(err, "conn.Exec")
+		}
+
+		return true, nil
+	}
+
+	var locked bool
+	err := s.conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1, hashtext($2))`, lockNamespace, name).Scan(&locked)
+	if err != nil {
+		s.reset(ctx)
+		return false, errors.Wrap(err, "conn.QueryRow")
+	}
+
+	if locked {
+		s.held[name] = struct{}{}
+	}
+
+	return locked, nil
+}
+
+// Close отпускает все задачи, чтобы другой экземпляр подхватил их без ожидания
+func (s *Store) Close(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reset(ctx)
+}
+
+func (s *Store) reset(ctx context.Context) {
+	if s.conn == nil {
+		return
+	}
+
+	_ = s.conn.Conn().Close(ctx)
+	s.conn.Release()
+	s.conn = nil
+	s.held = nil
+}
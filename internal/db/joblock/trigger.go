@@ -0,0 +1,70 @@
+package joblock
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	// triggerChannel канал LISTEN/NOTIFY для ручных запусков задач
+	triggerChannel = "job_trigger"
+	// listenRetryDelay пауза перед переподключением, пропущенные за это время запуски подберёт опрос
+	listenRetryDelay = 5 * time.Second
+)
+
+// Notify передаёт ручной запуск задачи всем экземплярам, выполнит его тот, кто держит блокировку
+func (s *Store) Notify(ctx context.Context, name string) error {
+	if _, err := s.dbPool.Exec(ctx, `SELECT pg_notify($1, $2)`, triggerChannel, name); err != nil {
+		return errors.Wrap(err, "dbPool.Exec")
+	}
+
+	return nil
+}
+
+// Listen передаёт в handle имена задач, запущенных на других экземплярах, до отмены ctx.
+// Держит отдельное соединение и переподключается при его обрыве
+func (s *Store) Listen(ctx context.Context, log *slog.Logger, handle func(name string)) {
+	for {
+		err := s.listen(ctx, handle)
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.WarnContext(ctx, "job trigger listener stopped, reconnecting", logger.Err(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+func (s *Store) listen(ctx context.Context, handle func(name string)) error {
+	conn, err := s.dbPool.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "dbPool.Acquire")
+	}
+	// после LISTEN соединение нельзя возвращать в пул как обычное
+	defer func() {
+		_ = conn.Conn().Close(context.Background())
+		conn.Release()
+	}()
+
+	if _, err = conn.Exec(ctx, `LISTEN `+triggerChannel); err != nil {
+		return errors.Wrap(err, "conn.Exec")
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return errors.Wrap(err, "conn.WaitForNotification")
+		}
+
+		handle(notification.Payload)
+	}
+}
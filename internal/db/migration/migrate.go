@@ -0,0 +1,89 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/alleswebdev/marketplace-3d-factory/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pkg/errors"
+	"github.com/pressly/goose/v3"
+)
+
+// migrateLockKey не даёт двум экземплярам с автомиграцией накатывать схему одновременно
+const migrateLockKey = 3246
+
+const (
+	CommandUp     = "up"
+	CommandDown   = "down"
+	CommandStatus = "status"
+)
+
+// Migrator применяет встроенные миграции через пул приложения
+type Migrator struct {
+	dbPool *pgxpool.Pool
+	log    *slog.Logger
+}
+
+func NewMigrator(dbPool *pgxpool.Pool, log *slog.Logger) *Migrator {
+	return &Migrator{dbPool: dbPool, log: log}
+}
+
+// Run command: up|down|status, down откатывает одну последнюю миграцию
+func (m *Migrator) Run(ctx context.Context, command string) error {
+	goose.SetBaseFS(migrations.FS)
+	goose.SetLogger(gooseLogger{log: m.log})
+
+	if err := goose.SetDialect("postgres"); err != nil {
+		return errors.Wrap(err, "goose.SetDialect")
+	}
+
+	conn, err := m.dbPool.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "dbPool.Acquire")
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrateLockKey); err != nil {
+		return errors.Wrap(err, "pg_advisory_lock")
+	}
+	defer conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrateLockKey) // nolint:errcheck
+
+	db := stdlib.OpenDBFromPool(m.dbPool)
+	defer db.Close()
+
+	switch command {
+	case CommandUp:
+		err = goose.UpContext(ctx, db, ".")
+	case CommandDown:
+		err = goose.DownContext(ctx, db, ".")
+	case CommandStatus:
+		err = goose.StatusContext(ctx, db, ".")
+	default:
+		return errors.Errorf("unknown migrate command %q, expected up|down|status", command)
+	}
+
+	return errors.Wrapf(err, "goose %s", command)
+}
+
+// ExpectedVersion последняя версия среди встроенных миграций
+func ExpectedVersion() (int64, error) {
+	return LatestVersion(migrations.FS)
+}
+
+type gooseLogger struct {
+	log *slog.Logger
+}
+
+func (l gooseLogger) Printf(format string, v ...interface{}) {
+	l.log.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l gooseLogger) Fatalf(format string, v ...interface{}) {
+	l.log.Error(strings.TrimSpace(fmt.Sprintf(format, v...)))
+	os.Exit(1)
+}
@@ -0,0 +1,56 @@
+// Package migration сверяет версию схемы базы с миграциями goose
+package migration
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+type Store struct {
+	dbPool *pgxpool.Pool
+}
+
+func New(dbPool *pgxpool.Pool) *Store {
+	return &Store{dbPool: dbPool}
+}
+
+// GetVersion возвращает последнюю применённую версию из таблицы goose
+func (s *Store) GetVersion(ctx context.Context) (int64, error) {
+	var version int64
+	err := s.dbPool.QueryRow(ctx, `SELECT COALESCE(max(version_id), 0) FROM goose_db_version WHERE is_applied`).Scan(&version)
+
+	return version, errors.Wrap(err, "dbPool.QueryRow")
+}
+
+// LatestVersion находит самую новую миграцию по префиксу имени файла, например 20250815122135_drop_test_table.sql
+func LatestVersion(fsys fs.FS) (int64, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return 0, errors.Wrap(err, "fs.ReadDir")
+	}
+
+	var latest int64
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		version, parseErr := strconv.ParseInt(prefix, 10, 64)
+		if parseErr != nil {
+			continue
+		}
+
+		if version > latest {
+			latest = version
+		}
+	}
+
+	return latest, nil
+}
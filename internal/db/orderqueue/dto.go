@@ -12,18 +12,34 @@ type Order struct {
 	Article        string       `db:"article"`
 	Items          Items        `db:"order_composite_items"`
 	Marketplace    string       `db:"marketplace"`
+	Account        string       `db:"account"`
 	OrderCreatedAt sql.NullTime `db:"order_created_at"`
 	CreatedAt      sql.NullTime `db:"created_at"`
 	UpdatedAt      sql.NullTime `db:"updated_at"`
 	Info           Info         `db:"info"`
 	IsComplete     bool         `db:"is_complete"`
 	IsPrinting     bool         `db:"is_printing"`
+	// PrintingAt, CompletedAt проставляет триггер orders_queue_state_timestamps
+	PrintingAt  sql.NullTime `db:"printing_at"`
+	CompletedAt sql.NullTime `db:"completed_at"`
+	// Price, Commission в копейках валюты Currency, у заказов без цены 0
+	Price      int64  `db:"price"`
+	Commission int64  `db:"commission"`
+	Currency   string `db:"currency"`
 }
 
 type Info struct {
 	OrderNumber     string    `json:"order_number"`
 	OrderShipmentAt time.Time `json:"order_shipment_date"`
 	Quantity        int32     `json:"quantity"`
+	// Name, Note, IsCancelled заполняются только у ручных заказов, у которых может не быть карточки
+	Name        string `json:"name,omitempty"`
+	Note        string `json:"note,omitempty"`
+	IsCancelled bool   `json:"is_cancelled,omitempty"`
+	// Barcodes коды с этикеток заказа маркетплейса: skus WB, баркоды отправления Ozon
+	Barcodes []string `json:"barcodes,omitempty"`
+	// Sku купленный вариант карточки из card_variants
+	Sku string `json:"sku,omitempty"`
 }
 
 type Item struct {
@@ -34,10 +50,54 @@ type Item struct {
 
 type Items []Item
 
+// OpenStat количество незавершённых позиций очереди в разрезе маркетплейса и статуса печати
+type OpenStat struct {
+	Marketplace string `db:"marketplace"`
+	IsPrinting  bool   `db:"is_printing"`
+	Total       int64  `db:"total"`
+	Overdue     int64  `db:"overdue"`
+}
+
+// DueStat открытые позиции маркетплейса по сроку отгрузки относительно начала дня
+type DueStat struct {
+	Marketplace string `db:"marketplace"`
+	Overdue     int64  `db:"overdue"`
+	Today       int64  `db:"today"`
+	Tomorrow    int64  `db:"tomorrow"`
+}
+
+// ExportRow позиция очереди для выгрузки в таблицу, Name из карточки или ручного заказа
+type ExportRow struct {
+	ID             string       `db:"id"`
+	Article        string       `db:"article"`
+	Name           string       `db:"name"`
+	Marketplace    string       `db:"marketplace"`
+	Account        string       `db:"account"`
+	OrderNumber    string       `db:"order_number"`
+	Quantity       int32        `db:"quantity"`
+	OrderCreatedAt sql.NullTime `db:"order_created_at"`
+	ShipmentAt     sql.NullTime `db:"shipment_at"`
+	PrintingAt     sql.NullTime `db:"printing_at"`
+	CompletedAt    sql.NullTime `db:"completed_at"`
+	IsPrinting     bool         `db:"is_printing"`
+	IsComplete     bool         `db:"is_complete"`
+	IsCancelled    bool         `db:"is_cancelled"`
+}
+
+// ExportFilter период закрытия [From, To), пустые Marketplace и Account - без фильтра
+type ExportFilter struct {
+	From        time.Time
+	To          time.Time
+	Marketplace string
+	Account     string
+}
+
 type ListFilter struct {
 	WithParentComplete   bool   `json:"withParentComplete"`
 	WithChildrenComplete bool   `json:"withChildrenComplete"`
 	Marketplace          string `json:"marketplace"`
+	// Account пустое значение - все кабинеты маркетплейса
+	Account string `json:"account"`
 }
 
 func (f ListFilter) GetMarketplace() string {
@@ -47,3 +107,11 @@ func (f ListFilter) GetMarketplace() string {
 
 	return f.Marketplace
 }
+
+func (o Order) GetAccount() string {
+	if len(o.Account) == 0 {
+		return card.DefaultAccount
+	}
+
+	return o.Account
+}
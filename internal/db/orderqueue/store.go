@@ -3,6 +3,7 @@ package orderqueue
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strconv"
 	"time"
 
@@ -17,6 +18,9 @@ import (
 const (
 	tableName = "orders_queue"
 
+	// queueWindow заказы старше этого срока в очередь не попадают
+	queueWindow = time.Hour * 24 * 7
+
 	idColumn             = "id"
 	articleColumn        = "article"
 	itemsColumn          = "order_composite_items"
@@ -24,29 +28,69 @@ const (
 	createdAtColumn      = "created_at"
 	updatedAtColumn      = "updated_at"
 	marketplaceColumn    = "marketplace"
+	accountColumn        = "account"
 	infoColumn           = "info"
 	isCompleteColumn     = "is_complete"
 	isPrintingColumn     = "is_printing"
+	priceColumn          = "price"
+	commissionColumn     = "commission"
+	currencyColumn       = "currency"
+	printingAtColumn     = "printing_at"
+	completedAtColumn    = "completed_at"
 )
 
+// ErrNotFound заказа с таким id нет в очереди
+var ErrNotFound = errors.New("order not found")
+
 type Store struct {
 	dbPool *pgxpool.Pool
+	log    *slog.Logger
 }
 
-func New(dbPool *pgxpool.Pool) *Store {
-	return &Store{dbPool: dbPool}
+func New(dbPool *pgxpool.Pool, log *slog.Logger) *Store {
+	return &Store{dbPool: dbPool, log: log}
 }
 
+// AddOrders у заказов, которые уже в очереди, обновляются только цена, комиссия и штрихкоды отправления:
+// комиссия и штрихкоды Ozon появляются позже, а backfill дозаполняет суммы старых заказов
 func (s *Store) AddOrders(ctx context.Context, orders []Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	// пустые штрихкоды в info не пишутся, поэтому их отсутствие в EXCLUDED не затирает уже сохранённые
+	const (
+		hasPrice    = `EXCLUDED.%[3]s > 0`
+		hasBarcodes = `EXCLUDED.%[6]s->'barcodes' IS NOT NULL`
+	)
+
 	qb := sq.Insert(tableName).
-		Columns(idColumn, articleColumn, orderCreatedAtColumn, itemsColumn, marketplaceColumn, infoColumn).
+		Columns(
+			idColumn, articleColumn, orderCreatedAtColumn, itemsColumn, marketplaceColumn, accountColumn, infoColumn,
+			priceColumn, commissionColumn, currencyColumn,
+		).
 		Suffix(
-			fmt.Sprintf(`ON CONFLICT(%s, %s) DO NOTHING`, articleColumn, idColumn),
+			fmt.Sprintf(`ON CONFLICT(%[8]s, %[9]s, %[1]s, %[2]s) DO UPDATE SET
+				%[3]s = CASE WHEN `+hasPrice+` THEN EXCLUDED.%[3]s ELSE %[7]s.%[3]s END,
+				%[4]s = CASE WHEN `+hasPrice+` THEN EXCLUDED.%[4]s ELSE %[7]s.%[4]s END,
+				%[5]s = CASE WHEN `+hasPrice+` THEN EXCLUDED.%[5]s ELSE %[7]s.%[5]s END,
+				%[6]s = CASE WHEN `+hasBarcodes+`
+					THEN %[7]s.%[6]s || jsonb_build_object('barcodes', EXCLUDED.%[6]s->'barcodes')
+					ELSE %[7]s.%[6]s END
+			WHERE `+hasPrice+` AND (%[7]s.%[3]s, %[7]s.%[4]s, %[7]s.%[5]s)
+					IS DISTINCT FROM (EXCLUDED.%[3]s, EXCLUDED.%[4]s, EXCLUDED.%[5]s)
+				OR `+hasBarcodes+` AND %[7]s.%[6]s->'barcodes' IS DISTINCT FROM EXCLUDED.%[6]s->'barcodes'`,
+				articleColumn, idColumn, priceColumn, commissionColumn, currencyColumn, infoColumn, tableName,
+				marketplaceColumn, accountColumn,
+			),
 		).
 		PlaceholderFormat(sq.Dollar)
 
 	for _, item := range orders {
-		qb = qb.Values(item.ID, item.Article, item.OrderCreatedAt.Time, item.Items, item.Marketplace, item.Info)
+		qb = qb.Values(
+			item.ID, item.Article, item.OrderCreatedAt.Time, item.Items, item.Marketplace, item.GetAccount(), item.Info,
+			item.Price, item.Commission, item.Currency,
+		)
 	}
 
 	query, args, err := qb.ToSql()
@@ -54,26 +98,139 @@ func (s *Store) AddOrders(ctx context.Context, orders []Order) error {
 		return errors.Wrap(err, "sq.ToSql")
 	}
 
-	_, err = s.dbPool.Exec(ctx, query, args...)
+	tag, err := s.dbPool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "dbPool.Exec")
+	}
 
-	return errors.Wrap(err, "dbPool.Exec")
+	if tag.RowsAffected() > 0 {
+		s.log.InfoContext(ctx, "orders added to queue", slog.Int64("affected", tag.RowsAffected()))
+	}
+
+	return nil
 }
 
 func (s *Store) GetOrders(ctx context.Context, filter ListFilter) ([]Order, error) {
-	qb := sq.Select("*").
+	qb := applyListFilter(sq.Select("*").From(tableName).Limit(100), filter)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "sq.ToSql")
+	}
+
+	var items []Order
+	err = pgxscan.Select(ctx, s.dbPool, &items, query, args...)
+
+	return items, errors.Wrap(err, "pgxscan.Select")
+}
+
+// ExportQueue те же заказы, что и GetOrders, но без ограничения количества, строки отдаются в handle по одной
+func (s *Store) ExportQueue(ctx context.Context, filter ListFilter, handle func(row ExportRow) error) error {
+	return s.export(ctx, applyListFilter(exportQuery(), filter), handle)
+}
+
+// ExportCompleted позиции, закрытые в интервале [From, To), включая отменённые
+func (s *Store) ExportCompleted(ctx context.Context, filter ExportFilter, handle func(row ExportRow) error) error {
+	qb := exportQuery().
+		Where(sq.GtOrEq{completedAtColumn: filter.From}).
+		Where(sq.Lt{completedAtColumn: filter.To}).
+		OrderBy(completedAtColumn).
+		PlaceholderFormat(sq.Dollar)
+
+	if len(filter.Marketplace) > 0 {
+		qb = qb.Where(sq.Eq{marketplaceColumn: filter.Marketplace})
+	}
+	if len(filter.Account) > 0 {
+		qb = qb.Where(sq.Eq{accountColumn: filter.Account})
+	}
+
+	return s.export(ctx, qb, handle)
+}
+
+func (s *Store) export(ctx context.Context, qb sq.SelectBuilder, handle func(row ExportRow) error) error {
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "sq.ToSql")
+	}
+
+	rows, err := s.dbPool.Query(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "dbPool.Query")
+	}
+	defer rows.Close()
+
+	scanner := pgxscan.NewRowScanner(rows)
+	for rows.Next() {
+		var row ExportRow
+		if err = scanner.Scan(&row); err != nil {
+			return errors.Wrap(err, "scanner.Scan")
+		}
+
+		if err = handle(row); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(rows.Err(), "rows.Err")
+}
+
+// exportQuery название берётся из карточки через lateral, чтобы колонки очереди в условиях оставались однозначными
+func exportQuery() sq.SelectBuilder {
+	return sq.Select(idColumn, articleColumn, marketplaceColumn, accountColumn, orderCreatedAtColumn).
+		Column(`COALESCE(NULLIF(card.name, ''), info->>'name', '') AS name`).
+		Column(`COALESCE(info->>'order_number', '') AS order_number`).
+		Column(`GREATEST(COALESCE((info->>'quantity')::int, 1), 1) AS quantity`).
+		Column(`CASE WHEN (info->>'order_shipment_date')::timestamptz > '2000-01-01'
+			THEN (info->>'order_shipment_date')::timestamptz END AS shipment_at`).
+		Column(`COALESCE((info->>'is_cancelled')::boolean, false) AS is_cancelled`).
+		Columns(isPrintingColumn, isCompleteColumn, printingAtColumn, completedAtColumn).
 		From(tableName).
-		Limit(100).
-		Where(sq.Eq{marketplaceColumn: filter.GetMarketplace()}).
-		Where(sq.Gt{createdAtColumn: time.Now().Add(-time.Hour * 24 * 7)}).
+		JoinClause(`LEFT JOIN LATERAL (
+			SELECT cards.name FROM cards
+			WHERE cards.article = orders_queue.article
+				AND cards.marketplace = orders_queue.marketplace
+				AND cards.account = orders_queue.account
+			LIMIT 1
+		) card ON true`)
+}
+
+// applyListFilter условия и порядок очереди, общие для списка и выгрузки
+func applyListFilter(qb sq.SelectBuilder, filter ListFilter) sq.SelectBuilder {
+	qb = qb.Where(sq.Eq{marketplaceColumn: filter.GetMarketplace()}).
 		Where(sq.Eq{isCompleteColumn: filter.WithParentComplete}).
 		PlaceholderFormat(sq.Dollar)
 
-	if filter.GetMarketplace() == card.MpOzon.String() || filter.GetMarketplace() == card.MpYandex.String() {
+	// ручные заказы на заказ могут делаться дольше окна очереди, открытые показываются всегда
+	if filter.GetMarketplace() != card.MpManual.String() || filter.WithParentComplete {
+		qb = qb.Where(sq.Gt{createdAtColumn: time.Now().Add(-queueWindow)})
+	}
+
+	if len(filter.Account) > 0 {
+		qb = qb.Where(sq.Eq{accountColumn: filter.Account})
+	}
+
+	switch card.Marketplace(filter.GetMarketplace()) {
+	case card.MpOzon, card.MpYandex, card.MpMegamarket, card.MpManual:
 		qb = qb.OrderBy(`info->>'order_shipment_date'`, orderCreatedAtColumn)
-	} else {
+	default:
 		qb = qb.OrderBy(orderCreatedAtColumn)
 	}
 
+	return qb
+}
+
+// ListOpen незавершённые заказы всех маркетплейсов в окне очереди, ручные - без ограничения по сроку
+func (s *Store) ListOpen(ctx context.Context) ([]Order, error) {
+	qb := sq.Select("*").
+		From(tableName).
+		Where(sq.Or{
+			sq.Gt{createdAtColumn: time.Now().Add(-queueWindow)},
+			sq.Eq{marketplaceColumn: card.MpManual.String()},
+		}).
+		Where(sq.Eq{isCompleteColumn: false}).
+		OrderBy(orderCreatedAtColumn).
+		PlaceholderFormat(sq.Dollar)
+
 	query, args, err := qb.ToSql()
 	if err != nil {
 		return nil, errors.Wrap(err, "sq.ToSql")
@@ -85,10 +242,237 @@ func (s *Store) GetOrders(ctx context.Context, filter ListFilter) ([]Order, erro
 	return items, errors.Wrap(err, "pgxscan.Select")
 }
 
-func (s *Store) SetCompleteByOrderIDs(ctx context.Context, orderIDs []string) error {
+// GetOrder заказ кабинета маркетплейса по id, ErrNotFound если его нет
+func (s *Store) GetOrder(ctx context.Context, id, marketplace, account string) (Order, error) {
+	qb := sq.Select("*").
+		From(tableName).
+		Where(sq.Eq{idColumn: id}).
+		Where(sq.Eq{marketplaceColumn: marketplace}).
+		Where(sq.Eq{accountColumn: account}).
+		Limit(1).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return Order{}, errors.Wrap(err, "sq.ToSql")
+	}
+
+	var item Order
+	if err = pgxscan.Get(ctx, s.dbPool, &item, query, args...); err != nil {
+		if pgxscan.NotFound(err) {
+			return Order{}, ErrNotFound
+		}
+
+		return Order{}, errors.Wrap(err, "pgxscan.Get")
+	}
+
+	return item, nil
+}
+
+// GetOrderPositions все позиции заказа маркетплейса, у заказов Ozon и Яндекса их может быть несколько
+func (s *Store) GetOrderPositions(ctx context.Context, id, marketplace string) ([]Order, error) {
+	qb := sq.Select("*").
+		From(tableName).
+		Where(sq.Eq{idColumn: id}).
+		Where(sq.Eq{marketplaceColumn: marketplace}).
+		OrderBy(articleColumn).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "sq.ToSql")
+	}
+
+	var items []Order
+	err = pgxscan.Select(ctx, s.dbPool, &items, query, args...)
+
+	return items, errors.Wrap(err, "pgxscan.Select")
+}
+
+// FindOpenByCode открытая позиция очереди по номеру заказа, штрихкоду с этикетки заказа или штрихкоду карточки.
+// Ищет в том же окне, что и очередь, иначе штрихкод карточки находил бы давно забытые позиции.
+// Сначала совпадения по самому заказу, затем позиции уже в печати - их и упаковывают, затем по сроку отгрузки.
+// ErrNotFound если открытых позиций с таким кодом нет
+func (s *Store) FindOpenByCode(ctx context.Context, code string) (Order, error) {
+	orderMatch := sq.Or{
+		sq.Eq{idColumn: code},
+		sq.Expr(`info->>'order_number' = ?`, code),
+		sq.Expr(`info->'barcodes' @> jsonb_build_array(?::text)`, code),
+	}
+	orderMatchSQL, orderMatchArgs, err := orderMatch.ToSql()
+	if err != nil {
+		return Order{}, errors.Wrap(err, "sq.ToSql")
+	}
+
+	// условие без параметра, иначе планировщик не возьмёт частичный индекс orders_queue_open_barcodes
+	qb := sq.Select("*").
+		From(tableName).
+		Where("NOT "+isCompleteColumn).
+		Where(sq.Or{
+			sq.Gt{createdAtColumn: time.Now().Add(-queueWindow)},
+			sq.Eq{marketplaceColumn: card.MpManual.String()},
+		}).
+		Where(sq.Or{
+			orderMatch,
+			sq.Expr(`EXISTS (
+				SELECT 1 FROM cards
+				WHERE cards.barcodes @> ARRAY[?::text]
+					AND cards.article = orders_queue.article
+					AND cards.marketplace = orders_queue.marketplace
+					AND cards.account = orders_queue.account
+			)`, code),
+		}).
+		OrderByClause(`(`+orderMatchSQL+`) DESC`, orderMatchArgs...).
+		OrderBy(isPrintingColumn+` DESC`, `info->>'order_shipment_date'`, orderCreatedAtColumn).
+		Limit(1).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return Order{}, errors.Wrap(err, "sq.ToSql")
+	}
+
+	var item Order
+	if err = pgxscan.Get(ctx, s.dbPool, &item, query, args...); err != nil {
+		if pgxscan.NotFound(err) {
+			return Order{}, ErrNotFound
+		}
+
+		return Order{}, errors.Wrap(err, "pgxscan.Get")
+	}
+
+	return item, nil
+}
+
+// AdvanceItem переводит открытую позицию в следующий статус: новая - в печать, в печати - готова.
+// В SET справа старые значения, поэтому оба перехода делает один запрос без гонки между сканерами.
+// ErrNotFound если позиции нет или она уже закрыта
+func (s *Store) AdvanceItem(ctx context.Context, id, article, marketplace, account string) (Order, error) {
+	qb := sq.Update(tableName).
+		Set(isPrintingColumn, true).
+		Set(isCompleteColumn, sq.Expr(isPrintingColumn)).
+		Set(updatedAtColumn, sq.Expr("now()")).
+		Where(sq.Eq{idColumn: id}).
+		Where(sq.Eq{articleColumn: article}).
+		Where(sq.Eq{marketplaceColumn: marketplace}).
+		Where(sq.Eq{accountColumn: account}).
+		Where(sq.Eq{isCompleteColumn: false}).
+		Suffix("RETURNING *").
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return Order{}, errors.Wrap(err, "sq.ToSql")
+	}
+
+	var item Order
+	if err = pgxscan.Get(ctx, s.dbPool, &item, query, args...); err != nil {
+		if pgxscan.NotFound(err) {
+			return Order{}, ErrNotFound
+		}
+
+		return Order{}, errors.Wrap(err, "pgxscan.Get")
+	}
+
+	return item, nil
+}
+
+// UpdateOrder перезаписывает артикул, состав, info, сумму и статус заказа, id, маркетплейс и кабинет не меняются
+func (s *Store) UpdateOrder(ctx context.Context, order Order) error {
+	qb := sq.Update(tableName).
+		Set(articleColumn, order.Article).
+		Set(itemsColumn, order.Items).
+		Set(infoColumn, order.Info).
+		Set(isCompleteColumn, order.IsComplete).
+		Set(priceColumn, order.Price).
+		Set(currencyColumn, order.Currency).
+		Set(updatedAtColumn, sq.Expr("now()")).
+		Where(sq.Eq{idColumn: order.ID}).
+		Where(sq.Eq{marketplaceColumn: order.Marketplace}).
+		Where(sq.Eq{accountColumn: order.GetAccount()}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "sq.ToSql")
+	}
+
+	tag, err := s.dbPool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "dbPool.Exec")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) GetOpenStats(ctx context.Context) ([]OpenStat, error) {
+	qb := sq.Select(
+		marketplaceColumn,
+		isPrintingColumn,
+		"count(*) AS total",
+		`count(*) FILTER (
+			WHERE (info->>'order_shipment_date')::timestamptz BETWEEN '2000-01-01' AND now()
+		) AS overdue`,
+	).
+		From(tableName).
+		Where(sq.Gt{createdAtColumn: time.Now().Add(-queueWindow)}).
+		Where(sq.Eq{isCompleteColumn: false}).
+		GroupBy(marketplaceColumn, isPrintingColumn).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "sq.ToSql")
+	}
+
+	var items []OpenStat
+	err = pgxscan.Select(ctx, s.dbPool, &items, query, args...)
+
+	return items, errors.Wrap(err, "pgxscan.Select")
+}
+
+// GetDueStats dayStart - начало сегодняшнего дня в часовом поясе получателя сводки
+func (s *Store) GetDueStats(ctx context.Context, dayStart time.Time) ([]DueStat, error) {
+	const shipmentAt = `(info->>'order_shipment_date')::timestamptz`
+
+	tomorrow := dayStart.AddDate(0, 0, 1)
+	qb := sq.Select(marketplaceColumn).
+		Column(sq.Expr(`count(*) FILTER (WHERE `+shipmentAt+` BETWEEN '2000-01-01' AND ?) AS overdue`, dayStart)).
+		Column(sq.Expr(`count(*) FILTER (WHERE `+shipmentAt+` >= ? AND `+shipmentAt+` < ?) AS today`, dayStart, tomorrow)).
+		Column(sq.Expr(`count(*) FILTER (WHERE `+shipmentAt+` >= ? AND `+shipmentAt+` < ?) AS tomorrow`, tomorrow, tomorrow.AddDate(0, 0, 1))).
+		From(tableName).
+		Where(sq.Or{
+			sq.Gt{createdAtColumn: time.Now().Add(-queueWindow)},
+			sq.Eq{marketplaceColumn: card.MpManual.String()},
+		}).
+		Where(sq.Eq{isCompleteColumn: false}).
+		GroupBy(marketplaceColumn).
+		OrderBy(marketplaceColumn).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "sq.ToSql")
+	}
+
+	var items []DueStat
+	err = pgxscan.Select(ctx, s.dbPool, &items, query, args...)
+
+	return items, errors.Wrap(err, "pgxscan.Select")
+}
+
+// SetCompleteByOrderIDs закрывает заказы одного кабинета, id разных маркетплейсов и кабинетов могут совпадать
+func (s *Store) SetCompleteByOrderIDs(ctx context.Context, marketplace, account string, orderIDs []string) error {
 	qb := sq.Update(tableName).
 		Set(isCompleteColumn, true).
 		Where(sq.Eq{idColumn: orderIDs}).
+		Where(sq.Eq{marketplaceColumn: marketplace}).
+		Where(sq.Eq{accountColumn: account}).
+		Where(sq.Eq{isCompleteColumn: false}).
 		PlaceholderFormat(sq.Dollar)
 
 	query, args, err := qb.ToSql()
@@ -96,9 +480,44 @@ func (s *Store) SetCompleteByOrderIDs(ctx context.Context, orderIDs []string) er
 		return errors.Wrap(err, "sq.ToSql")
 	}
 
-	_, err = s.dbPool.Exec(ctx, query, args...)
+	tag, err := s.dbPool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "dbPool.Exec")
+	}
 
-	return errors.Wrap(err, "dbPool.Exec")
+	if tag.RowsAffected() > 0 {
+		s.log.InfoContext(ctx, "orders completed", slog.Int64("updated", tag.RowsAffected()))
+	}
+
+	return nil
+}
+
+// SetCancelledByOrderIDs закрывает отменённые маркетплейсом заказы и помечает их отменёнными
+func (s *Store) SetCancelledByOrderIDs(ctx context.Context, marketplace, account string, orderIDs []string) error {
+	qb := sq.Update(tableName).
+		Set(isCompleteColumn, true).
+		Set(infoColumn, sq.Expr(infoColumn+` || '{"is_cancelled": true}'::jsonb`)).
+		Where(sq.Eq{idColumn: orderIDs}).
+		Where(sq.Eq{marketplaceColumn: marketplace}).
+		Where(sq.Eq{accountColumn: account}).
+		Where(sq.Eq{isCompleteColumn: false}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "sq.ToSql")
+	}
+
+	tag, err := s.dbPool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "dbPool.Exec")
+	}
+
+	if tag.RowsAffected() > 0 {
+		s.log.InfoContext(ctx, "orders cancelled", slog.Int64("updated", tag.RowsAffected()))
+	}
+
+	return nil
 }
 
 func (s *Store) SetComplete(ctx context.Context, id string, isComplete bool) error {
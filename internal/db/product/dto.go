@@ -0,0 +1,57 @@
+package product
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// Product мастер-товар, его состав, файлы и себестоимость перекрывают значения привязанных карточек.
+// Пустое поле не перекрывает: действует значение карточки
+type Product struct {
+	ID            uuid.UUID    `db:"id" json:"id"`
+	Name          string       `db:"name" json:"name"`
+	Articles      []string     `db:"articles" json:"articles"`
+	Files         []string     `db:"files" json:"files"`
+	IsComposite   bool         `db:"is_composite" json:"is_composite"`
+	FilamentGrams float64      `db:"filament_grams" json:"filament_grams"`
+	PrintHours    float64      `db:"print_hours" json:"print_hours"`
+	CreatedAt     sql.NullTime `db:"created_at" json:"created_at"`
+	UpdatedAt     sql.NullTime `db:"updated_at" json:"updated_at"`
+}
+
+// LinkedCard карточка маркетплейса, привязанная к мастер-товару
+type LinkedCard struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	ProductID   uuid.UUID `db:"product_id" json:"product_id"`
+	Marketplace string    `db:"marketplace" json:"marketplace"`
+	Account     string    `db:"account" json:"account"`
+	Article     string    `db:"article" json:"article"`
+	Name        string    `db:"name" json:"name"`
+	Photo       string    `db:"photo" json:"photo"`
+}
+
+// Suggestion непривязанная карточка и похожая на неё карточка другого маркетплейса.
+// Если MatchProductID заполнен, карточку можно привязать к уже существующему мастеру
+type Suggestion struct {
+	CardID           uuid.UUID     `db:"card_id" json:"card_id"`
+	Marketplace      string        `db:"marketplace" json:"marketplace"`
+	Article          string        `db:"article" json:"article"`
+	Name             string        `db:"name" json:"name"`
+	MatchCardID      uuid.UUID     `db:"match_card_id" json:"match_card_id"`
+	MatchMarketplace string        `db:"match_marketplace" json:"match_marketplace"`
+	MatchArticle     string        `db:"match_article" json:"match_article"`
+	MatchName        string        `db:"match_name" json:"match_name"`
+	MatchProductID   uuid.NullUUID `db:"match_product_id" json:"match_product_id"`
+	// ArticleScore, NameScore сходство от 0 до 1, совпадение артикула без учёта регистра - 1
+	ArticleScore float64 `db:"article_score" json:"article_score"`
+	NameScore    float64 `db:"name_score" json:"name_score"`
+	Score        float64 `db:"score" json:"score"`
+}
+
+// SuggestionFilter MinScore - порог сходства названий или артикулов
+type SuggestionFilter struct {
+	Marketplace string
+	MinScore    float64
+	Limit       uint64
+}
@@ -0,0 +1,286 @@
+package product
+
+import (
+	"context"
+	"log/slog"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+)
+
+const (
+	tableName         = "products"
+	cardsTableName    = "cards"
+	idColumn          = "id"
+	nameColumn        = "name"
+	articlesColumn    = "articles"
+	filesColumn       = "files"
+	isCompositeColumn = "is_composite"
+	filamentColumn    = "filament_grams"
+	printHoursColumn  = "print_hours"
+	productIDColumn   = "product_id"
+	createdAtColumn   = "created_at"
+	updatedAtColumn   = "updated_at"
+)
+
+// ErrNotFound мастер-товара с таким id нет
+var ErrNotFound = errors.New("product not found")
+
+type Store struct {
+	dbPool *pgxpool.Pool
+	log    *slog.Logger
+}
+
+func New(dbPool *pgxpool.Pool, log *slog.Logger) *Store {
+	return &Store{dbPool: dbPool, log: log}
+}
+
+// Create мастер-товар и привязка к нему карточек cardIDs в одной транзакции
+func (s *Store) Create(ctx context.Context, product Product, cardIDs []uuid.UUID) error {
+	qb := sq.Insert(tableName).
+		Columns(idColumn, nameColumn, articlesColumn, filesColumn, isCompositeColumn, filamentColumn, printHoursColumn).
+		Values(
+			product.ID, product.Name, nullIfEmpty(product.Articles), nullIfEmpty(product.Files),
+			composite(product), nullIfZero(product.FilamentGrams), nullIfZero(product.PrintHours),
+		).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "sq.ToSql")
+	}
+
+	return db.TransactionWrapper(ctx, s.dbPool, func(ctx context.Context, tx db.Conn) error {
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "tx.Exec")
+		}
+
+		_, err := link(ctx, tx, uuid.NullUUID{UUID: product.ID, Valid: true}, cardIDs)
+
+		return err
+	})
+}
+
+// Update перезаписывает все поля мастер-товара, ErrNotFound если его нет
+func (s *Store) Update(ctx context.Context, product Product) error {
+	qb := sq.Update(tableName).
+		Set(nameColumn, product.Name).
+		Set(articlesColumn, nullIfEmpty(product.Articles)).
+		Set(filesColumn, nullIfEmpty(product.Files)).
+		Set(isCompositeColumn, composite(product)).
+		Set(filamentColumn, nullIfZero(product.FilamentGrams)).
+		Set(printHoursColumn, nullIfZero(product.PrintHours)).
+		Where(sq.Eq{idColumn: product.ID}).
+		PlaceholderFormat(sq.Dollar)
+
+	return s.execOne(ctx, qb)
+}
+
+// Delete карточки мастер-товара отвязываются внешним ключом ON DELETE SET NULL
+func (s *Store) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.execOne(ctx, sq.Delete(tableName).Where(sq.Eq{idColumn: id}).PlaceholderFormat(sq.Dollar))
+}
+
+func (s *Store) Get(ctx context.Context, id uuid.UUID) (Product, error) {
+	qb := selectProducts().
+		Where(sq.Eq{idColumn: id}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return Product{}, errors.Wrap(err, "sq.ToSql")
+	}
+
+	var item Product
+	if err = pgxscan.Get(ctx, s.dbPool, &item, query, args...); err != nil {
+		if pgxscan.NotFound(err) {
+			return Product{}, ErrNotFound
+		}
+
+		return Product{}, errors.Wrap(err, "pgxscan.Get")
+	}
+
+	return item, nil
+}
+
+func (s *Store) List(ctx context.Context) ([]Product, error) {
+	return selectRows[Product](ctx, s.dbPool, selectProducts().OrderBy(nameColumn, idColumn))
+}
+
+// ListCards карточки, привязанные к мастер-товарам productIDs
+func (s *Store) ListCards(ctx context.Context, productIDs []uuid.UUID) ([]LinkedCard, error) {
+	qb := sq.Select(idColumn, productIDColumn, "marketplace", "account", "article", nameColumn).
+		Column(`COALESCE(photo, '') AS photo`).
+		From(cardsTableName).
+		Where(sq.Eq{productIDColumn: productIDs}).
+		OrderBy(productIDColumn, "marketplace", "account", "article").
+		PlaceholderFormat(sq.Dollar)
+
+	return selectRows[LinkedCard](ctx, s.dbPool, qb)
+}
+
+// GetCards собственные значения карточек, из них заполняется новый мастер-товар
+func (s *Store) GetCards(ctx context.Context, cardIDs []uuid.UUID) ([]card.Card, error) {
+	qb := sq.Select("*").
+		From(cardsTableName).
+		Where(sq.Eq{idColumn: cardIDs}).
+		OrderBy("marketplace", "account", "article").
+		PlaceholderFormat(sq.Dollar)
+
+	return selectRows[card.Card](ctx, s.dbPool, qb)
+}
+
+// Link привязывает карточки к мастер-товару, привязка к другому мастеру перезаписывается.
+// Возвращает количество найденных карточек
+func (s *Store) Link(ctx context.Context, productID uuid.UUID, cardIDs []uuid.UUID) (int64, error) {
+	return link(ctx, s.dbPool, uuid.NullUUID{UUID: productID, Valid: true}, cardIDs)
+}
+
+// Unlink карточки снова используют собственные состав, файлы и себестоимость
+func (s *Store) Unlink(ctx context.Context, cardIDs []uuid.UUID) (int64, error) {
+	return link(ctx, s.dbPool, uuid.NullUUID{}, cardIDs)
+}
+
+// Suggestions пары похожих карточек разных маркетплейсов, первая из пары ещё не привязана к мастеру.
+// Пара двух непривязанных карточек выдаётся один раз. Кандидаты собираются тремя соединениями,
+// каждое идёт по своему индексу: lower(article), триграммы name и триграммы article
+func (s *Store) Suggestions(ctx context.Context, filter SuggestionFilter) ([]Suggestion, error) {
+	const (
+		articleScore = `CASE WHEN lower(a.article) = lower(b.article) THEN 1 ELSE similarity(a.article, b.article) END`
+		nameScore    = `similarity(a.name, b.name)`
+		candidates   = `(
+			SELECT a.id AS card_id, b.id AS match_card_id
+			FROM cards a JOIN cards b ON lower(b.article) = lower(a.article)
+			WHERE a.product_id IS NULL
+			UNION
+			SELECT a.id, b.id FROM cards a JOIN cards b ON b.name % a.name WHERE a.product_id IS NULL
+			UNION
+			SELECT a.id, b.id FROM cards a JOIN cards b ON b.article % a.article WHERE a.product_id IS NULL
+		) pairs`
+	)
+
+	qb := sq.Select().
+		Column("a.id AS card_id").
+		Column("a.marketplace, a.article, a.name").
+		Column("b.id AS match_card_id").
+		Column("b.marketplace AS match_marketplace, b.article AS match_article, b.name AS match_name").
+		Column("b.product_id AS match_product_id").
+		Column(articleScore+` AS article_score`).
+		Column(nameScore+` AS name_score`).
+		Column(`GREATEST(`+articleScore+`, `+nameScore+`) AS score`).
+		From(candidates).
+		Join(cardsTableName+" a ON a.id = pairs.card_id").
+		Join(cardsTableName+" b ON b.id = pairs.match_card_id").
+		Where("b.marketplace <> a.marketplace").
+		Where("(b.product_id IS NOT NULL OR a.id < b.id)").
+		Where(sq.Or{
+			sq.Expr(`lower(a.article) = lower(b.article)`),
+			sq.Expr(nameScore+` >= ?`, filter.MinScore),
+			sq.Expr(`similarity(a.article, b.article) >= ?`, filter.MinScore),
+		}).
+		OrderBy("score DESC", "a.article").
+		Limit(filter.Limit).
+		PlaceholderFormat(sq.Dollar)
+
+	if len(filter.Marketplace) > 0 {
+		qb = qb.Where(sq.Eq{"a.marketplace": filter.Marketplace})
+	}
+
+	return selectRows[Suggestion](ctx, s.dbPool, qb)
+}
+
+func link(ctx context.Context, conn db.Conn, productID uuid.NullUUID, cardIDs []uuid.UUID) (int64, error) {
+	if len(cardIDs) == 0 {
+		return 0, nil
+	}
+
+	qb := sq.Update(cardsTableName).
+		Set(productIDColumn, productID).
+		Where(sq.Eq{idColumn: cardIDs}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "sq.ToSql")
+	}
+
+	tag, err := conn.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "conn.Exec")
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func (s *Store) execOne(ctx context.Context, qb sq.Sqlizer) error {
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "sq.ToSql")
+	}
+
+	tag, err := s.dbPool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "dbPool.Exec")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func selectRows[T any](ctx context.Context, dbPool *pgxpool.Pool, qb sq.SelectBuilder) ([]T, error) {
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "sq.ToSql")
+	}
+
+	items := make([]T, 0)
+	err = pgxscan.Select(ctx, dbPool, &items, query, args...)
+
+	return items, errors.Wrap(err, "pgxscan.Select")
+}
+
+// selectProducts незаполненные поля мастера отдаются пустыми значениями
+func selectProducts() sq.SelectBuilder {
+	return sq.Select(idColumn, nameColumn, createdAtColumn, updatedAtColumn).
+		Column(`COALESCE(` + articlesColumn + `, '{}') AS ` + articlesColumn).
+		Column(`COALESCE(` + filesColumn + `, '{}') AS ` + filesColumn).
+		Column(`COALESCE(` + isCompositeColumn + `, false) AS ` + isCompositeColumn).
+		Column(`COALESCE(` + filamentColumn + `, 0) AS ` + filamentColumn).
+		Column(`COALESCE(` + printHoursColumn + `, 0) AS ` + printHoursColumn).
+		From(tableName)
+}
+
+// nullIfEmpty пустое поле мастера хранится как NULL, тогда действует значение привязанной карточки
+func nullIfEmpty(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	return values
+}
+
+func nullIfZero(value float64) *float64 {
+	if value == 0 {
+		return nil
+	}
+
+	return &value
+}
+
+// composite признак состава задаётся вместе с articles, без них берётся из карточки
+func composite(product Product) *bool {
+	if len(product.Articles) == 0 {
+		return nil
+	}
+
+	return &product.IsComposite
+}
@@ -0,0 +1,151 @@
+package report
+
+import (
+	"strconv"
+	"time"
+)
+
+// Filter период по дате заказа [From, To), пустой Marketplace - все маркетплейсы
+type Filter struct {
+	From        time.Time
+	To          time.Time
+	Marketplace string
+	// Location граница суток для отчёта по дням
+	Location *time.Location
+	Limit    uint64
+	// Costs ставки модели себестоимости для отчётов маржинальности
+	Costs Costs
+}
+
+// Costs рубли за килограмм филамента и за час работы принтера
+type Costs struct {
+	MaterialPerKg float64
+	MachineHour   float64
+}
+
+type DailyOrders struct {
+	Day         time.Time `db:"day" json:"day"`
+	Marketplace string    `db:"marketplace" json:"marketplace"`
+	Orders      int64     `db:"orders" json:"orders"`
+	Quantity    int64     `db:"quantity" json:"quantity"`
+	Cancelled   int64     `db:"cancelled" json:"cancelled"`
+}
+
+// LeadTime медианы в секундах, считаются только по позициям, у которых есть соответствующая отметка
+type LeadTime struct {
+	Marketplace        string  `db:"marketplace" json:"marketplace"`
+	Orders             int64   `db:"orders" json:"orders"`
+	MedianToPrinting   float64 `db:"median_to_printing" json:"median_to_printing_seconds"`
+	MedianToComplete   float64 `db:"median_to_complete" json:"median_to_complete_seconds"`
+	MedianPrintingTime float64 `db:"median_printing_time" json:"median_printing_time_seconds"`
+}
+
+type ArticleDemand struct {
+	Article     string `db:"article" json:"article"`
+	Marketplace string `db:"marketplace" json:"marketplace"`
+	Orders      int64  `db:"orders" json:"orders"`
+	Quantity    int64  `db:"quantity" json:"quantity"`
+}
+
+// OnTime закрытые до даты отгрузки позиции среди закрытых неотменённых с известной датой отгрузки
+type OnTime struct {
+	Marketplace string  `db:"marketplace" json:"marketplace"`
+	Completed   int64   `db:"completed" json:"completed"`
+	OnTime      int64   `db:"on_time" json:"on_time"`
+	Rate        float64 `db:"rate" json:"rate"`
+}
+
+// Economics суммы в рублях валюты Currency, MarginRate - доля маржи в выручке.
+// Себестоимость задана в рублях, у позиций в других валютах она не считается и они входят в WithoutCost
+type Economics struct {
+	Currency    string  `db:"currency" json:"currency"`
+	Orders      int64   `db:"orders" json:"orders"`
+	Quantity    int64   `db:"quantity" json:"quantity"`
+	Revenue     float64 `db:"revenue" json:"revenue"`
+	Commission  float64 `db:"commission" json:"commission"`
+	Cost        float64 `db:"cost" json:"cost"`
+	Margin      float64 `db:"margin" json:"margin"`
+	MarginRate  float64 `db:"margin_rate" json:"margin_rate"`
+	WithoutCost int64   `db:"without_cost" json:"without_cost"`
+}
+
+type ArticleMargin struct {
+	Article     string `db:"article" json:"article"`
+	Marketplace string `db:"marketplace" json:"marketplace"`
+	Economics
+}
+
+// ProductMargin ProductID пустой у карточек без мастер-товара, Article тогда вида marketplace:article
+type ProductMargin struct {
+	ProductID    string `db:"product_id" json:"product_id"`
+	Name         string `db:"name" json:"name"`
+	Article      string `db:"article" json:"article"`
+	Marketplaces int64  `db:"marketplaces" json:"marketplaces"`
+	Economics
+}
+
+type MarketplaceMargin struct {
+	Marketplace string `db:"marketplace" json:"marketplace"`
+	Economics
+}
+
+var economicsHeader = []string{"currency", "orders", "quantity", "revenue", "commission", "cost", "margin", "margin_rate", "without_cost"}
+
+var (
+	DailyOrdersHeader   = []string{"day", "marketplace", "orders", "quantity", "cancelled"}
+	LeadTimeHeader      = []string{"marketplace", "orders", "median_to_printing_seconds", "median_to_complete_seconds", "median_printing_time_seconds"}
+	ArticleDemandHeader = []string{"article", "marketplace", "orders", "quantity"}
+	OnTimeHeader        = []string{"marketplace", "completed", "on_time", "rate"}
+
+	ArticleMarginHeader     = append([]string{"article", "marketplace"}, economicsHeader...)
+	MarketplaceMarginHeader = append([]string{"marketplace"}, economicsHeader...)
+	ProductMarginHeader     = append([]string{"product_id", "name", "article", "marketplaces"}, economicsHeader...)
+)
+
+func (r DailyOrders) CSVRecord() []string {
+	return []string{r.Day.Format(time.DateOnly), r.Marketplace, itoa(r.Orders), itoa(r.Quantity), itoa(r.Cancelled)}
+}
+
+func (r LeadTime) CSVRecord() []string {
+	return []string{r.Marketplace, itoa(r.Orders), ftoa(r.MedianToPrinting), ftoa(r.MedianToComplete), ftoa(r.MedianPrintingTime)}
+}
+
+func (r ArticleDemand) CSVRecord() []string {
+	return []string{r.Article, r.Marketplace, itoa(r.Orders), itoa(r.Quantity)}
+}
+
+func (r OnTime) CSVRecord() []string {
+	return []string{r.Marketplace, itoa(r.Completed), itoa(r.OnTime), strconv.FormatFloat(r.Rate, 'f', 4, 64)}
+}
+
+func (r ArticleMargin) CSVRecord() []string {
+	return append([]string{r.Article, r.Marketplace}, r.Economics.csvRecord()...)
+}
+
+func (r ProductMargin) CSVRecord() []string {
+	return append([]string{r.ProductID, r.Name, r.Article, itoa(r.Marketplaces)}, r.Economics.csvRecord()...)
+}
+
+func (r MarketplaceMargin) CSVRecord() []string {
+	return append([]string{r.Marketplace}, r.Economics.csvRecord()...)
+}
+
+func (e Economics) csvRecord() []string {
+	return []string{
+		e.Currency, itoa(e.Orders), itoa(e.Quantity),
+		money(e.Revenue), money(e.Commission), money(e.Cost), money(e.Margin),
+		strconv.FormatFloat(e.MarginRate, 'f', 4, 64), itoa(e.WithoutCost),
+	}
+}
+
+func money(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func itoa(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
+
+func ftoa(v float64) string {
+	return strconv.FormatFloat(v, 'f', 0, 64)
+}
@@ -0,0 +1,173 @@
+package report
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	tableName = "orders_queue"
+
+	// quantity в info может отсутствовать у старых записей, такая позиция считается за одну штуку
+	quantityExpr  = `GREATEST(COALESCE((info->>'quantity')::int, 1), 1)`
+	cancelledExpr = `COALESCE((info->>'is_cancelled')::boolean, false)`
+	shipmentExpr  = `(info->>'order_shipment_date')::timestamptz`
+
+	// costCurrency валюта MaterialCostPerKg и MachineHourRate
+	costCurrency = "RUB"
+)
+
+// Store агрегаты по очереди для отчётов, вся группировка на стороне Postgres
+type Store struct {
+	dbPool *pgxpool.Pool
+}
+
+func New(dbPool *pgxpool.Pool) *Store {
+	return &Store{dbPool: dbPool}
+}
+
+func (s *Store) DailyOrders(ctx context.Context, filter Filter) ([]DailyOrders, error) {
+	qb := sq.Select().
+		Column(sq.Expr(`date_trunc('day', order_created_at AT TIME ZONE ?)::date AS day`, filter.Location.String())).
+		Column("marketplace").
+		Column("count(*) AS orders").
+		Column(`sum(`+quantityExpr+`) AS quantity`).
+		Column(`count(*) FILTER (WHERE `+cancelledExpr+`) AS cancelled`).
+		From(tableName).
+		GroupBy("day", "marketplace").
+		OrderBy("day", "marketplace")
+
+	return selectRows[DailyOrders](ctx, s.dbPool, applyFilter(qb, filter))
+}
+
+func (s *Store) LeadTime(ctx context.Context, filter Filter) ([]LeadTime, error) {
+	qb := sq.Select("marketplace", "count(*) AS orders").
+		Column(`COALESCE(percentile_cont(0.5) WITHIN GROUP (
+			ORDER BY extract(epoch FROM printing_at - order_created_at)), 0) AS median_to_printing`).
+		Column(`COALESCE(percentile_cont(0.5) WITHIN GROUP (
+			ORDER BY extract(epoch FROM completed_at - order_created_at)), 0) AS median_to_complete`).
+		Column(`COALESCE(percentile_cont(0.5) WITHIN GROUP (
+			ORDER BY extract(epoch FROM completed_at - printing_at)), 0) AS median_printing_time`).
+		From(tableName).
+		Where(`NOT ` + cancelledExpr).
+		GroupBy("marketplace").
+		OrderBy("marketplace")
+
+	return selectRows[LeadTime](ctx, s.dbPool, applyFilter(qb, filter))
+}
+
+func (s *Store) TopArticles(ctx context.Context, filter Filter) ([]ArticleDemand, error) {
+	qb := sq.Select("article", "marketplace", "count(*) AS orders").
+		Column(`sum(`+quantityExpr+`) AS quantity`).
+		From(tableName).
+		Where(`NOT `+cancelledExpr).
+		GroupBy("article", "marketplace").
+		OrderBy("quantity DESC", "orders DESC", "article").
+		Limit(filter.Limit)
+
+	return selectRows[ArticleDemand](ctx, s.dbPool, applyFilter(qb, filter))
+}
+
+func (s *Store) OnTime(ctx context.Context, filter Filter) ([]OnTime, error) {
+	qb := sq.Select("marketplace", "count(*) AS completed").
+		Column(`count(*) FILTER (WHERE completed_at <= ` + shipmentExpr + `) AS on_time`).
+		Column(`COALESCE(round(count(*) FILTER (WHERE completed_at <= ` + shipmentExpr + `)::numeric / NULLIF(count(*), 0), 4), 0)::float8 AS rate`).
+		From(tableName).
+		Where(`NOT ` + cancelledExpr).
+		Where(sq.NotEq{"completed_at": nil}).
+		Where(shipmentExpr + ` > '2000-01-01'`).
+		GroupBy("marketplace").
+		OrderBy("marketplace")
+
+	return selectRows[OnTime](ctx, s.dbPool, applyFilter(qb, filter))
+}
+
+func (s *Store) ArticleMargin(ctx context.Context, filter Filter) ([]ArticleMargin, error) {
+	qb := economics(sq.Select(tableName+".article", tableName+".marketplace"), filter).
+		GroupBy(tableName+".article", tableName+".marketplace", tableName+".currency").
+		OrderBy("margin DESC", tableName+".article").
+		Limit(filter.Limit)
+
+	return selectRows[ArticleMargin](ctx, s.dbPool, applyFilter(qb, filter))
+}
+
+// ProductMargin маржинальность мастер-товара по всем маркетплейсам, позиции непривязанных карточек
+// остаются отдельными строками по артикулу маркетплейса
+func (s *Store) ProductMargin(ctx context.Context, filter Filter) ([]ProductMargin, error) {
+	qb := economics(sq.Select().
+		Column(`COALESCE(products.id::text, '') AS product_id`).
+		Column(`COALESCE(products.name, min(cards.name), '') AS name`).
+		Column(`CASE WHEN products.id IS NULL THEN min(`+tableName+`.marketplace || ':' || `+tableName+`.article)
+			ELSE '' END AS article`).
+		Column(`count(DISTINCT `+tableName+`.marketplace) AS marketplaces`), filter).
+		GroupBy("products.id", `CASE WHEN products.id IS NULL THEN `+tableName+`.marketplace || ':' || `+tableName+`.article END`,
+			tableName+".currency").
+		OrderBy("margin DESC", "name").
+		Limit(filter.Limit)
+
+	return selectRows[ProductMargin](ctx, s.dbPool, applyFilter(qb, filter))
+}
+
+func (s *Store) MarketplaceMargin(ctx context.Context, filter Filter) ([]MarketplaceMargin, error) {
+	qb := economics(sq.Select(tableName+".marketplace"), filter).
+		GroupBy(tableName+".marketplace", tableName+".currency").
+		OrderBy(tableName+".marketplace", tableName+".currency")
+
+	return selectRows[MarketplaceMargin](ctx, s.dbPool, applyFilter(qb, filter))
+}
+
+// economics выручка, комиссия и себестоимость неотменённых позиций с ценой. Себестоимость считается
+// по текущей модели мастер-товара или карточки в рублях, поэтому только для позиций в costCurrency.
+// Позиции без модели и в других валютах попадают в without_cost с нулевой себестоимостью
+func economics(qb sq.SelectBuilder, filter Filter) sq.SelectBuilder {
+	return qb.Column(tableName+".currency").
+		Column("count(*) AS orders").
+		Column(`sum(`+quantityExpr+`) AS quantity`).
+		Column(`round(sum(price) / 100.0, 2)::float8 AS revenue`).
+		Column(`round(sum(commission) / 100.0, 2)::float8 AS commission`).
+		Column(`round(sum(unit.cost), 2)::float8 AS cost`).
+		Column(`round(sum(price - commission) / 100.0 - sum(unit.cost), 2)::float8 AS margin`).
+		Column(`COALESCE(round((sum(price - commission) / 100.0 - sum(unit.cost)) * 100.0 / NULLIF(sum(price), 0), 4), 0)::float8 AS margin_rate`).
+		Column(`count(*) FILTER (WHERE unit.cost = 0) AS without_cost`).
+		From(tableName).
+		LeftJoin(`cards ON cards.article = `+tableName+`.article
+			AND cards.marketplace = `+tableName+`.marketplace
+			AND cards.account = `+tableName+`.account`).
+		LeftJoin(`products ON products.id = cards.product_id`).
+		LeftJoin(`LATERAL (SELECT CASE WHEN `+tableName+`.currency = ? THEN
+			(COALESCE(products.filament_grams, cards.filament_grams, 0) / 1000 * ?::numeric
+			+ COALESCE(products.print_hours, cards.print_hours, 0) * ?::numeric) * `+quantityExpr+`
+			ELSE 0 END AS cost) unit ON true`,
+			costCurrency, filter.Costs.MaterialPerKg, filter.Costs.MachineHour).
+		Where(`NOT ` + cancelledExpr).
+		Where(sq.Gt{"price": 0})
+}
+
+// applyFilter колонки с именем таблицы, чтобы фильтр работал и в запросах с join карточек
+func applyFilter(qb sq.SelectBuilder, filter Filter) sq.SelectBuilder {
+	qb = qb.Where(sq.GtOrEq{tableName + ".order_created_at": filter.From}).
+		Where(sq.Lt{tableName + ".order_created_at": filter.To}).
+		PlaceholderFormat(sq.Dollar)
+
+	if filter.Marketplace != "" {
+		qb = qb.Where(sq.Eq{tableName + ".marketplace": filter.Marketplace})
+	}
+
+	return qb
+}
+
+func selectRows[T any](ctx context.Context, dbPool *pgxpool.Pool, qb sq.SelectBuilder) ([]T, error) {
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "sq.ToSql")
+	}
+
+	items := make([]T, 0)
+	err = pgxscan.Select(ctx, dbPool, &items, query, args...)
+
+	return items, errors.Wrap(err, "pgxscan.Select")
+}
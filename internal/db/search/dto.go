@@ -0,0 +1,42 @@
+package search
+
+import (
+	"database/sql"
+)
+
+// Filter пустой Marketplace - все маркетплейсы
+type Filter struct {
+	Query       string
+	Marketplace string
+	Limit       uint64
+}
+
+// CardHit карточка, найденная по названию, артикулу или частям составной
+type CardHit struct {
+	Marketplace string   `db:"marketplace" json:"marketplace"`
+	Account     string   `db:"account" json:"account"`
+	Article     string   `db:"article" json:"article"`
+	Name        string   `db:"name" json:"name"`
+	Photo       string   `db:"photo" json:"photo"`
+	Articles    []string `db:"articles" json:"articles"`
+	IsComposite bool     `db:"is_composite" json:"is_composite"`
+	Rank        float64  `db:"rank" json:"rank"`
+}
+
+// OrderHit открытая позиция очереди, найденная по самому заказу или по его карточке
+type OrderHit struct {
+	ID             string       `db:"id" json:"id"`
+	Article        string       `db:"article" json:"article"`
+	Marketplace    string       `db:"marketplace" json:"marketplace"`
+	Account        string       `db:"account" json:"account"`
+	Name           string       `db:"name" json:"name"`
+	Photo          string       `db:"photo" json:"photo"`
+	OrderNumber    string       `db:"order_number" json:"order_number"`
+	Quantity       int32        `db:"quantity" json:"quantity"`
+	OrderCreatedAt sql.NullTime `db:"order_created_at" json:"order_created_at"`
+	ShipmentAt     sql.NullTime `db:"shipment_at" json:"shipment_at"`
+	IsPrinting     bool         `db:"is_printing" json:"is_printing"`
+	Rank           float64      `db:"rank" json:"rank"`
+	// Code QR-код позиции для сканера, заполняет сервис
+	Code string `db:"-" json:"code"`
+}
@@ -0,0 +1,111 @@
+package search
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	// выражения совпадают с индексами из миграции add_search_indexes, иначе индексы не используются
+	cardText  = `cards_search_text(cards.name, cards.article, cards.articles)`
+	orderText = `orders_queue_search_text(orders_queue.id, orders_queue.article, orders_queue.info, orders_queue.order_composite_items)`
+)
+
+// Store поиск по карточкам и открытым заказам: полнотекстовый с русским стеммингом
+// и триграммный для опечаток и кусков артикулов
+type Store struct {
+	dbPool *pgxpool.Pool
+}
+
+func New(dbPool *pgxpool.Pool) *Store {
+	return &Store{dbPool: dbPool}
+}
+
+func (s *Store) Cards(ctx context.Context, filter Filter) ([]CardHit, error) {
+	qb := sq.Select("marketplace", "account", "article", "name", "photo", "is_composite").
+		Column(`COALESCE(articles, '{}') AS articles`).
+		Column(sq.Alias(rank(cardText, filter.Query), "rank")).
+		From("cards").
+		Where(match(cardText, filter.Query)).
+		OrderBy("rank DESC", "article").
+		Limit(filter.Limit).
+		PlaceholderFormat(sq.Dollar)
+
+	if len(filter.Marketplace) > 0 {
+		qb = qb.Where(sq.Eq{"marketplace": filter.Marketplace})
+	}
+
+	return selectRows[CardHit](ctx, s.dbPool, qb)
+}
+
+// Orders позиция находится и по тексту заказа, и по тексту его карточки: у заказов маркетплейсов название только в карточке
+func (s *Store) Orders(ctx context.Context, filter Filter) ([]OrderHit, error) {
+	orderRank, orderRankArgs, err := rank(orderText, filter.Query).ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "sq.ToSql")
+	}
+	cardRank, cardRankArgs, err := rank("card.search_text", filter.Query).ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "sq.ToSql")
+	}
+
+	qb := sq.Select("orders_queue.id", "orders_queue.article", "orders_queue.marketplace", "orders_queue.account").
+		Columns("orders_queue.order_created_at", "orders_queue.is_printing").
+		Column(`COALESCE(NULLIF(card.name, ''), info->>'name', '') AS name`).
+		Column(`COALESCE(card.photo, '') AS photo`).
+		Column(`COALESCE(info->>'order_number', '') AS order_number`).
+		Column(`GREATEST(COALESCE((info->>'quantity')::int, 1), 1) AS quantity`).
+		Column(`CASE WHEN (info->>'order_shipment_date')::timestamptz > '2000-01-01'
+			THEN (info->>'order_shipment_date')::timestamptz END AS shipment_at`).
+		Column(sq.Expr(`GREATEST(`+orderRank+`, COALESCE(`+cardRank+`, 0)) AS rank`, append(orderRankArgs, cardRankArgs...)...)).
+		From("orders_queue").
+		JoinClause(`LEFT JOIN LATERAL (
+			SELECT cards.name, cards.photo, `+cardText+` AS search_text FROM cards
+			WHERE cards.article = orders_queue.article
+				AND cards.marketplace = orders_queue.marketplace
+				AND cards.account = orders_queue.account
+			LIMIT 1
+		) card ON true`).
+		// условие без параметра, иначе планировщик не возьмёт частичные индексы открытых заказов
+		Where("NOT orders_queue.is_complete").
+		Where(sq.Or{
+			match(orderText, filter.Query),
+			match("card.search_text", filter.Query),
+		}).
+		OrderBy("rank DESC", "orders_queue.order_created_at DESC").
+		Limit(filter.Limit).
+		PlaceholderFormat(sq.Dollar)
+
+	if len(filter.Marketplace) > 0 {
+		qb = qb.Where(sq.Eq{"orders_queue.marketplace": filter.Marketplace})
+	}
+
+	return selectRows[OrderHit](ctx, s.dbPool, qb)
+}
+
+// match стемминг находит другие формы слова, word similarity - слово с опечаткой или часть артикула
+func match(text, query string) sq.Sqlizer {
+	return sq.Expr(`(to_tsvector('russian', `+text+`) @@ websearch_to_tsquery('russian', ?)
+		OR ?::text <% `+text+`)`, query, query)
+}
+
+func rank(text, query string) sq.Sqlizer {
+	return sq.Expr(`ts_rank(to_tsvector('russian', `+text+`), websearch_to_tsquery('russian', ?))
+		+ word_similarity(?::text, `+text+`)`, query, query)
+}
+
+func selectRows[T any](ctx context.Context, dbPool *pgxpool.Pool, qb sq.SelectBuilder) ([]T, error) {
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "sq.ToSql")
+	}
+
+	items := make([]T, 0)
+	err = pgxscan.Select(ctx, dbPool, &items, query, args...)
+
+	return items, errors.Wrap(err, "pgxscan.Select")
+}
@@ -0,0 +1,30 @@
+package slaalert
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Alert struct {
+	ID             int64        `db:"id" json:"id"`
+	Rule           string       `db:"rule" json:"rule"`
+	OrderID        string       `db:"order_id" json:"order_id"`
+	Article        string       `db:"article" json:"article"`
+	Marketplace    string       `db:"marketplace" json:"marketplace"`
+	Account        string       `db:"account" json:"account"`
+	Message        string       `db:"message" json:"message"`
+	CreatedAt      time.Time    `db:"created_at" json:"created_at"`
+	NotifiedAt     sql.NullTime `db:"notified_at" json:"-"`
+	AcknowledgedAt sql.NullTime `db:"acknowledged_at" json:"-"`
+	AcknowledgedBy string       `db:"acknowledged_by" json:"acknowledged_by,omitempty"`
+}
+
+// Delivery алерт доставлен в нотификатор
+type Delivery struct {
+	AlertID  int64  `db:"alert_id"`
+	Notifier string `db:"notifier"`
+}
+
+type ListFilter struct {
+	WithAcknowledged bool `json:"withAcknowledged"`
+}
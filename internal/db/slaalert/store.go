@@ -0,0 +1,206 @@
+package slaalert
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	tableName           = "sla_alerts"
+	deliveriesTableName = "sla_alert_deliveries"
+
+	// listLimit последние срабатывания для экрана алертов
+	listLimit = 200
+
+	idColumn             = "id"
+	ruleColumn           = "rule"
+	orderIDColumn        = "order_id"
+	articleColumn        = "article"
+	marketplaceColumn    = "marketplace"
+	accountColumn        = "account"
+	messageColumn        = "message"
+	notifiedAtColumn     = "notified_at"
+	acknowledgedAtColumn = "acknowledged_at"
+	acknowledgedByColumn = "acknowledged_by"
+
+	alertIDColumn  = "alert_id"
+	notifierColumn = "notifier"
+)
+
+// ErrNotFound алерта с таким id нет
+var ErrNotFound = errors.New("alert not found")
+
+type Store struct {
+	dbPool *pgxpool.Pool
+	log    *slog.Logger
+}
+
+func New(dbPool *pgxpool.Pool, log *slog.Logger) *Store {
+	return &Store{dbPool: dbPool, log: log}
+}
+
+// AddAlerts повторное срабатывание правила по той же позиции игнорируется
+func (s *Store) AddAlerts(ctx context.Context, alerts []Alert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	qb := sq.Insert(tableName).
+		Columns(ruleColumn, orderIDColumn, articleColumn, marketplaceColumn, accountColumn, messageColumn).
+		Suffix(fmt.Sprintf(`ON CONFLICT (%s, %s, %s, %s, %s) DO NOTHING`,
+			ruleColumn, marketplaceColumn, accountColumn, orderIDColumn, articleColumn,
+		)).
+		PlaceholderFormat(sq.Dollar)
+
+	for _, alert := range alerts {
+		qb = qb.Values(alert.Rule, alert.OrderID, alert.Article, alert.Marketplace, alert.Account, alert.Message)
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "sq.ToSql")
+	}
+
+	tag, err := s.dbPool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "dbPool.Exec")
+	}
+
+	if tag.RowsAffected() > 0 {
+		s.log.InfoContext(ctx, "sla alerts raised", slog.Int64("inserted", tag.RowsAffected()))
+	}
+
+	return nil
+}
+
+// ListPending ещё не разосланные и не подтверждённые алерты
+func (s *Store) ListPending(ctx context.Context) ([]Alert, error) {
+	qb := sq.Select("*").
+		From(tableName).
+		Where(sq.Eq{notifiedAtColumn: nil}).
+		Where(sq.Eq{acknowledgedAtColumn: nil}).
+		OrderBy(idColumn).
+		PlaceholderFormat(sq.Dollar)
+
+	return s.selectAlerts(ctx, qb)
+}
+
+func (s *Store) ListAlerts(ctx context.Context, filter ListFilter) ([]Alert, error) {
+	qb := sq.Select("*").
+		From(tableName).
+		OrderBy(idColumn + " DESC").
+		Limit(listLimit).
+		PlaceholderFormat(sq.Dollar)
+
+	if !filter.WithAcknowledged {
+		qb = qb.Where(sq.Eq{acknowledgedAtColumn: nil})
+	}
+
+	return s.selectAlerts(ctx, qb)
+}
+
+func (s *Store) SetNotified(ctx context.Context, id int64) error {
+	qb := sq.Update(tableName).
+		Set(notifiedAtColumn, sq.Expr("now()")).
+		Where(sq.Eq{idColumn: id}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "sq.ToSql")
+	}
+
+	_, err = s.dbPool.Exec(ctx, query, args...)
+
+	return errors.Wrap(err, "dbPool.Exec")
+}
+
+// ListDelivered нотификаторы, в которые алерты уже доставлены, по id алерта
+func (s *Store) ListDelivered(ctx context.Context, ids []int64) (map[int64][]string, error) {
+	result := make(map[int64][]string, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	qb := sq.Select(alertIDColumn, notifierColumn).
+		From(deliveriesTableName).
+		Where(sq.Eq{alertIDColumn: ids}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "sq.ToSql")
+	}
+
+	var items []Delivery
+	if err = pgxscan.Select(ctx, s.dbPool, &items, query, args...); err != nil {
+		return nil, errors.Wrap(err, "pgxscan.Select")
+	}
+
+	for _, item := range items {
+		result[item.AlertID] = append(result[item.AlertID], item.Notifier)
+	}
+
+	return result, nil
+}
+
+// SetDelivered повторная отметка того же нотификатора игнорируется
+func (s *Store) SetDelivered(ctx context.Context, id int64, notifier string) error {
+	qb := sq.Insert(deliveriesTableName).
+		Columns(alertIDColumn, notifierColumn).
+		Values(id, notifier).
+		Suffix(fmt.Sprintf(`ON CONFLICT (%s, %s) DO NOTHING`, alertIDColumn, notifierColumn)).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "sq.ToSql")
+	}
+
+	_, err = s.dbPool.Exec(ctx, query, args...)
+
+	return errors.Wrap(err, "dbPool.Exec")
+}
+
+// Acknowledge повторное подтверждение не меняет автора и время первого
+func (s *Store) Acknowledge(ctx context.Context, id int64, by string) error {
+	qb := sq.Update(tableName).
+		Set(acknowledgedAtColumn, sq.Expr("COALESCE("+acknowledgedAtColumn+", now())")).
+		Set(acknowledgedByColumn, sq.Expr("CASE WHEN "+acknowledgedAtColumn+" IS NULL THEN ? ELSE "+acknowledgedByColumn+" END", by)).
+		Where(sq.Eq{idColumn: id}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "sq.ToSql")
+	}
+
+	tag, err := s.dbPool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "dbPool.Exec")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) selectAlerts(ctx context.Context, qb sq.SelectBuilder) ([]Alert, error) {
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "sq.ToSql")
+	}
+
+	var items []Alert
+	err = pgxscan.Select(ctx, s.dbPool, &items, query, args...)
+
+	return items, errors.Wrap(err, "pgxscan.Select")
+}
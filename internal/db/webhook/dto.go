@@ -0,0 +1,40 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// типы событий, которые пишет триггер orders_queue_webhook_events и задача просрочки
+const (
+	EventOrderCreated      = "order.created"
+	EventOrderStateChanged = "order.state_changed"
+	EventOrderCancelled    = "order.cancelled"
+	EventOrderOverdue      = "order.overdue"
+	// EventSLAAlert пишет нотификатор webhook правил SLA, к заказу добавляется поле alert
+	EventSLAAlert = "sla.alert"
+)
+
+var EventTypes = []string{EventOrderCreated, EventOrderStateChanged, EventOrderCancelled, EventOrderOverdue, EventSLAAlert}
+
+type Event struct {
+	ID int64 `db:"id"`
+	// TxID транзакция, записавшая событие, события доставляются по (TxID, ID)
+	TxID      int64           `db:"txid"`
+	Type      string          `db:"event_type"`
+	OrderID   string          `db:"order_id"`
+	Article   string          `db:"article"`
+	Payload   json.RawMessage `db:"payload"`
+	CreatedAt time.Time       `db:"created_at"`
+}
+
+// Cursor до какого события доставлено получателю и когда повторить неудачную доставку
+type Cursor struct {
+	Endpoint      string    `db:"endpoint"`
+	LastTxID      int64     `db:"last_txid"`
+	LastEventID   int64     `db:"last_event_id"`
+	Attempts      int32     `db:"attempts"`
+	NextAttemptAt time.Time `db:"next_attempt_at"`
+	LastError     string    `db:"last_error"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
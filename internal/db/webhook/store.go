@@ -0,0 +1,197 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	outboxTable  = "webhook_outbox"
+	cursorsTable = "webhook_cursors"
+
+	// overdueWindow совпадает с окном очереди, старые заказы просрочкой не считаются
+	overdueWindow = time.Hour * 24 * 7
+
+	idColumn            = "id"
+	txIDColumn          = "txid"
+	createdAtColumn     = "created_at"
+	endpointColumn      = "endpoint"
+	lastTxIDColumn      = "last_txid"
+	lastEventIDColumn   = "last_event_id"
+	attemptsColumn      = "attempts"
+	nextAttemptAtColumn = "next_attempt_at"
+	lastErrorColumn     = "last_error"
+	updatedAtColumn     = "updated_at"
+)
+
+// ErrNotFound у получателя ещё нет курсора
+var ErrNotFound = errors.New("webhook cursor not found")
+
+type Store struct {
+	dbPool *pgxpool.Pool
+}
+
+func New(dbPool *pgxpool.Pool) *Store {
+	return &Store{dbPool: dbPool}
+}
+
+// ListEvents события после позиции курсора по (txid, id). Читаются только события транзакций старше
+// самой старой незавершённой: событие, которое закоммитится позже, не окажется позади курсора
+func (s *Store) ListEvents(ctx context.Context, cursor Cursor, limit uint64) ([]Event, error) {
+	qb := sq.Select("*").
+		From(outboxTable).
+		Where(fmt.Sprintf("(%s, %s) > (?, ?)", txIDColumn, idColumn), cursor.LastTxID, cursor.LastEventID).
+		Where(txIDColumn+" < pg_snapshot_xmin(pg_current_snapshot())::TEXT::BIGINT").
+		OrderBy(txIDColumn, idColumn).
+		Limit(limit).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "sq.ToSql")
+	}
+
+	var items []Event
+	err = pgxscan.Select(ctx, s.dbPool, &items, query, args...)
+
+	return items, errors.Wrap(err, "pgxscan.Select")
+}
+
+func (s *Store) GetCursor(ctx context.Context, endpoint string) (Cursor, error) {
+	qb := sq.Select("*").
+		From(cursorsTable).
+		Where(sq.Eq{endpointColumn: endpoint}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return Cursor{}, errors.Wrap(err, "sq.ToSql")
+	}
+
+	var item Cursor
+	if err = pgxscan.Get(ctx, s.dbPool, &item, query, args...); err != nil {
+		if pgxscan.NotFound(err) {
+			return Cursor{}, ErrNotFound
+		}
+
+		return Cursor{}, errors.Wrap(err, "pgxscan.Get")
+	}
+
+	return item, nil
+}
+
+func (s *Store) SaveCursor(ctx context.Context, cursor Cursor) error {
+	qb := sq.Insert(cursorsTable).
+		Columns(
+			endpointColumn, lastTxIDColumn, lastEventIDColumn, attemptsColumn, nextAttemptAtColumn, lastErrorColumn,
+			updatedAtColumn,
+		).
+		Values(
+			cursor.Endpoint, cursor.LastTxID, cursor.LastEventID, cursor.Attempts, cursor.NextAttemptAt, cursor.LastError,
+			sq.Expr("now()"),
+		).
+		Suffix(fmt.Sprintf(`ON CONFLICT (%[1]s) DO UPDATE SET
+			%[2]s = excluded.%[2]s,
+			%[3]s = excluded.%[3]s,
+			%[4]s = excluded.%[4]s,
+			%[5]s = excluded.%[5]s,
+			%[6]s = excluded.%[6]s,
+			%[7]s = excluded.%[7]s`,
+			endpointColumn, lastTxIDColumn, lastEventIDColumn, attemptsColumn, nextAttemptAtColumn, lastErrorColumn,
+			updatedAtColumn,
+		)).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "sq.ToSql")
+	}
+
+	_, err = s.dbPool.Exec(ctx, query, args...)
+
+	return errors.Wrap(err, "dbPool.Exec")
+}
+
+// AddOverdueEvents пишет order.overdue по открытым заказам с прошедшей датой отгрузки, каждому заказу один раз
+func (s *Store) AddOverdueEvents(ctx context.Context) (int64, error) {
+	tag, err := s.dbPool.Exec(ctx, `
+		INSERT INTO webhook_outbox (event_type, order_id, article, payload)
+		SELECT $1, o.id, o.article, webhook_order_payload(o)
+		FROM orders_queue o
+		WHERE NOT o.is_complete
+		  AND o.created_at > $2
+		  AND (o.info->>'order_shipment_date')::timestamptz BETWEEN '2000-01-01' AND now()
+		ON CONFLICT (order_id, article, (payload ->> 'marketplace'), (payload ->> 'account'))
+			WHERE event_type = 'order.overdue' DO NOTHING`,
+		EventOrderOverdue, time.Now().Add(-overdueWindow),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "dbPool.Exec")
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// AddOrderEvent событие с текущим состоянием позиции заказа кабинета, extra добавляется к полям заказа
+func (s *Store) AddOrderEvent(ctx context.Context, eventType, marketplace, account, orderID, article string, extra map[string]any) error {
+	_, err := s.dbPool.Exec(ctx, `
+		INSERT INTO webhook_outbox (event_type, order_id, article, payload)
+		SELECT $1, o.id, o.article, webhook_order_payload(o) || $6::jsonb
+		FROM orders_queue o
+		WHERE o.marketplace = $2 AND o.account = $3 AND o.id = $4 AND o.article = $5`,
+		eventType, marketplace, account, orderID, article, extra,
+	)
+
+	return errors.Wrap(err, "dbPool.Exec")
+}
+
+// DeleteDelivered удаляет события старше before, уже доставленные всем перечисленным получателям.
+// Пока у кого-то из получателей нет курсора, ничего не удаляется
+func (s *Store) DeleteDelivered(ctx context.Context, before time.Time, endpoints []string) (int64, error) {
+	qb := sq.Delete(outboxTable).
+		Where(sq.Lt{createdAtColumn: before}).
+		PlaceholderFormat(sq.Dollar)
+
+	if len(endpoints) > 0 {
+		cursors := sq.Select("count(*)").
+			From(cursorsTable).
+			Where(sq.Eq{endpointColumn: endpoints})
+		cursorsSQL, cursorsArgs, err := cursors.ToSql()
+		if err != nil {
+			return 0, errors.Wrap(err, "sq.ToSql")
+		}
+
+		pending := sq.Select("1").
+			From(cursorsTable + " c").
+			Where(sq.Eq{"c." + endpointColumn: endpoints}).
+			Where(fmt.Sprintf("(%s.%s, %s.%s) > (c.%s, c.%s)",
+				outboxTable, txIDColumn, outboxTable, idColumn, lastTxIDColumn, lastEventIDColumn,
+			))
+		pendingSQL, pendingArgs, err := pending.ToSql()
+		if err != nil {
+			return 0, errors.Wrap(err, "sq.ToSql")
+		}
+
+		qb = qb.
+			Where(fmt.Sprintf("(%s) = ?", cursorsSQL), append(cursorsArgs, len(endpoints))...).
+			Where("NOT EXISTS ("+pendingSQL+")", pendingArgs...)
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "sq.ToSql")
+	}
+
+	tag, err := s.dbPool.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "dbPool.Exec")
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"strings"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+)
+
+// itemCodePrefix отличает QR-коды очереди от штрихкодов маркетплейсов на том же сканере
+const itemCodePrefix = "F3D:"
+
+// ItemCode содержимое QR-кода позиции очереди, id заказа уникален только в пределах кабинета маркетплейса.
+// Имя кабинета без двоеточий проверяет конфиг, артикул идёт последним, поэтому двоеточия в нём не мешают разбору
+func ItemCode(marketplace card.Marketplace, account, id, article string) string {
+	return itemCodePrefix + marketplace.String() + ":" + account + ":" + id + ":" + article
+}
+
+// ParseItemCode ok = false, если код не выпущен очередью
+func ParseItemCode(code string) (marketplace card.Marketplace, account, id, article string, ok bool) {
+	rest, found := strings.CutPrefix(code, itemCodePrefix)
+	if !found {
+		return "", "", "", "", false
+	}
+
+	parts := strings.SplitN(rest, ":", 4)
+	if len(parts) != 4 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", "", false
+	}
+
+	return card.Marketplace(parts[0]), parts[1], parts[2], parts[3], true
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// ManualOrder заказ с сайта, из телеграма или на заказ, который не приходит от маркетплейса
+type ManualOrder struct {
+	// Article необязателен, по нему берутся фото и название из карточек маркетплейса manual
+	Article  string    `json:"article"`
+	Name     string    `json:"name"`
+	Note     string    `json:"note"`
+	DueDate  time.Time `json:"due_date"`
+	Quantity int32     `json:"quantity"`
+	// Price сумма заказа в копейках, Currency по умолчанию RUB
+	Price    int64  `json:"price"`
+	Currency string `json:"currency"`
+	// Parts названия составных частей, каждая печатается и отмечается отдельно
+	Parts []string `json:"parts"`
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "github.com/google/uuid"
+
+// ProductMaster мастер-товар, общий для карточек одной модели на разных маркетплейсах
+type ProductMaster struct {
+	// Name, Articles, Files и себестоимость при создании необязательны, пустые берутся из привязываемых карточек
+	Name          string   `json:"name"`
+	Articles      []string `json:"articles"`
+	Files         []string `json:"files"`
+	IsComposite   bool     `json:"is_composite"`
+	FilamentGrams float64  `json:"filament_grams"`
+	PrintHours    float64  `json:"print_hours"`
+	// CardIDs карточки, которые сразу привязываются к новому мастеру, при обновлении не используется
+	CardIDs []uuid.UUID `json:"card_ids"`
+}
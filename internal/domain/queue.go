@@ -7,12 +7,19 @@ import (
 
 type (
 	QueueItem struct {
-		ID             string            `json:"id"`
-		OrderID        string            `json:"order_id"`
-		Name           string            `json:"name"`
-		Article        string            `json:"article"`
-		Marketplace    card.Marketplace  `json:"marketplace"`
-		Photo          string            `json:"photo"`
+		ID      string `json:"id"`
+		OrderID string `json:"order_id"`
+		// Code содержимое QR-кода для этикетки и сканера упаковки
+		Code        string           `json:"code"`
+		Name        string           `json:"name"`
+		Article     string           `json:"article"`
+		Marketplace card.Marketplace `json:"marketplace"`
+		Account     string           `json:"account"`
+		Photo       string           `json:"photo"`
+		// Color, Material, Size купленного варианта карточки, по цвету выбирается филамент
+		Color          string            `json:"color"`
+		Material       string            `json:"material"`
+		Size           string            `json:"size"`
 		IsPrinting     bool              `json:"is_printing"`
 		IsComplete     bool              `json:"is_complete"`
 		Children       []QueueItem       `json:"children"`
@@ -0,0 +1,92 @@
+// Package logger настраивает slog и переносит поля запроса или запуска задачи через context
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	KeyRequestID   = "request_id"
+	KeyJob         = "job"
+	KeyRunID       = "run_id"
+	KeyMarketplace = "marketplace"
+	KeyAccount     = "account"
+	KeyOrderID     = "order_id"
+	KeyArticle     = "article"
+	KeyProductID   = "product_id"
+	KeyError       = "error"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type ctxKey struct{}
+
+// New level: debug|info|warn|error, format: text|json
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, errors.Wrap(err, "level.UnmarshalText")
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText, "":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, errors.Errorf("unknown log format %q", format)
+	}
+
+	return slog.New(contextHandler{Handler: handler}), nil
+}
+
+// WithAttrs добавляет поля, которые попадут во все записи, сделанные через *Context методы с этим ctx
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	merged = append(merged, existing...)
+	merged = append(merged, attrs...)
+
+	return context.WithValue(ctx, ctxKey{}, merged)
+}
+
+// Err пишет только текст ошибки: text handler печатает ошибки pkg/errors через %+v вместе со стеком
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.Any(KeyError, nil)
+	}
+
+	return slog.String(KeyError, err.Error())
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(ctxKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,62 @@
+// Package metrics собирает метрики Prometheus: состояние фоновых задач, запросы к API маркетплейсов и очередь
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const Namespace = "factory"
+
+var (
+	jobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "job_runs_total",
+		Help:      "Number of background job runs by result.",
+	}, []string{"job", "status"})
+
+	jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "job_duration_seconds",
+		Help:      "Duration of background job runs.",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"job"})
+
+	jobLastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "job_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful background job run.",
+	}, []string{"job"})
+
+	apiRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "marketplace_request_duration_seconds",
+		Help:      "Latency of marketplace API requests by host, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"host", "method", "code"})
+)
+
+func ObserveJobRun(job string, duration time.Duration, err error) {
+	jobDuration.WithLabelValues(job).Observe(duration.Seconds())
+
+	if err != nil {
+		jobRuns.WithLabelValues(job, "error").Inc()
+		return
+	}
+
+	jobRuns.WithLabelValues(job, "ok").Inc()
+	jobLastSuccess.WithLabelValues(job).SetToCurrentTime()
+}
+
+// ObserveAPIRequest statusCode 0 означает, что ответ не получен
+func ObserveAPIRequest(host, method string, statusCode int, duration time.Duration) {
+	code := "error"
+	if statusCode > 0 {
+		code = strconv.Itoa(statusCode)
+	}
+
+	apiRequestDuration.WithLabelValues(host, method, code).Observe(duration.Seconds())
+}
@@ -0,0 +1,182 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/report"
+	"github.com/pkg/errors"
+)
+
+const (
+	// cacheTTL отчёты строятся по всей истории, пересчитывать их на каждое обновление страницы незачем
+	cacheTTL = 5 * time.Minute
+	// maxPeriod ограничивает тяжёлые медианы по очень длинным периодам
+	maxPeriod = 366 * 24 * time.Hour
+
+	defaultPeriod = 30 * 24 * time.Hour
+	defaultLimit  = 20
+	maxLimit      = 500
+)
+
+// ErrInvalidFilter параметры отчёта не прошли проверку
+var ErrInvalidFilter = errors.New("invalid report filter")
+
+type ReportStore interface {
+	DailyOrders(ctx context.Context, filter report.Filter) ([]report.DailyOrders, error)
+	LeadTime(ctx context.Context, filter report.Filter) ([]report.LeadTime, error)
+	TopArticles(ctx context.Context, filter report.Filter) ([]report.ArticleDemand, error)
+	OnTime(ctx context.Context, filter report.Filter) ([]report.OnTime, error)
+	ArticleMargin(ctx context.Context, filter report.Filter) ([]report.ArticleMargin, error)
+	MarketplaceMargin(ctx context.Context, filter report.Filter) ([]report.MarketplaceMargin, error)
+	ProductMargin(ctx context.Context, filter report.Filter) ([]report.ProductMargin, error)
+}
+
+// Request From и To - даты в часовом поясе сервиса, To включительно
+type Request struct {
+	From        string `query:"from"`
+	To          string `query:"to"`
+	Marketplace string `query:"marketplace"`
+	Limit       uint64 `query:"limit"`
+}
+
+type cacheEntry struct {
+	value     any
+	expiresAt time.Time
+}
+
+type Service struct {
+	store    ReportStore
+	location *time.Location
+	costs    report.Costs
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+func New(store ReportStore, location *time.Location, costs report.Costs) *Service {
+	return &Service{
+		store:    store,
+		location: location,
+		costs:    costs,
+		cache:    make(map[string]cacheEntry),
+	}
+}
+
+func (s *Service) DailyOrders(ctx context.Context, req Request) ([]report.DailyOrders, error) {
+	return cached(ctx, s, "daily", req, s.store.DailyOrders)
+}
+
+func (s *Service) LeadTime(ctx context.Context, req Request) ([]report.LeadTime, error) {
+	return cached(ctx, s, "lead_time", req, s.store.LeadTime)
+}
+
+func (s *Service) TopArticles(ctx context.Context, req Request) ([]report.ArticleDemand, error) {
+	return cached(ctx, s, "top_articles", req, s.store.TopArticles)
+}
+
+func (s *Service) OnTime(ctx context.Context, req Request) ([]report.OnTime, error) {
+	return cached(ctx, s, "on_time", req, s.store.OnTime)
+}
+
+func (s *Service) ArticleMargin(ctx context.Context, req Request) ([]report.ArticleMargin, error) {
+	return cached(ctx, s, "article_margin", req, s.store.ArticleMargin)
+}
+
+func (s *Service) ProductMargin(ctx context.Context, req Request) ([]report.ProductMargin, error) {
+	return cached(ctx, s, "product_margin", req, s.store.ProductMargin)
+}
+
+func (s *Service) MarketplaceMargin(ctx context.Context, req Request) ([]report.MarketplaceMargin, error) {
+	return cached(ctx, s, "marketplace_margin", req, s.store.MarketplaceMargin)
+}
+
+func cached[T any](
+	ctx context.Context,
+	s *Service,
+	name string,
+	req Request,
+	load func(ctx context.Context, filter report.Filter) ([]T, error),
+) ([]T, error) {
+	filter, err := s.makeFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%s|%s|%s|%s|%d", name, filter.From.Format(time.DateOnly), filter.To.Format(time.DateOnly), filter.Marketplace, filter.Limit)
+
+	s.mu.Lock()
+	entry, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.value.([]T), nil
+	}
+
+	rows, err := load(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrapf(err, "report %s", name)
+	}
+
+	s.mu.Lock()
+	s.evictExpired()
+	s.cache[key] = cacheEntry{value: rows, expiresAt: time.Now().Add(cacheTTL)}
+	s.mu.Unlock()
+
+	return rows, nil
+}
+
+// evictExpired вызывается под s.mu, ключей немного - по одному на набор параметров
+func (s *Service) evictExpired() {
+	now := time.Now()
+	for key, entry := range s.cache {
+		if now.After(entry.expiresAt) {
+			delete(s.cache, key)
+		}
+	}
+}
+
+func (s *Service) makeFilter(req Request) (report.Filter, error) {
+	now := time.Now().In(s.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+
+	filter := report.Filter{
+		From:        today.Add(-defaultPeriod),
+		To:          today.AddDate(0, 0, 1),
+		Marketplace: req.Marketplace,
+		Location:    s.location,
+		Limit:       req.Limit,
+		Costs:       s.costs,
+	}
+
+	if req.From != "" {
+		from, err := time.ParseInLocation(time.DateOnly, req.From, s.location)
+		if err != nil {
+			return filter, errors.Wrapf(ErrInvalidFilter, "from must be YYYY-MM-DD, got %q", req.From)
+		}
+		filter.From = from
+	}
+
+	if req.To != "" {
+		to, err := time.ParseInLocation(time.DateOnly, req.To, s.location)
+		if err != nil {
+			return filter, errors.Wrapf(ErrInvalidFilter, "to must be YYYY-MM-DD, got %q", req.To)
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	if !filter.From.Before(filter.To) {
+		return filter, errors.Wrap(ErrInvalidFilter, "from must not be after to")
+	}
+	if filter.To.Sub(filter.From) > maxPeriod {
+		return filter, errors.Wrapf(ErrInvalidFilter, "period must not exceed %d days", int(maxPeriod.Hours()/24))
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultLimit
+	}
+	filter.Limit = min(filter.Limit, maxLimit)
+
+	return filter, nil
+}
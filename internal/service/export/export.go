@@ -0,0 +1,240 @@
+// Package export выгружает очередь и закрытые заказы в CSV и XLSX потоком, не собирая всю выборку в памяти
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/orderqueue"
+	"github.com/pkg/errors"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+
+	defaultPeriod = 30 * 24 * time.Hour
+	dateTimeCSV   = "2006-01-02 15:04:05"
+)
+
+// ErrInvalidRequest параметры выгрузки не прошли проверку
+var ErrInvalidRequest = errors.New("invalid export request")
+
+var header = []any{
+	"order_id", "order_number", "marketplace", "account", "article", "name", "quantity", "status",
+	"order_created_at", "shipment_at", "printing_at", "completed_at",
+}
+
+type OrderStore interface {
+	ExportQueue(ctx context.Context, filter orderqueue.ListFilter, handle func(row orderqueue.ExportRow) error) error
+	ExportCompleted(ctx context.Context, filter orderqueue.ExportFilter, handle func(row orderqueue.ExportRow) error) error
+}
+
+type (
+	// QueueRequest те же фильтры, что у list-queue
+	QueueRequest struct {
+		WithParentComplete bool   `query:"withParentComplete"`
+		Marketplace        string `query:"marketplace"`
+		Account            string `query:"account"`
+		Format             string `query:"format"`
+	}
+
+	// CompletedRequest From и To - даты в часовом поясе сервиса, To включительно, по умолчанию последние 30 дней
+	CompletedRequest struct {
+		From        string `query:"from"`
+		To          string `query:"to"`
+		Marketplace string `query:"marketplace"`
+		Account     string `query:"account"`
+		Format      string `query:"format"`
+	}
+
+	// File проверенная выгрузка, Write вызывается уже при отправке ответа
+	File struct {
+		Name        string
+		ContentType string
+		Write       func(ctx context.Context, w io.Writer) error
+	}
+)
+
+type rowWriter interface {
+	WriteRow(cells []any) error
+	Close() error
+}
+
+type Service struct {
+	store    OrderStore
+	location *time.Location
+}
+
+func New(store OrderStore, location *time.Location) *Service {
+	return &Service{store: store, location: location}
+}
+
+func (s *Service) Queue(req QueueRequest) (File, error) {
+	format, err := parseFormat(req.Format)
+	if err != nil {
+		return File{}, err
+	}
+
+	filter := orderqueue.ListFilter{
+		WithParentComplete: req.WithParentComplete,
+		Marketplace:        req.Marketplace,
+		Account:            req.Account,
+	}
+
+	return s.file("queue-"+filter.GetMarketplace(), format, func(ctx context.Context, handle func(row orderqueue.ExportRow) error) error {
+		return errors.Wrap(s.store.ExportQueue(ctx, filter, handle), "store.ExportQueue")
+	}), nil
+}
+
+func (s *Service) Completed(req CompletedRequest) (File, error) {
+	format, err := parseFormat(req.Format)
+	if err != nil {
+		return File{}, err
+	}
+
+	now := time.Now().In(s.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+
+	filter := orderqueue.ExportFilter{
+		From:        today.Add(-defaultPeriod),
+		To:          today.AddDate(0, 0, 1),
+		Marketplace: req.Marketplace,
+		Account:     req.Account,
+	}
+
+	if req.From != "" {
+		if filter.From, err = time.ParseInLocation(time.DateOnly, req.From, s.location); err != nil {
+			return File{}, errors.Wrapf(ErrInvalidRequest, "from must be YYYY-MM-DD, got %q", req.From)
+		}
+	}
+	if req.To != "" {
+		to, err := time.ParseInLocation(time.DateOnly, req.To, s.location)
+		if err != nil {
+			return File{}, errors.Wrapf(ErrInvalidRequest, "to must be YYYY-MM-DD, got %q", req.To)
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+	if !filter.From.Before(filter.To) {
+		return File{}, errors.Wrap(ErrInvalidRequest, "from must not be after to")
+	}
+
+	name := "completed-" + filter.From.Format(time.DateOnly) + "-" + filter.To.AddDate(0, 0, -1).Format(time.DateOnly)
+
+	return s.file(name, format, func(ctx context.Context, handle func(row orderqueue.ExportRow) error) error {
+		return errors.Wrap(s.store.ExportCompleted(ctx, filter, handle), "store.ExportCompleted")
+	}), nil
+}
+
+func (s *Service) file(
+	name, format string,
+	rows func(ctx context.Context, handle func(row orderqueue.ExportRow) error) error,
+) File {
+	file := File{Name: name + "." + format, ContentType: "text/csv; charset=utf-8"}
+	if format == FormatXLSX {
+		file.ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	file.Write = func(ctx context.Context, w io.Writer) error {
+		writer, err := s.newWriter(format, w)
+		if err != nil {
+			return err
+		}
+
+		if err = writer.WriteRow(header); err != nil {
+			return err
+		}
+
+		err = rows(ctx, func(row orderqueue.ExportRow) error {
+			return writer.WriteRow(s.record(row))
+		})
+		if err != nil {
+			return err
+		}
+
+		return writer.Close()
+	}
+
+	return file
+}
+
+func (s *Service) newWriter(format string, w io.Writer) (rowWriter, error) {
+	if format == FormatXLSX {
+		return newXLSXWriter(w)
+	}
+
+	return &csvWriter{w: csv.NewWriter(w)}, nil
+}
+
+func (s *Service) record(row orderqueue.ExportRow) []any {
+	return []any{
+		row.ID, row.OrderNumber, row.Marketplace, row.Account, row.Article, row.Name, int64(row.Quantity), status(row),
+		s.localTime(row.OrderCreatedAt.Time, row.OrderCreatedAt.Valid),
+		s.localTime(row.ShipmentAt.Time, row.ShipmentAt.Valid),
+		s.localTime(row.PrintingAt.Time, row.PrintingAt.Valid),
+		s.localTime(row.CompletedAt.Time, row.CompletedAt.Valid),
+	}
+}
+
+func (s *Service) localTime(t time.Time, valid bool) any {
+	if !valid {
+		return nil
+	}
+
+	return t.In(s.location)
+}
+
+func status(row orderqueue.ExportRow) string {
+	switch {
+	case row.IsCancelled:
+		return "cancelled"
+	case row.IsComplete:
+		return "complete"
+	case row.IsPrinting:
+		return "printing"
+	default:
+		return "new"
+	}
+}
+
+func parseFormat(format string) (string, error) {
+	switch format {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	default:
+		return "", errors.Wrapf(ErrInvalidRequest, "format must be csv or xlsx, got %q", format)
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (w *csvWriter) WriteRow(cells []any) error {
+	record := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		switch v := cell.(type) {
+		case string:
+			record = append(record, v)
+		case int64:
+			record = append(record, strconv.FormatInt(v, 10))
+		case time.Time:
+			record = append(record, v.Format(dateTimeCSV))
+		default:
+			record = append(record, "")
+		}
+	}
+
+	return errors.Wrap(w.w.Write(record), "csv.Write")
+}
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+
+	return errors.Wrap(w.w.Error(), "csv.Flush")
+}
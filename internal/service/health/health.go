@@ -0,0 +1,146 @@
+// Package health проверяет зависимости приложения для /readyz
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers"
+)
+
+const (
+	StatusOK      = "ok"
+	StatusFail    = "fail"
+	StatusSkipped = "skipped"
+)
+
+type (
+	DBPinger interface {
+		Ping(ctx context.Context) error
+	}
+
+	MigrationsProvider interface {
+		GetVersion(ctx context.Context) (int64, error)
+	}
+
+	JobsProvider interface {
+		Jobs() []workers.JobState
+	}
+)
+
+type (
+	Component struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+		Detail string `json:"detail,omitempty"`
+	}
+
+	Report struct {
+		Status     string      `json:"status"`
+		Components []Component `json:"components"`
+	}
+)
+
+type Checker struct {
+	db              DBPinger
+	migrations      MigrationsProvider
+	expectedVersion int64
+	jobs            JobsProvider
+	jobStaleAfter   time.Duration
+	startedAt       time.Time
+}
+
+// New expectedVersion 0 отключает сверку миграций, jobStaleAfter - сколько задача может не завершаться успешно
+func New(
+	db DBPinger,
+	migrations MigrationsProvider,
+	expectedVersion int64,
+	jobs JobsProvider,
+	jobStaleAfter time.Duration,
+) *Checker {
+	return &Checker{
+		db:              db,
+		migrations:      migrations,
+		expectedVersion: expectedVersion,
+		jobs:            jobs,
+		jobStaleAfter:   jobStaleAfter,
+		startedAt:       time.Now(),
+	}
+}
+
+func (c *Checker) Ready(ctx context.Context) Report {
+	components := []Component{c.checkDB(ctx), c.checkMigrations(ctx)}
+	components = append(components, c.checkJobs()...)
+
+	report := Report{Status: StatusOK, Components: components}
+	for _, component := range components {
+		if component.Status == StatusFail {
+			report.Status = StatusFail
+			break
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) checkDB(ctx context.Context) Component {
+	if err := c.db.Ping(ctx); err != nil {
+		return Component{Name: "database", Status: StatusFail, Detail: err.Error()}
+	}
+
+	return Component{Name: "database", Status: StatusOK}
+}
+
+func (c *Checker) checkMigrations(ctx context.Context) Component {
+	if c.expectedVersion == 0 {
+		return Component{Name: "migrations", Status: StatusSkipped, Detail: "expected version is unknown"}
+	}
+
+	version, err := c.migrations.GetVersion(ctx)
+	if err != nil {
+		return Component{Name: "migrations", Status: StatusFail, Detail: err.Error()}
+	}
+
+	if version != c.expectedVersion {
+		return Component{
+			Name:   "migrations",
+			Status: StatusFail,
+			Detail: fmt.Sprintf("database version %d, expected %d", version, c.expectedVersion),
+		}
+	}
+
+	return Component{Name: "migrations", Status: StatusOK, Detail: fmt.Sprintf("version %d", version)}
+}
+
+// checkJobs проверяет только задачи, которые выполняет этот экземпляр и которые не на паузе
+func (c *Checker) checkJobs() []Component {
+	jobs := c.jobs.Jobs()
+	result := make([]Component, 0, len(jobs))
+	for _, job := range jobs {
+		name := "job:" + job.Name
+
+		switch {
+		case job.Paused:
+			result = append(result, Component{Name: name, Status: StatusSkipped, Detail: "paused"})
+		case !job.Leader:
+			result = append(result, Component{Name: name, Status: StatusSkipped, Detail: "run by another instance"})
+		case job.LastSuccessAt.IsZero() && time.Since(c.startedAt) < c.jobStaleAfter:
+			result = append(result, Component{Name: name, Status: StatusOK, Detail: "starting"})
+		case time.Since(job.LastSuccessAt) > c.jobStaleAfter:
+			result = append(result, Component{
+				Name:   name,
+				Status: StatusFail,
+				Detail: fmt.Sprintf("no success since %s: %s", job.LastSuccessAt.Format(time.RFC3339), job.LastError),
+			})
+		default:
+			result = append(result, Component{
+				Name:   name,
+				Status: StatusOK,
+				Detail: "last success " + job.LastSuccessAt.Format(time.RFC3339),
+			})
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,105 @@
+package inbound
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/ozon"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/yandex"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers/ordersupdater"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/workers/suppliesupdater"
+	"github.com/pkg/errors"
+)
+
+// ErrUnknownAccount уведомление пришло для кабинета, которого нет в конфиге
+var ErrUnknownAccount = errors.New("unknown seller account")
+
+type JobTrigger interface {
+	Trigger(name string) error
+}
+
+// Receiver по уведомлению маркетплейса сразу запускает те же задачи, что работают по расписанию,
+// поэтому заказ попадает в очередь тем же путём, а опрос остаётся страховкой на случай потерянных уведомлений
+type Receiver struct {
+	trigger        JobTrigger
+	ozonAccounts   map[string]string
+	yandexAccounts map[string]string
+	log            *slog.Logger
+}
+
+// New ozonAccounts client_id -> имя кабинета, yandexAccounts campaign_id -> имя кабинета
+func New(trigger JobTrigger, ozonAccounts, yandexAccounts map[string]string, log *slog.Logger) *Receiver {
+	return &Receiver{
+		trigger:        trigger,
+		ozonAccounts:   ozonAccounts,
+		yandexAccounts: yandexAccounts,
+		log:            log,
+	}
+}
+
+func (r *Receiver) Ozon(ctx context.Context, msg ozon.PushMessage) error {
+	account, ok := r.ozonAccounts[strconv.FormatInt(msg.SellerID, 10)]
+	if !ok {
+		return errors.Wrapf(ErrUnknownAccount, "ozon seller_id %d", msg.SellerID)
+	}
+
+	log := r.log.With(
+		slog.String(logger.KeyMarketplace, card.MpOzon.String()),
+		slog.String(logger.KeyAccount, account),
+		slog.String(logger.KeyOrderID, msg.PostingNumber),
+		slog.String("message_type", msg.MessageType),
+	)
+
+	switch msg.MessageType {
+	case ozon.PushTypeNewPosting:
+		return r.run(ctx, log, ordersupdater.JobName(card.MpOzon, account))
+	case ozon.PushTypePostingCancelled, ozon.PushTypeStateChanged:
+		return r.run(ctx, log, suppliesupdater.JobName)
+	default:
+		log.DebugContext(ctx, "webhook ignored")
+		return nil
+	}
+}
+
+func (r *Receiver) Yandex(ctx context.Context, n yandex.Notification) error {
+	account, ok := r.yandexAccounts[strconv.FormatInt(n.CampaignID, 10)]
+	if !ok {
+		return errors.Wrapf(ErrUnknownAccount, "yandex campaignId %d", n.CampaignID)
+	}
+
+	log := r.log.With(
+		slog.String(logger.KeyMarketplace, card.MpYandex.String()),
+		slog.String(logger.KeyAccount, account),
+		slog.String(logger.KeyOrderID, strconv.FormatInt(n.OrderID, 10)),
+		slog.String("message_type", n.NotificationType),
+	)
+
+	switch n.NotificationType {
+	case yandex.NotificationOrderCreated:
+		return r.run(ctx, log, ordersupdater.JobName(card.MpYandex, account))
+	case yandex.NotificationOrderCancelled, yandex.NotificationOrderStatusUpdated:
+		return r.run(ctx, log, suppliesupdater.JobName)
+	default:
+		log.DebugContext(ctx, "webhook ignored")
+		return nil
+	}
+}
+
+// run запуск задачи другого экземпляра передаётся ему раннером, задачу выключенного маркетплейса подберёт опрос
+func (r *Receiver) run(ctx context.Context, log *slog.Logger, job string) error {
+	err := r.trigger.Trigger(job)
+	switch {
+	case err == nil:
+		log.InfoContext(ctx, "webhook received, job triggered", slog.String("job", job))
+		return nil
+	case errors.Is(err, workers.ErrNotLeader), errors.Is(err, workers.ErrJobNotFound):
+		log.InfoContext(ctx, "webhook received, job left to polling", slog.String("job", job), logger.Err(err))
+		return nil
+	default:
+		return errors.Wrap(err, "trigger.Trigger")
+	}
+}
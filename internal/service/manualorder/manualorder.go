@@ -0,0 +1,188 @@
+package manualorder
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/orderqueue"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/domain"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// defaultCurrency ручные заказы принимаются в рублях, если валюта не указана
+const defaultCurrency = "RUB"
+
+var (
+	// ErrInvalidOrder запрос не прошёл проверку, текст ошибки можно показать пользователю
+	ErrInvalidOrder = errors.New("invalid manual order")
+	// ErrCancelled отменённый заказ нельзя редактировать
+	ErrCancelled = errors.New("manual order is cancelled")
+)
+
+type OrderStore interface {
+	AddOrders(ctx context.Context, orders []orderqueue.Order) error
+	GetOrder(ctx context.Context, id, marketplace, account string) (orderqueue.Order, error)
+	UpdateOrder(ctx context.Context, order orderqueue.Order) error
+}
+
+// Service заводит ручные заказы в ту же очередь, что и заказы маркетплейсов
+type Service struct {
+	store OrderStore
+	log   *slog.Logger
+}
+
+func New(store OrderStore, log *slog.Logger) *Service {
+	return &Service{store: store, log: log}
+}
+
+func (s Service) Create(ctx context.Context, req domain.ManualOrder) (string, error) {
+	if err := validate(&req); err != nil {
+		return "", err
+	}
+
+	order := orderqueue.Order{
+		ID:             uuid.NewString(),
+		Article:        req.Article,
+		Items:          makeItems(req.Parts, nil),
+		Marketplace:    card.MpManual.String(),
+		Account:        card.DefaultAccount,
+		OrderCreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		Info:           makeInfo(req),
+		Price:          req.Price,
+		Currency:       req.Currency,
+	}
+
+	if err := s.store.AddOrders(ctx, []orderqueue.Order{order}); err != nil {
+		return "", errors.Wrap(err, "store.AddOrders")
+	}
+
+	s.log.InfoContext(ctx, "manual order created", slog.String(logger.KeyOrderID, order.ID))
+
+	return order.ID, nil
+}
+
+// Update заменяет поля заказа, у частей с прежним названием сохраняется отметка о готовности
+func (s Service) Update(ctx context.Context, id string, req domain.ManualOrder) error {
+	if err := validate(&req); err != nil {
+		return err
+	}
+
+	order, err := s.store.GetOrder(ctx, id, card.MpManual.String(), card.DefaultAccount)
+	if err != nil {
+		return errors.Wrap(err, "store.GetOrder")
+	}
+
+	if order.Info.IsCancelled {
+		return ErrCancelled
+	}
+
+	order.Article = req.Article
+	order.Items = makeItems(req.Parts, order.Items)
+	order.Info = makeInfo(req)
+	order.Price = req.Price
+	order.Currency = req.Currency
+
+	if err = s.store.UpdateOrder(ctx, order); err != nil {
+		return errors.Wrap(err, "store.UpdateOrder")
+	}
+
+	s.log.InfoContext(ctx, "manual order updated", slog.String(logger.KeyOrderID, id))
+
+	return nil
+}
+
+// Cancel закрывает заказ и помечает его отменённым, запись остаётся в истории
+func (s Service) Cancel(ctx context.Context, id string) error {
+	order, err := s.store.GetOrder(ctx, id, card.MpManual.String(), card.DefaultAccount)
+	if err != nil {
+		return errors.Wrap(err, "store.GetOrder")
+	}
+
+	if order.Info.IsCancelled {
+		return nil
+	}
+
+	order.Info.IsCancelled = true
+	order.IsComplete = true
+
+	if err = s.store.UpdateOrder(ctx, order); err != nil {
+		return errors.Wrap(err, "store.UpdateOrder")
+	}
+
+	s.log.InfoContext(ctx, "manual order cancelled", slog.String(logger.KeyOrderID, id))
+
+	return nil
+}
+
+func validate(req *domain.ManualOrder) error {
+	req.Article = strings.TrimSpace(req.Article)
+	req.Name = strings.TrimSpace(req.Name)
+	req.Note = strings.TrimSpace(req.Note)
+
+	if req.Article == "" && req.Name == "" {
+		return errors.Wrap(ErrInvalidOrder, "article or name is required")
+	}
+
+	if req.Quantity < 0 {
+		return errors.Wrapf(ErrInvalidOrder, "quantity must not be negative, got %d", req.Quantity)
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	if req.Price < 0 {
+		return errors.Wrapf(ErrInvalidOrder, "price must not be negative, got %d", req.Price)
+	}
+	req.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+	if req.Currency == "" {
+		req.Currency = defaultCurrency
+	}
+
+	for i, part := range req.Parts {
+		req.Parts[i] = strings.TrimSpace(part)
+		if req.Parts[i] == "" {
+			return errors.Wrapf(ErrInvalidOrder, "part #%d: name is required", i)
+		}
+	}
+
+	return nil
+}
+
+func makeInfo(req domain.ManualOrder) orderqueue.Info {
+	return orderqueue.Info{
+		OrderShipmentAt: req.DueDate,
+		Quantity:        req.Quantity,
+		Name:            req.Name,
+		Note:            req.Note,
+	}
+}
+
+// makeItems части с совпадающим названием берутся из prev, чтобы не терять прогресс печати
+func makeItems(parts []string, prev orderqueue.Items) orderqueue.Items {
+	result := make(orderqueue.Items, 0, len(parts))
+	for _, part := range parts {
+		idx := -1
+		for i, item := range prev {
+			if item.Name == part {
+				idx = i
+				break
+			}
+		}
+
+		if idx >= 0 {
+			result = append(result, prev[idx])
+			prev = append(prev[:idx:idx], prev[idx+1:]...)
+			continue
+		}
+
+		result = append(result, orderqueue.Item{ID: uuid.NewString(), Name: part})
+	}
+
+	return result
+}
@@ -0,0 +1,59 @@
+// Package marketplace приводит API маркетплейсов к общему виду, с которым работают воркеры карточек и заказов
+package marketplace
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+)
+
+type (
+	// Order одна позиция заказа, для маркетплейсов с несколькими товарами в заказе позиций несколько
+	Order struct {
+		ID         string
+		Article    string
+		Number     string
+		CreatedAt  time.Time
+		ShipmentAt time.Time
+		Quantity   int32
+		// Price выручка позиции с учётом количества в копейках валюты Currency,
+		// Commission удержание маркетплейса, 0 если API его не отдаёт
+		Price      int64
+		Commission int64
+		Currency   string
+		// Barcodes коды с этикеток самого заказа, по ним сканер на упаковке находит позицию
+		Barcodes []string
+		// SKU купленный вариант карточки, пустой - вариант определяется по артикулу
+		SKU string
+	}
+
+	// Marketplace адаптер одного кабинета продавца
+	Marketplace interface {
+		Name() card.Marketplace
+		Account() string
+		// ListCatalog отдаёт карточки кабинета страницами в handle
+		ListCatalog(ctx context.Context, handle func(ctx context.Context, cards []card.Card) error) error
+		// ListNewOrders заказы, которые ещё нужно изготовить
+		ListNewOrders(ctx context.Context) ([]Order, error)
+		// ListShippedOrders ID заказов, которые уже собраны или переданы в доставку
+		ListShippedOrders(ctx context.Context) ([]string, error)
+		// ListCancelled из открытых заказов очереди openIDs возвращает отменённые
+		ListCancelled(ctx context.Context, openIDs []string) ([]string, error)
+	}
+
+	// Backfiller необязательная часть адаптера для загрузки истории заказов,
+	// finishedIDs - заказы страницы, которые уже не нужно изготавливать
+	Backfiller interface {
+		ListOrdersSince(ctx context.Context, since time.Time, handle func(ctx context.Context, orders []Order, finishedIDs []string) error) error
+	}
+)
+
+// currencyRUB код валюты, в котором хранятся суммы заказов российских маркетплейсов
+const currencyRUB = "RUB"
+
+// toKopecks API отдают суммы в рублях с дробной частью
+func toKopecks(value float64) int64 {
+	return int64(math.Round(value * 100))
+}
@@ -0,0 +1,306 @@
+package marketplace
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/megamarket"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	// megamarketOrdersLookback за какой период искать новые и собранные отправления
+	megamarketOrdersLookback = 7 * 24 * time.Hour
+	// megamarketCatalogLookback за какой период собирать карточки из отправлений при первой синхронизации
+	megamarketCatalogLookback = 30 * 24 * time.Hour
+	// megamarketCatalogOverlap следующая синхронизация захватывает конец предыдущей: отправление может попасть в поиск с задержкой
+	megamarketCatalogOverlap = time.Hour
+	// megamarketMinSearchWindow меньше этого периода поиск не дробится, даже если упёрся в лимит
+	megamarketMinSearchWindow = time.Minute
+)
+
+var (
+	megamarketActiveStatuses  = []string{megamarket.StatusNew, megamarket.StatusConfirmed}
+	megamarketShippedStatuses = []string{megamarket.StatusPacked, megamarket.StatusShipped, megamarket.StatusDelivered}
+	megamarketCancelStatuses  = []string{
+		megamarket.StatusMerchantCanceled,
+		megamarket.StatusCustomerCanceled,
+		megamarket.StatusCustomerRejected,
+		megamarket.StatusMerchantRejected,
+		megamarket.StatusConfirmedExpired,
+		megamarket.StatusPackingExpired,
+		megamarket.StatusShippingExpired,
+	}
+)
+
+type MegamarketClient interface {
+	SearchShipments(ctx context.Context, from, to time.Time, statuses []string) (megamarket.SearchResponse, error)
+	GetShipments(ctx context.Context, shipmentIDs []string) (megamarket.GetResponse, error)
+}
+
+// Megamarket у API нет выгрузки каталога, поэтому карточки собираются из товаров в отправлениях
+type Megamarket struct {
+	account string
+	client  MegamarketClient
+	catalog *catalogCursor
+	log     *slog.Logger
+}
+
+// catalogCursor с какого момента искать отправления для карточек, хранится в памяти:
+// после рестарта первая синхронизация снова просматривает megamarketCatalogLookback
+type catalogCursor struct {
+	mu    sync.Mutex
+	since time.Time
+}
+
+func (c *catalogCursor) from(now time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return maxTime(c.since, now.Add(-megamarketCatalogLookback))
+}
+
+func (c *catalogCursor) advance(to time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.since = maxTime(c.since, to.Add(-megamarketCatalogOverlap))
+}
+
+func NewMegamarket(account string, client MegamarketClient, log *slog.Logger) Megamarket {
+	return Megamarket{
+		account: account,
+		client:  client,
+		catalog: &catalogCursor{},
+		log: log.With(
+			slog.String(logger.KeyMarketplace, card.MpMegamarket.String()),
+			slog.String(logger.KeyAccount, account),
+		),
+	}
+}
+
+func (m Megamarket) Name() card.Marketplace {
+	return card.MpMegamarket
+}
+
+func (m Megamarket) Account() string {
+	return m.account
+}
+
+// ListCatalog просматривает только отправления, созданные после прошлой успешной синхронизации
+func (m Megamarket) ListCatalog(ctx context.Context, handle func(ctx context.Context, cards []card.Card) error) error {
+	now := time.Now()
+	ids, err := m.search(ctx, m.catalog.from(now), now, nil)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	err = m.getShipments(ctx, ids, func(shipments []megamarket.Shipment) error {
+		cards := make([]card.Card, 0)
+		for _, shipment := range shipments {
+			for _, item := range shipment.Items {
+				if seen[item.OfferID] {
+					continue
+				}
+				seen[item.OfferID] = true
+
+				cards = append(cards, card.Card{
+					ID:          uuid.New(),
+					Name:        item.GoodsData.Name,
+					Article:     item.OfferID,
+					Marketplace: card.MpMegamarket,
+					Account:     m.account,
+				})
+			}
+		}
+
+		if len(cards) == 0 {
+			return nil
+		}
+
+		return handle(ctx, cards)
+	})
+	if err != nil {
+		return err
+	}
+
+	m.catalog.advance(now)
+
+	return nil
+}
+
+func (m Megamarket) ListNewOrders(ctx context.Context) ([]Order, error) {
+	now := time.Now()
+	ids, err := m.search(ctx, now.Add(-megamarketOrdersLookback), now, megamarketActiveStatuses)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Order
+	err = m.getShipments(ctx, ids, func(shipments []megamarket.Shipment) error {
+		result = append(result, convertMegamarketShipments(shipments)...)
+		return nil
+	})
+
+	return result, err
+}
+
+// ListShippedOrders отправления, которые уже собраны или переданы в доставку
+func (m Megamarket) ListShippedOrders(ctx context.Context) ([]string, error) {
+	now := time.Now()
+	return m.search(ctx, now.Add(-megamarketOrdersLookback), now, megamarketShippedStatuses)
+}
+
+// ListCancelled отправление отменено, если отменены все его позиции
+func (m Megamarket) ListCancelled(ctx context.Context, openIDs []string) ([]string, error) {
+	var cancelledIDs []string
+	err := m.getShipments(ctx, openIDs, func(shipments []megamarket.Shipment) error {
+		for _, shipment := range shipments {
+			if len(shipment.Items) == 0 {
+				continue
+			}
+
+			cancelled := true
+			for _, item := range shipment.Items {
+				cancelled = cancelled && slices.Contains(megamarketCancelStatuses, item.Status)
+			}
+
+			if cancelled {
+				cancelledIDs = append(cancelledIDs, shipment.ShipmentID)
+			}
+		}
+
+		return nil
+	})
+
+	return cancelledIDs, err
+}
+
+func (m Megamarket) ListOrdersSince(
+	ctx context.Context,
+	since time.Time,
+	handle func(ctx context.Context, orders []Order, finishedIDs []string) error,
+) error {
+	ids, err := m.search(ctx, since, time.Now(), nil)
+	if err != nil {
+		return err
+	}
+
+	return m.getShipments(ctx, ids, func(shipments []megamarket.Shipment) error {
+		finishedIDs := make([]string, 0, len(shipments))
+		for _, shipment := range shipments {
+			if !slices.ContainsFunc(shipment.Items, isMegamarketActive) {
+				finishedIDs = append(finishedIDs, shipment.ShipmentID)
+			}
+		}
+
+		return handle(ctx, convertMegamarketShipments(shipments), finishedIDs)
+	})
+}
+
+// search делит период пополам, пока ответ упирается в лимит API
+func (m Megamarket) search(ctx context.Context, from, to time.Time, statuses []string) ([]string, error) {
+	resp, err := m.client.SearchShipments(ctx, from, to, statuses)
+	if err != nil {
+		return nil, errors.Wrap(err, "client.SearchShipments")
+	}
+
+	if len(resp.Shipments) < megamarket.SearchLimit || to.Sub(from) <= megamarketMinSearchWindow {
+		return resp.Shipments, nil
+	}
+
+	middle := from.Add(to.Sub(from) / 2)
+	left, err := m.search(ctx, from, middle, statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := m.search(ctx, middle, to, statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	// отправление на границе периодов может попасть в обе половины
+	result := append(left, right...)
+	slices.Sort(result)
+
+	return slices.Compact(result), nil
+}
+
+func (m Megamarket) getShipments(ctx context.Context, ids []string, handle func(shipments []megamarket.Shipment) error) error {
+	for _, batch := range chunk(ids, megamarket.GetBatchSize) {
+		resp, err := m.client.GetShipments(ctx, batch)
+		if err != nil {
+			return errors.Wrap(err, "client.GetShipments")
+		}
+
+		if err = handle(resp.Shipments); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func chunk(ids []string, size int) [][]string {
+	result := make([][]string, 0, len(ids)/size+1)
+	for start := 0; start < len(ids); start += size {
+		result = append(result, ids[start:min(start+size, len(ids))])
+	}
+
+	return result
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+func isMegamarketActive(item megamarket.Item) bool {
+	return slices.Contains(megamarketActiveStatuses, item.Status)
+}
+
+// convertMegamarketShipments позиции отправления приходят по одной штуке, в очередь они попадают по товару с количеством
+func convertMegamarketShipments(shipments []megamarket.Shipment) []Order {
+	result := make([]Order, 0, len(shipments))
+	for _, shipment := range shipments {
+		byOffer := make(map[string]int)
+		for _, item := range shipment.Items {
+			if !isMegamarketActive(item) {
+				continue
+			}
+
+			quantity := max(item.Quantity, 1)
+			price := toKopecks(item.FinalPrice * float64(quantity))
+			if idx, ok := byOffer[item.OfferID]; ok {
+				result[idx].Quantity += int32(quantity)
+				result[idx].Price += price
+				continue
+			}
+
+			byOffer[item.OfferID] = len(result)
+			result = append(result, Order{
+				ID:         shipment.ShipmentID,
+				Article:    item.OfferID,
+				Number:     shipment.OrderCode,
+				CreatedAt:  shipment.CreationDate,
+				ShipmentAt: shipment.ShipmentDateFrom,
+				Quantity:   int32(quantity),
+				Price:      price,
+				Currency:   currencyRUB,
+			})
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,241 @@
+package marketplace
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/megamarket"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+)
+
+const megamarketTestPrefix = "/api/market/v1"
+
+// megamarketSearch тело запроса поиска, которое получил фейковый API
+type megamarketSearch struct {
+	DateFrom time.Time `json:"dateFrom"`
+	DateTo   time.Time `json:"dateTo"`
+	Statuses []string  `json:"statuses"`
+}
+
+// megamarketAPI отдаёт записанные ответы API и запоминает запросы поиска
+type megamarketAPI struct {
+	mu       sync.Mutex
+	searches []megamarketSearch
+	search   func(req megamarketSearch) []byte
+	get      []byte
+}
+
+func newMegamarketAPI(t *testing.T) *megamarketAPI {
+	t.Helper()
+
+	search := readFixture(t, "megamarket_search.json")
+	return &megamarketAPI{
+		search: func(megamarketSearch) []byte { return search },
+		get:    readFixture(t, "megamarket_get.json"),
+	}
+}
+
+func (a *megamarketAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var envelope struct {
+		Data megamarketSearch `json:"data"`
+	}
+	body, _ := io.ReadAll(r.Body)
+
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case megamarketTestPrefix + "/orderService/order/search":
+		_ = json.Unmarshal(body, &envelope)
+
+		a.mu.Lock()
+		a.searches = append(a.searches, envelope.Data)
+		a.mu.Unlock()
+
+		_, _ = w.Write(a.search(envelope.Data))
+	case megamarketTestPrefix + "/orderService/order/get":
+		_, _ = w.Write(a.get)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (a *megamarketAPI) lastSearch() megamarketSearch {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.searches[len(a.searches)-1]
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	return data
+}
+
+func newTestMegamarket(t *testing.T, api *megamarketAPI) Megamarket {
+	t.Helper()
+
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := megamarket.NewClient("token", log).WithBaseURL(srv.URL + megamarketTestPrefix)
+
+	return NewMegamarket("main", client, log)
+}
+
+func TestMegamarket_ListNewOrders(t *testing.T) {
+	api := newMegamarketAPI(t)
+	orders, err := newTestMegamarket(t, api).ListNewOrders(context.Background())
+	if err != nil {
+		t.Fatalf("ListNewOrders: %v", err)
+	}
+
+	if search := api.lastSearch(); !slices.Equal(search.Statuses, megamarketActiveStatuses) {
+		t.Errorf("search statuses = %v, want %v", search.Statuses, megamarketActiveStatuses)
+	}
+
+	// две штуки одного товара складываются в одну позицию, отменённые позиции пропускаются
+	if len(orders) != 1 {
+		t.Fatalf("got %d orders, want 1: %+v", len(orders), orders)
+	}
+
+	order := orders[0]
+	if order.ID != "9158103442" || order.Number != "MM-4523961" || order.Article != "dragon-blue" {
+		t.Errorf("order = %+v", order)
+	}
+	if order.Quantity != 2 {
+		t.Errorf("quantity = %d, want 2", order.Quantity)
+	}
+	if order.Price != 232200 || order.Currency != currencyRUB {
+		t.Errorf("price = %d %s, want 232200 RUB", order.Price, order.Currency)
+	}
+	if want := time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC); !order.ShipmentAt.Equal(want) {
+		t.Errorf("shipment at = %s, want %s", order.ShipmentAt, want)
+	}
+}
+
+func TestMegamarket_ListCancelled(t *testing.T) {
+	cancelled, err := newTestMegamarket(t, newMegamarketAPI(t)).
+		ListCancelled(context.Background(), []string{"9158103442", "9158107789"})
+	if err != nil {
+		t.Fatalf("ListCancelled: %v", err)
+	}
+
+	// у первого отправления отменена только одна позиция
+	if want := []string{"9158107789"}; !slices.Equal(cancelled, want) {
+		t.Errorf("cancelled = %v, want %v", cancelled, want)
+	}
+}
+
+func TestMegamarket_ListOrdersSince(t *testing.T) {
+	var (
+		orders   []Order
+		finished []string
+	)
+
+	since := time.Now().Add(-72 * time.Hour)
+	api := newMegamarketAPI(t)
+	err := newTestMegamarket(t, api).ListOrdersSince(context.Background(), since,
+		func(_ context.Context, batch []Order, finishedIDs []string) error {
+			orders = append(orders, batch...)
+			finished = append(finished, finishedIDs...)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("ListOrdersSince: %v", err)
+	}
+
+	if search := api.lastSearch(); !search.DateFrom.Equal(since) {
+		t.Errorf("search from = %s, want %s", search.DateFrom, since)
+	}
+	if len(orders) != 1 {
+		t.Errorf("got %d orders, want 1", len(orders))
+	}
+	if want := []string{"9158107789"}; !slices.Equal(finished, want) {
+		t.Errorf("finished = %v, want %v", finished, want)
+	}
+}
+
+func TestMegamarket_ListCatalog(t *testing.T) {
+	api := newMegamarketAPI(t)
+	mp := newTestMegamarket(t, api)
+
+	var cards []card.Card
+	handle := func(_ context.Context, batch []card.Card) error {
+		cards = append(cards, batch...)
+		return nil
+	}
+
+	startedAt := time.Now()
+	if err := mp.ListCatalog(context.Background(), handle); err != nil {
+		t.Fatalf("ListCatalog: %v", err)
+	}
+
+	if from := api.lastSearch().DateFrom; from.After(startedAt.Add(-megamarketCatalogLookback + time.Minute)) {
+		t.Errorf("first sync from = %s, want lookback of %s", from, megamarketCatalogLookback)
+	}
+
+	// карточки уникальны по offerId, даже если товар встречается в нескольких отправлениях
+	articles := make([]string, 0, len(cards))
+	for _, c := range cards {
+		if c.Marketplace != card.MpMegamarket || c.Account != "main" {
+			t.Errorf("card %s: marketplace %s, account %s", c.Article, c.Marketplace, c.Account)
+		}
+		articles = append(articles, c.Article)
+	}
+	if want := []string{"dragon-blue", "keychain-cat"}; !slices.Equal(articles, want) {
+		t.Errorf("articles = %v, want %v", articles, want)
+	}
+
+	if err := mp.ListCatalog(context.Background(), handle); err != nil {
+		t.Fatalf("second ListCatalog: %v", err)
+	}
+
+	// повторная синхронизация начинается с конца прошлой с запасом megamarketCatalogOverlap
+	if from := api.lastSearch().DateFrom; from.Before(startedAt.Add(-megamarketCatalogOverlap - time.Second)) {
+		t.Errorf("second sync from = %s, want not earlier than %s", from, startedAt.Add(-megamarketCatalogOverlap))
+	}
+}
+
+func TestMegamarket_SearchSplitsPeriodOnLimit(t *testing.T) {
+	api := newMegamarketAPI(t)
+	// на весь период API отдаёт ровно лимит, на половины - по одному отправлению
+	api.search = func(req megamarketSearch) []byte {
+		ids := []string{req.DateFrom.Format(time.RFC3339)}
+		if req.DateTo.Sub(req.DateFrom) > 4*24*time.Hour {
+			ids = make([]string, 0, megamarket.SearchLimit)
+			for i := 0; i < megamarket.SearchLimit; i++ {
+				ids = append(ids, strconv.Itoa(i))
+			}
+		}
+
+		data, _ := json.Marshal(map[string]any{"success": 1, "data": map[string]any{"shipments": ids}})
+		return data
+	}
+
+	to := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	ids, err := newTestMegamarket(t, api).search(context.Background(), to.Add(-megamarketOrdersLookback), to, nil)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+
+	if len(ids) != 2 {
+		t.Errorf("got ids %v, want one per half of the period", ids)
+	}
+}
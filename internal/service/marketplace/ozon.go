@@ -0,0 +1,291 @@
+package marketplace
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/ozon"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	ozonProductsLimit = 300
+	// ozonCancelledLookback за какой период искать отмены, заказы старше в очередь не попадают
+	ozonCancelledLookback = 7 * 24 * time.Hour
+
+	// ozonColorAttribute «Цвет товара» из справочника, ozonColorNameAttribute - «Название цвета» в свободной форме.
+	// Материал у Ozon задаётся атрибутом категории, общего id у него нет
+	ozonColorAttribute     = 10096
+	ozonColorNameAttribute = 10097
+)
+
+type OzonClient interface {
+	GetProductList(ctx context.Context, lastID string, limit int) (ozon.ProductListResponse, error)
+	GetProductInfoList(ctx context.Context, productIDs []int64) (ozon.ProductListInfoResponse, error)
+	GetProductAttributes(ctx context.Context, productIDs []int64) (ozon.ProductAttributesResponse, error)
+	GetUnfulfilledList(ctx context.Context, status string) (ozon.UnfulfilledListResponse, error)
+	GetPostingList(ctx context.Context, since, to time.Time, offset int) (ozon.PostingListResponse, error)
+}
+
+type Ozon struct {
+	account string
+	client  OzonClient
+	log     *slog.Logger
+}
+
+func NewOzon(account string, client OzonClient, log *slog.Logger) Ozon {
+	return Ozon{
+		account: account,
+		client:  client,
+		log: log.With(
+			slog.String(logger.KeyMarketplace, card.MpOzon.String()),
+			slog.String(logger.KeyAccount, account),
+		),
+	}
+}
+
+func (m Ozon) Name() card.Marketplace {
+	return card.MpOzon
+}
+
+func (m Ozon) Account() string {
+	return m.account
+}
+
+func (m Ozon) ListCatalog(ctx context.Context, handle func(ctx context.Context, cards []card.Card) error) error {
+	var lastID string
+	for {
+		resp, err := m.client.GetProductList(ctx, lastID, ozonProductsLimit)
+		if err != nil {
+			return errors.Wrap(err, "client.GetProductList")
+		}
+
+		productIDs := make([]int64, 0, len(resp.Result.Items))
+		for _, item := range resp.Result.Items {
+			productIDs = append(productIDs, item.ProductID)
+		}
+
+		if len(productIDs) == 0 {
+			return nil
+		}
+
+		products, err := m.client.GetProductInfoList(ctx, productIDs)
+		if err != nil {
+			return errors.Wrap(err, "client.GetProductInfoList")
+		}
+
+		colors := m.colors(ctx, productIDs)
+
+		cards := make([]card.Card, 0, len(products.Items))
+		for _, item := range products.Items {
+			c := card.Card{
+				ID:          uuid.New(),
+				Name:        item.Name,
+				Article:     item.OfferId,
+				Marketplace: card.MpOzon,
+				Account:     m.account,
+				Barcodes:    item.Barcodes,
+			}
+
+			if len(item.PrimaryImage) > 0 {
+				c.Photo = item.PrimaryImage[0]
+			}
+
+			// без атрибутов варианты не перезаписываются, чтобы сбой запроса не стёр цвета
+			if sku := ozonSku(item.Sources); sku != 0 && colors != nil {
+				c.Variants = []card.Variant{{
+					SKU:   strconv.Itoa(sku),
+					Model: ozonModel(item.ModelInfo.ModelId),
+					Color: colors[int64(item.Id)],
+				}}
+			}
+
+			cards = append(cards, c)
+		}
+
+		if err = handle(ctx, cards); err != nil {
+			return err
+		}
+
+		lastID = resp.Result.LastID
+		if len(resp.Result.Items) < ozonProductsLimit || lastID == "" {
+			return nil
+		}
+	}
+}
+
+// colors цвет товара по product_id, nil если атрибуты получить не удалось
+func (m Ozon) colors(ctx context.Context, productIDs []int64) map[int64]string {
+	resp, err := m.client.GetProductAttributes(ctx, productIDs)
+	if err != nil {
+		m.log.WarnContext(ctx, "ozon product attributes", slog.String(logger.KeyAccount, m.account), logger.Err(err))
+		return nil
+	}
+
+	result := make(map[int64]string, len(resp.Result))
+	for _, product := range resp.Result {
+		byID := make(map[int64]string, 2)
+		for _, attribute := range product.Attributes {
+			values := make([]string, 0, len(attribute.Values))
+			for _, value := range attribute.Values {
+				values = append(values, value.Value)
+			}
+			byID[attribute.ID] = strings.Join(values, ", ")
+		}
+
+		result[product.ID] = byID[ozonColorAttribute]
+		if result[product.ID] == "" {
+			result[product.ID] = byID[ozonColorNameAttribute]
+		}
+	}
+
+	return result
+}
+
+// ozonSku sku, под которым товар приходит в отправлениях, у схем FBO и FBS он сейчас общий
+func ozonSku(sources []ozon.ProductSource) int {
+	for _, source := range sources {
+		if source.Sku != 0 {
+			return source.Sku
+		}
+	}
+
+	return 0
+}
+
+func ozonModel(modelID int) string {
+	if modelID == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(modelID)
+}
+
+func (m Ozon) ListNewOrders(ctx context.Context) ([]Order, error) {
+	resp, err := m.client.GetUnfulfilledList(ctx, ozon.StatusAwaitingDeliver)
+	if err != nil {
+		return nil, errors.Wrap(err, "client.GetUnfulfilledList")
+	}
+
+	return convertOzonPostings(resp.Result.Postings), nil
+}
+
+// ListShippedOrders отправления, которые уже переданы в доставку
+func (m Ozon) ListShippedOrders(ctx context.Context) ([]string, error) {
+	resp, err := m.client.GetUnfulfilledList(ctx, ozon.StatusDelivering)
+	if err != nil {
+		return nil, errors.Wrap(err, "client.GetUnfulfilledList")
+	}
+
+	orderIDs := make([]string, 0, len(resp.Result.Postings))
+	for _, posting := range resp.Result.Postings {
+		orderIDs = append(orderIDs, posting.PostingNumber)
+	}
+
+	return orderIDs, nil
+}
+
+func (m Ozon) ListCancelled(ctx context.Context, openIDs []string) ([]string, error) {
+	if len(openIDs) == 0 {
+		return nil, nil
+	}
+
+	var cancelledIDs []string
+	err := m.listPostings(ctx, time.Now().Add(-ozonCancelledLookback), func(postings []ozon.Posting) error {
+		for _, posting := range postings {
+			if posting.Status == ozon.StatusCancelled && slices.Contains(openIDs, posting.PostingNumber) {
+				cancelledIDs = append(cancelledIDs, posting.PostingNumber)
+			}
+		}
+
+		return nil
+	})
+
+	return cancelledIDs, err
+}
+
+func (m Ozon) ListOrdersSince(
+	ctx context.Context,
+	since time.Time,
+	handle func(ctx context.Context, orders []Order, finishedIDs []string) error,
+) error {
+	return m.listPostings(ctx, since, func(postings []ozon.Posting) error {
+		finishedIDs := make([]string, 0, len(postings))
+		for _, posting := range postings {
+			if posting.Status != ozon.StatusAwaitingPackaging && posting.Status != ozon.StatusAwaitingDeliver {
+				finishedIDs = append(finishedIDs, posting.PostingNumber)
+			}
+		}
+
+		return handle(ctx, convertOzonPostings(postings), finishedIDs)
+	})
+}
+
+func (m Ozon) listPostings(ctx context.Context, since time.Time, handle func(postings []ozon.Posting) error) error {
+	to := time.Now()
+	offset := 0
+	for {
+		resp, err := m.client.GetPostingList(ctx, since, to, offset)
+		if err != nil {
+			return errors.Wrap(err, "client.GetPostingList")
+		}
+
+		if err = handle(resp.Result.Postings); err != nil {
+			return err
+		}
+
+		if !resp.Result.HasNext || len(resp.Result.Postings) == 0 {
+			return nil
+		}
+		offset += len(resp.Result.Postings)
+	}
+}
+
+// convertOzonPostings цена в products за штуку, комиссия в financial_data за позицию, позиции сопоставляются по sku
+func convertOzonPostings(postings []ozon.Posting) []Order {
+	result := make([]Order, 0, len(postings))
+	for _, posting := range postings {
+		var barcodes []string
+		for _, barcode := range []string{posting.Barcodes.UpperBarcode, posting.Barcodes.LowerBarcode} {
+			if barcode != "" {
+				barcodes = append(barcodes, barcode)
+			}
+		}
+
+		commissions := make(map[int]float64, len(posting.FinancialData.Products))
+		for _, financial := range posting.FinancialData.Products {
+			commissions[financial.ProductID] += financial.CommissionAmount
+		}
+
+		for _, product := range posting.Products {
+			price, _ := strconv.ParseFloat(product.Price, 64)
+			currency := product.CurrencyCode
+			if currency == "" {
+				currency = currencyRUB
+			}
+
+			result = append(result, Order{
+				ID:         posting.PostingNumber,
+				Article:    product.OfferID,
+				Number:     posting.PostingNumber,
+				CreatedAt:  posting.InProcessAt,
+				ShipmentAt: posting.ShipmentDate,
+				Quantity:   int32(product.Quantity),
+				Price:      toKopecks(price * float64(product.Quantity)),
+				Commission: toKopecks(commissions[product.Sku]),
+				Currency:   currency,
+				Barcodes:   barcodes,
+				SKU:        strconv.Itoa(product.Sku),
+			})
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,317 @@
+package marketplace
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/wb"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	wbCardsLimit = 99
+	// wbStatusDeclinedByClient заказ отменён покупателем
+	wbStatusDeclinedByClient = "declined_by_client"
+)
+
+type WbClient interface {
+	GetNewOrders(ctx context.Context) (wb.OrdersResponse, error)
+	GetOrders(ctx context.Context, dateFrom time.Time, next int) (wb.OrdersResponse, error)
+	GetOrdersStatus(ctx context.Context, orders []uint64) (wb.OrderStatusResponse, error)
+	GetCardsList(ctx context.Context, cursor wb.CardListCursor) (wb.CardsListResponse, error)
+	GetSupplies(ctx context.Context, next int) (wb.SuppliesResponse, error)
+	GetSupplyOrders(ctx context.Context, supply string) (wb.SupplyOrdersResponse, error)
+}
+
+type Wb struct {
+	account string
+	client  WbClient
+	log     *slog.Logger
+}
+
+func NewWb(account string, client WbClient, log *slog.Logger) Wb {
+	return Wb{
+		account: account,
+		client:  client,
+		log: log.With(
+			slog.String(logger.KeyMarketplace, card.MpWb.String()),
+			slog.String(logger.KeyAccount, account),
+		),
+	}
+}
+
+func (m Wb) Name() card.Marketplace {
+	return card.MpWb
+}
+
+func (m Wb) Account() string {
+	return m.account
+}
+
+func (m Wb) ListCatalog(ctx context.Context, handle func(ctx context.Context, cards []card.Card) error) error {
+	cursor := wb.CardListCursor{Limit: wbCardsLimit}
+	for {
+		resp, err := m.client.GetCardsList(ctx, cursor)
+		if err != nil {
+			return errors.Wrap(err, "client.GetCardsList")
+		}
+
+		cards := make([]card.Card, 0, len(resp.Cards))
+		for _, item := range resp.Cards {
+			c := card.Card{
+				ID:          uuid.MustParse(item.NmUUID),
+				Name:        item.Title,
+				Article:     item.VendorCode,
+				Marketplace: card.MpWb,
+				Account:     m.account,
+			}
+
+			if len(item.Photos) > 0 {
+				c.Photo = item.Photos[0].Big
+			}
+
+			color := wbCharacteristic(item, "Цвет")
+			material := wbCharacteristic(item, "Материал изделия", "Материал")
+
+			c.Variants = make([]card.Variant, 0, len(item.Sizes))
+			for _, size := range item.Sizes {
+				c.Barcodes = append(c.Barcodes, size.Skus...)
+				c.Variants = append(c.Variants, card.Variant{
+					SKU:      strconv.Itoa(size.ChrtID),
+					Model:    strconv.Itoa(item.ImtID),
+					Size:     wbSize(size.TechSize, size.WbSize),
+					Color:    color,
+					Material: material,
+				})
+			}
+
+			cards = append(cards, c)
+		}
+
+		if err = handle(ctx, cards); err != nil {
+			return err
+		}
+
+		if resp.CardsListResponseCursor.Total < wbCardsLimit {
+			return nil
+		}
+
+		cursor.UpdatedAt = resp.CardsListResponseCursor.UpdatedAt
+		cursor.NmID = resp.CardsListResponseCursor.NmID
+	}
+}
+
+func (m Wb) ListNewOrders(ctx context.Context) ([]Order, error) {
+	resp, err := m.client.GetNewOrders(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "client.GetNewOrders")
+	}
+
+	return convertWbOrders(resp.Orders), nil
+}
+
+// ListShippedOrders заказы из ещё не закрытых поставок: раз заказ попал в поставку, он уже собран
+func (m Wb) ListShippedOrders(ctx context.Context) ([]string, error) {
+	next := 1
+	suppliesIDs := make([]string, 0)
+
+	for next > 0 {
+		resp, err := m.client.GetSupplies(ctx, next)
+		if err != nil {
+			return nil, errors.Wrap(err, "client.GetSupplies")
+		}
+
+		if len(resp.Supplies) <= 0 {
+			break
+		}
+		next = resp.Next
+
+		for _, supply := range resp.Supplies {
+			if supply.Done {
+				continue
+			}
+
+			suppliesIDs = append(suppliesIDs, supply.ID)
+		}
+	}
+
+	orderIDs := make([]string, 0)
+	for _, supplyID := range suppliesIDs {
+		resp, err := m.client.GetSupplyOrders(ctx, supplyID)
+		if err != nil {
+			m.log.WarnContext(ctx, "client.GetSupplyOrders", slog.String("supply_id", supplyID), logger.Err(err))
+			continue
+		}
+
+		for _, order := range resp.Orders {
+			orderIDs = append(orderIDs, strconv.FormatInt(order.ID, 10))
+		}
+	}
+
+	return orderIDs, nil
+}
+
+func (m Wb) ListCancelled(ctx context.Context, openIDs []string) ([]string, error) {
+	ids := make([]uint64, 0, len(openIDs))
+	for _, openID := range openIDs {
+		id, err := strconv.ParseUint(openID, 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	resp, err := m.client.GetOrdersStatus(ctx, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "client.GetOrdersStatus")
+	}
+
+	var cancelledIDs []string
+	for _, order := range resp.Orders {
+		if order.SupplierStatus == wbStatusDeclinedByClient {
+			cancelledIDs = append(cancelledIDs, strconv.FormatUint(order.ID, 10))
+		}
+	}
+
+	return cancelledIDs, nil
+}
+
+func (m Wb) ListOrdersSince(
+	ctx context.Context,
+	since time.Time,
+	handle func(ctx context.Context, orders []Order, finishedIDs []string) error,
+) error {
+	next := 0
+	for {
+		resp, err := m.client.GetOrders(ctx, since, next)
+		if err != nil {
+			return errors.Wrap(err, "client.GetOrders")
+		}
+
+		finishedIDs, err := m.finishedOrders(ctx, resp.Orders)
+		if err != nil {
+			return err
+		}
+
+		if err = handle(ctx, convertWbOrders(resp.Orders), finishedIDs); err != nil {
+			return err
+		}
+
+		if len(resp.Orders) == 0 || resp.Next == next {
+			return nil
+		}
+		next = resp.Next
+	}
+}
+
+func (m Wb) finishedOrders(ctx context.Context, orders []wb.Order) ([]string, error) {
+	if len(orders) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint64, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, uint64(order.ID))
+	}
+
+	resp, err := m.client.GetOrdersStatus(ctx, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "client.GetOrdersStatus")
+	}
+
+	finishedIDs := make([]string, 0, len(resp.Orders))
+	for _, order := range resp.Orders {
+		if order.SupplierStatus != wb.SupplierStatusNew && order.SupplierStatus != wb.SupplierStatusConfirm {
+			finishedIDs = append(finishedIDs, strconv.FormatUint(order.ID, 10))
+		}
+	}
+
+	return finishedIDs, nil
+}
+
+// convertWbOrders ConvertedPrice приходит уже в копейках валюты продавца, Price - в валюте покупателя
+func convertWbOrders(orders []wb.Order) []Order {
+	result := make([]Order, 0, len(orders))
+	for _, order := range orders {
+		result = append(result, Order{
+			ID:        strconv.FormatInt(order.ID, 10),
+			Article:   order.Article,
+			CreatedAt: order.CreatedAt,
+			Price:     order.ConvertedPrice,
+			Currency:  wbCurrency(order.ConvertedCurrencyCode),
+			Barcodes:  order.Skus,
+			SKU:       strconv.FormatInt(order.ChrtID, 10),
+		})
+	}
+
+	return result
+}
+
+// wbCurrency WB отдаёт числовой код ISO 4217, в очереди валюта хранится буквенным кодом
+func wbCurrency(code int64) string {
+	switch code {
+	case 643:
+		return currencyRUB
+	case 933:
+		return "BYN"
+	case 398:
+		return "KZT"
+	case 51:
+		return "AMD"
+	case 417:
+		return "KGS"
+	case 860:
+		return "UZS"
+	default:
+		return strconv.FormatInt(code, 10)
+	}
+}
+
+// wbCharacteristic значение первой найденной характеристики, списки значений склеиваются через запятую
+func wbCharacteristic(item wb.Card, names ...string) string {
+	for _, name := range names {
+		for _, characteristic := range item.Characteristics {
+			if !strings.EqualFold(characteristic.Name, name) {
+				continue
+			}
+
+			switch value := characteristic.Value.(type) {
+			case string:
+				return value
+			case float64:
+				return strconv.FormatFloat(value, 'f', -1, 64)
+			case []any:
+				values := make([]string, 0, len(value))
+				for _, v := range value {
+					values = append(values, fmt.Sprint(v))
+				}
+				return strings.Join(values, ", ")
+			}
+		}
+	}
+
+	return ""
+}
+
+// wbSize у безразмерных товаров WB отдаёт techSize "0"
+func wbSize(techSize, wbSize string) string {
+	if wbSize != "" {
+		return wbSize
+	}
+	if techSize == "0" {
+		return ""
+	}
+
+	return techSize
+}
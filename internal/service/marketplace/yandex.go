@@ -0,0 +1,246 @@
+package marketplace
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/yandex"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var yandexLocation = time.FixedZone("MSK", 3*60*60)
+
+type YandexClient interface {
+	GetProductList(ctx context.Context) (yandex.OfferMappingsDTO, error)
+	GetOrders(ctx context.Context, status string) (yandex.OrdersDTO, error)
+	GetOrdersSince(ctx context.Context, fromDate time.Time, page int) (yandex.OrdersDTO, error)
+}
+
+type Yandex struct {
+	account string
+	client  YandexClient
+	log     *slog.Logger
+}
+
+func NewYandex(account string, client YandexClient, log *slog.Logger) Yandex {
+	return Yandex{
+		account: account,
+		client:  client,
+		log: log.With(
+			slog.String(logger.KeyMarketplace, card.MpYandex.String()),
+			slog.String(logger.KeyAccount, account),
+		),
+	}
+}
+
+func (m Yandex) Name() card.Marketplace {
+	return card.MpYandex
+}
+
+func (m Yandex) Account() string {
+	return m.account
+}
+
+func (m Yandex) ListCatalog(ctx context.Context, handle func(ctx context.Context, cards []card.Card) error) error {
+	resp, err := m.client.GetProductList(ctx)
+	if err != nil {
+		return errors.Wrap(err, "client.GetProductList")
+	}
+
+	cards := make([]card.Card, 0, len(resp.Result.OfferMappings))
+	for _, mapping := range resp.Result.OfferMappings {
+		c := card.Card{
+			ID:          uuid.New(),
+			Name:        mapping.Offer.Name,
+			Article:     mapping.Offer.OfferId,
+			Marketplace: card.MpYandex,
+			Account:     m.account,
+			Barcodes:    mapping.Offer.Barcodes,
+		}
+
+		if len(mapping.Offer.Pictures) > 0 {
+			c.Photo = mapping.Offer.Pictures[0]
+		}
+
+		params := make(map[string][]string, len(mapping.Offer.Params))
+		for _, param := range mapping.Offer.Params {
+			name := strings.ToLower(param.Name)
+			params[name] = append(params[name], param.Value)
+		}
+
+		// sku продавца в Яндексе - сам offerId, marketSku у разных офферов может совпадать
+		variant := card.Variant{
+			SKU:      mapping.Offer.OfferId,
+			Size:     yandexParam(params, "размер"),
+			Color:    yandexParam(params, "цвет товара", "цвет"),
+			Material: yandexParam(params, "материал"),
+		}
+		if mapping.Mapping.MarketModelId != 0 {
+			variant.Model = strconv.Itoa(mapping.Mapping.MarketModelId)
+		}
+		c.Variants = []card.Variant{variant}
+
+		cards = append(cards, c)
+	}
+
+	return handle(ctx, cards)
+}
+
+func (m Yandex) ListNewOrders(ctx context.Context) ([]Order, error) {
+	resp, err := m.client.GetOrders(ctx, yandex.StatusProcessing)
+	if err != nil {
+		return nil, errors.Wrap(err, "client.GetOrders")
+	}
+
+	orders := make([]yandex.Order, 0, len(resp.Orders))
+	for _, order := range resp.Orders {
+		if isYandexFinished(order) {
+			continue
+		}
+		orders = append(orders, order)
+	}
+
+	return convertYandexOrders(orders), nil
+}
+
+// ListShippedOrders заказы в обработке, которые уже отгружены
+func (m Yandex) ListShippedOrders(ctx context.Context) ([]string, error) {
+	resp, err := m.client.GetOrders(ctx, yandex.StatusProcessing)
+	if err != nil {
+		return nil, errors.Wrap(err, "client.GetOrders")
+	}
+
+	var shippedIDs []string
+	for _, order := range resp.Orders {
+		if order.Substatus == yandex.SubstatusShipped {
+			shippedIDs = append(shippedIDs, yandexItemIDs(order)...)
+		}
+	}
+
+	return shippedIDs, nil
+}
+
+func (m Yandex) ListCancelled(ctx context.Context, openIDs []string) ([]string, error) {
+	if len(openIDs) == 0 {
+		return nil, nil
+	}
+
+	resp, err := m.client.GetOrders(ctx, yandex.StatusCancelled)
+	if err != nil {
+		return nil, errors.Wrap(err, "client.GetOrders")
+	}
+
+	var cancelledIDs []string
+	for _, order := range resp.Orders {
+		for _, id := range yandexItemIDs(order) {
+			if slices.Contains(openIDs, id) {
+				cancelledIDs = append(cancelledIDs, id)
+			}
+		}
+	}
+
+	return cancelledIDs, nil
+}
+
+func (m Yandex) ListOrdersSince(
+	ctx context.Context,
+	since time.Time,
+	handle func(ctx context.Context, orders []Order, finishedIDs []string) error,
+) error {
+	for page := 1; ; page++ {
+		resp, err := m.client.GetOrdersSince(ctx, since, page)
+		if err != nil {
+			return errors.Wrap(err, "client.GetOrdersSince")
+		}
+
+		finishedIDs := make([]string, 0, len(resp.Orders))
+		for _, order := range resp.Orders {
+			if isYandexFinished(order) {
+				finishedIDs = append(finishedIDs, yandexItemIDs(order)...)
+			}
+		}
+
+		if err = handle(ctx, convertYandexOrders(resp.Orders), finishedIDs); err != nil {
+			return err
+		}
+
+		if page >= resp.Pager.PagesCount {
+			return nil
+		}
+	}
+}
+
+func isYandexFinished(order yandex.Order) bool {
+	return order.Status != yandex.StatusProcessing || order.Substatus == yandex.SubstatusShipped
+}
+
+// yandexItemIDs в очереди каждая позиция заказа яндекса хранится под своим ID
+func yandexItemIDs(order yandex.Order) []string {
+	ids := make([]string, 0, len(order.Items))
+	for _, item := range order.Items {
+		ids = append(ids, strconv.Itoa(item.Id))
+	}
+
+	return ids
+}
+
+func convertYandexOrders(orders []yandex.Order) []Order {
+	result := make([]Order, 0, len(orders))
+	for _, order := range orders {
+		createdAt, err := time.ParseInLocation("02-01-2006 15:04:05", order.CreationDate, yandexLocation)
+		if err != nil {
+			createdAt = time.Now()
+		}
+
+		shipmentAt := time.Now()
+		if len(order.Delivery.Shipments) > 0 {
+			shipmentAt, err = time.Parse("02-01-2006", order.Delivery.Shipments[0].ShipmentDate)
+			if err != nil {
+				shipmentAt = time.Now()
+			}
+		}
+
+		for _, item := range order.Items {
+			result = append(result, Order{
+				ID:         strconv.Itoa(item.Id),
+				Article:    item.OfferId,
+				Number:     fmt.Sprintf("№ %[1]d / %[1]d", order.Id),
+				CreatedAt:  createdAt,
+				ShipmentAt: shipmentAt,
+				Quantity:   int32(item.Count),
+				Price:      toKopecks(item.Price * float64(item.Count)),
+				Currency:   yandexCurrency(order.Currency),
+			})
+		}
+	}
+
+	return result
+}
+
+// yandexCurrency рубль в API маркета обозначается устаревшим кодом RUR
+func yandexCurrency(currency string) string {
+	if currency == "" || currency == "RUR" {
+		return currencyRUB
+	}
+
+	return currency
+}
+
+// yandexParam значения первого найденного параметра оффера, имена параметров в нижнем регистре
+func yandexParam(params map[string][]string, names ...string) string {
+	for _, name := range names {
+		if values := params[name]; len(values) > 0 {
+			return strings.Join(values, ", ")
+		}
+	}
+
+	return ""
+}
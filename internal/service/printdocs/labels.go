@@ -0,0 +1,101 @@
+package printdocs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/pkg/errors"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	labelWidth  = 58.0
+	labelHeight = 40.0
+	labelQRSize = 26.0
+	// labelMaxCopies этикетка печатается на каждую штуку, ограничение защищает от опечатки в количестве
+	labelMaxCopies = 50
+	qrPixels       = 256
+)
+
+// ErrNoLabels у заказа нет позиций с таким артикулом
+var ErrNoLabels = errors.New("no queue items for labels")
+
+// LabelRequest пустой Article - этикетки на все позиции заказа
+type LabelRequest struct {
+	Marketplace string `query:"marketplace"`
+	ID          string `query:"id"`
+	Article     string `query:"article"`
+}
+
+// Labels этикетки 58x40 мм с QR-кодом позиции, по одной на каждую штуку, печатаются при запуске в печать
+func (s *Service) Labels(ctx context.Context, req LabelRequest) ([]byte, error) {
+	marketplace := req.Marketplace
+	if marketplace == "" {
+		marketplace = "wb"
+	}
+
+	items, err := s.queue.GetOrderItems(ctx, marketplace, req.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "queue.GetOrderItems")
+	}
+
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{UnitStr: "mm", Size: gofpdf.SizeType{Wd: labelWidth, Ht: labelHeight}})
+	pdf.AddUTF8FontFromBytes(fontFamily, "", fontRegular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", fontBold)
+	pdf.SetTitle("Этикетки "+req.ID, true)
+	pdf.SetMargins(2, 2, 2)
+	pdf.SetAutoPageBreak(false, 0)
+
+	pages := 0
+	for _, item := range items {
+		if req.Article != "" && item.Article != req.Article {
+			continue
+		}
+
+		qr, err := qrcode.Encode(item.Code, qrcode.Medium, qrPixels)
+		if err != nil {
+			return nil, errors.Wrap(err, "qrcode.Encode")
+		}
+
+		image := "qr" + strconv.Itoa(pages)
+		pdf.RegisterImageOptionsReader(image, gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(qr))
+
+		number := item.Info.OrderNumber
+		if number == "" {
+			number = item.OrderID
+		}
+
+		copies := min(max(int(item.Info.Quantity), 1), labelMaxCopies)
+		for i := 1; i <= copies; i++ {
+			pdf.AddPage()
+			pages++
+
+			pdf.ImageOptions(image, 1, 1, labelQRSize, labelQRSize, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+			textX, textWidth := labelQRSize+2, labelWidth-labelQRSize-4
+			pdf.SetXY(textX, 3)
+			pdf.SetFont(fontFamily, "", 7)
+			pdf.CellFormat(textWidth, 4, item.Marketplace.String(), "", 2, "L", false, 0, "")
+			pdf.SetFont(fontFamily, "B", 8)
+			pdf.CellFormat(textWidth, 4, fit(pdf, number, textWidth), "", 2, "L", false, 0, "")
+			pdf.Ln(2)
+			pdf.SetFont(fontFamily, "B", 14)
+			pdf.CellFormat(textWidth, 7, fmt.Sprintf("%d/%d", i, copies), "", 2, "L", false, 0, "")
+
+			pdf.SetXY(2, labelQRSize+2)
+			pdf.SetFont(fontFamily, "B", 8)
+			pdf.CellFormat(labelWidth-4, 4, fit(pdf, item.Article, labelWidth-4), "", 2, "L", false, 0, "")
+			pdf.SetFont(fontFamily, "", 7)
+			pdf.CellFormat(labelWidth-4, 4, fit(pdf, item.Name, labelWidth-4), "", 2, "L", false, 0, "")
+		}
+	}
+
+	if pages == 0 {
+		return nil, ErrNoLabels
+	}
+
+	return output(pdf)
+}
@@ -0,0 +1,97 @@
+package printdocs
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	_ "image/png" // фото карточек Ozon и Яндекса бывают в png
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // WB отдаёт фото карточек в webp, который PDF не умеет
+)
+
+const (
+	photoWorkers = 8
+	// photoMaxSize сторона превью в пикселях, полноразмерные фото раздули бы PDF до десятков мегабайт
+	photoMaxSize     = 240
+	photoMaxBytes    = 10 << 20
+	photoJPEGQuality = 80
+)
+
+// photoLoader скачивает фото карточек и готовит из них jpeg превью, недоступные фото пропускаются
+type photoLoader struct {
+	client *http.Client
+}
+
+// load превью по адресам фото, в результате только успешно загруженные
+func (l photoLoader) load(ctx context.Context, urls []string) map[string][]byte {
+	result := make(map[string][]byte, len(urls))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, photoWorkers)
+
+	for _, url := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(url string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			thumb, err := l.thumbnail(ctx, url)
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			result[url] = thumb
+			mu.Unlock()
+		}(url)
+	}
+
+	wg.Wait()
+
+	return result
+}
+
+func (l photoLoader) thumbnail(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "http.NewRequest")
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "client.Do")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	src, _, err := image.Decode(io.LimitReader(resp.Body, photoMaxBytes))
+	if err != nil {
+		return nil, errors.Wrap(err, "image.Decode")
+	}
+
+	bounds := src.Bounds()
+	scale := min(1, float64(photoMaxSize)/float64(max(bounds.Dx(), bounds.Dy())))
+	dst := image.NewRGBA(image.Rect(0, 0, max(1, int(float64(bounds.Dx())*scale)), max(1, int(float64(bounds.Dy())*scale))))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	buf := bytes.Buffer{}
+	if err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: photoJPEGQuality}); err != nil {
+		return nil, errors.Wrap(err, "jpeg.Encode")
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,374 @@
+// Package printdocs собирает PDF для печати на бумаге: список печати на смену, листы сборки по датам отгрузки
+// и этикетки с QR-кодами позиций
+package printdocs
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/domain"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/utils"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/pkg/errors"
+)
+
+const (
+	fontFamily = "DejaVu"
+
+	photoTimeout = 20 * time.Second
+	photoSize    = 18.0
+	rowHeight    = 7.0
+	photoRow     = photoSize + 2
+)
+
+var (
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	fontRegular []byte
+	//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+	fontBold []byte
+)
+
+type QueueService interface {
+	ListQueue(ctx context.Context, withParent, withChildren bool, marketplace, account string) ([]domain.QueueItem, error)
+	GetOrderItems(ctx context.Context, marketplace, id string) ([]domain.QueueItem, error)
+}
+
+// Request пустой Marketplace - wb, как и в list-queue
+type Request struct {
+	Marketplace string `query:"marketplace"`
+	Account     string `query:"account"`
+	// WithoutPhotos не скачивать фото карточек, лист получается быстрее и легче
+	WithoutPhotos bool `query:"withoutPhotos"`
+}
+
+type (
+	// printLine одна строка списка печати: артикул целиком или одна часть составного
+	printLine struct {
+		Article  string
+		Part     string
+		Name     string
+		Photo    string
+		Quantity int64
+		Orders   int
+	}
+
+	// slipGroup заказы с одной датой отгрузки, нулевая дата - отгрузка не указана
+	slipGroup struct {
+		Date  time.Time
+		Items []domain.QueueItem
+	}
+)
+
+type Service struct {
+	queue    QueueService
+	photos   photoLoader
+	location *time.Location
+}
+
+func New(queue QueueService, location *time.Location) *Service {
+	return &Service{
+		queue:    queue,
+		photos:   photoLoader{client: &http.Client{Timeout: photoTimeout}},
+		location: location,
+	}
+}
+
+// PrintList открытые позиции, которые ещё не в печати, сгруппированные по артикулу и части
+func (s *Service) PrintList(ctx context.Context, req Request) ([]byte, error) {
+	items, err := s.queue.ListQueue(ctx, false, false, req.Marketplace, req.Account)
+	if err != nil {
+		return nil, errors.Wrap(err, "queue.ListQueue")
+	}
+
+	lines := makePrintLines(items)
+
+	var photos map[string][]byte
+	if !req.WithoutPhotos {
+		urls := make([]string, 0, len(lines))
+		for _, line := range lines {
+			if line.Photo != "" {
+				urls = append(urls, line.Photo)
+			}
+		}
+		photos = s.photos.load(ctx, urls)
+	}
+
+	pdf := s.newDocument("Список печати", req)
+
+	widths := []float64{photoSize + 2, 40, 30, 84, 14, 14}
+	s.tableHeader(pdf, widths, []string{"Фото", "Артикул", "Часть", "Название", "Кол-во", "Заказов"})
+
+	registered := make(map[string]string)
+	for _, line := range lines {
+		if s.pageBreak(pdf, photoRow) {
+			s.tableHeader(pdf, widths, []string{"Фото", "Артикул", "Часть", "Название", "Кол-во", "Заказов"})
+		}
+
+		x, y := pdf.GetXY()
+		pdf.CellFormat(widths[0], photoRow, "", "1", 0, "", false, 0, "")
+		if thumb, ok := photos[line.Photo]; ok {
+			name, ok := registered[line.Photo]
+			if !ok {
+				name = "photo" + strconv.Itoa(len(registered))
+				pdf.RegisterImageOptionsReader(name, gofpdf.ImageOptions{ImageType: "JPG"}, bytes.NewReader(thumb))
+				registered[line.Photo] = name
+			}
+			pdf.ImageOptions(name, x+1, y+1, photoSize, photoSize, false, gofpdf.ImageOptions{ImageType: "JPG"}, 0, "")
+		}
+
+		pdf.CellFormat(widths[1], photoRow, fit(pdf, line.Article, widths[1]), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], photoRow, fit(pdf, line.Part, widths[2]), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], photoRow, fit(pdf, line.Name, widths[3]), "1", 0, "L", false, 0, "")
+		pdf.SetFont(fontFamily, "B", 12)
+		pdf.CellFormat(widths[4], photoRow, strconv.FormatInt(line.Quantity, 10), "1", 0, "C", false, 0, "")
+		pdf.SetFont(fontFamily, "", 9)
+		pdf.CellFormat(widths[5], photoRow, strconv.Itoa(line.Orders), "1", 1, "C", false, 0, "")
+	}
+
+	if len(lines) == 0 {
+		pdf.CellFormat(0, rowHeight, "Нечего печатать: все позиции уже в печати или готовы", "", 1, "L", false, 0, "")
+	}
+
+	return output(pdf)
+}
+
+// PackingSlip незакрытые заказы по датам отгрузки, у каждой строки квадрат для отметки при сборке
+func (s *Service) PackingSlip(ctx context.Context, req Request) ([]byte, error) {
+	items, err := s.queue.ListQueue(ctx, false, false, req.Marketplace, req.Account)
+	if err != nil {
+		return nil, errors.Wrap(err, "queue.ListQueue")
+	}
+
+	pdf := s.newDocument("Лист сборки", req)
+
+	widths := []float64{8, 42, 40, 80, 14, 12}
+	header := []string{"№", "Заказ", "Артикул", "Название", "Кол-во", "✓"}
+
+	for _, group := range s.makeSlipGroups(items) {
+		s.pageBreak(pdf, 3*rowHeight)
+
+		pdf.SetFont(fontFamily, "B", 12)
+		title := "Без даты отгрузки"
+		if !group.Date.IsZero() {
+			title = "Отгрузка " + formatDate(group.Date)
+		}
+		pdf.CellFormat(0, 9, fmt.Sprintf("%s — заказов: %d", title, len(group.Items)), "", 1, "L", false, 0, "")
+		s.tableHeader(pdf, widths, header)
+
+		for i, item := range group.Items {
+			if s.pageBreak(pdf, rowHeight) {
+				s.tableHeader(pdf, widths, header)
+			}
+
+			number := item.Info.OrderNumber
+			if number == "" {
+				number = item.OrderID
+			}
+
+			pdf.CellFormat(widths[0], rowHeight, strconv.Itoa(i+1), "1", 0, "C", false, 0, "")
+			pdf.CellFormat(widths[1], rowHeight, fit(pdf, number, widths[1]), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(widths[2], rowHeight, fit(pdf, item.Article, widths[2]), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(widths[3], rowHeight, fit(pdf, item.Name, widths[3]), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(widths[4], rowHeight, strconv.Itoa(int(max(item.Info.Quantity, 1))), "1", 0, "C", false, 0, "")
+
+			x, y := pdf.GetXY()
+			pdf.CellFormat(widths[5], rowHeight, "", "1", 1, "", false, 0, "")
+			pdf.Rect(x+(widths[5]-4)/2, y+(rowHeight-4)/2, 4, 4, "D")
+		}
+
+		pdf.Ln(4)
+	}
+
+	if len(items) == 0 {
+		pdf.CellFormat(0, rowHeight, "Открытых заказов нет", "", 1, "L", false, 0, "")
+	}
+
+	return output(pdf)
+}
+
+// makePrintLines у составных заказов в список попадают только неготовые части, количество - штуки по заказам
+func makePrintLines(items []domain.QueueItem) []printLine {
+	byKey := make(map[string]*printLine)
+	lines := make([]*printLine, 0)
+
+	add := func(item domain.QueueItem, part string) {
+		key := item.Article + "\x00" + part
+		line, ok := byKey[key]
+		if !ok {
+			line = &printLine{Article: item.Article, Part: part, Name: item.Name, Photo: item.Photo}
+			byKey[key] = line
+			lines = append(lines, line)
+		}
+		line.Quantity += int64(max(item.Info.Quantity, 1))
+		line.Orders++
+	}
+
+	for _, item := range items {
+		if item.IsComplete || item.IsPrinting {
+			continue
+		}
+
+		if len(item.CompositeItems) == 0 {
+			add(item, "")
+			continue
+		}
+
+		for _, part := range item.CompositeItems {
+			if !part.IsComplete {
+				add(item, part.Name)
+			}
+		}
+	}
+
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Article != lines[j].Article {
+			return lines[i].Article < lines[j].Article
+		}
+		return lines[i].Part < lines[j].Part
+	})
+
+	result := make([]printLine, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, *line)
+	}
+
+	return result
+}
+
+// makeSlipGroups даты отгрузки по возрастанию в часовом поясе сервиса, заказы без даты в конце
+func (s *Service) makeSlipGroups(items []domain.QueueItem) []slipGroup {
+	byDate := make(map[time.Time]*slipGroup)
+	groups := make([]*slipGroup, 0)
+
+	for _, item := range items {
+		if item.IsComplete {
+			continue
+		}
+
+		var date time.Time
+		if at := item.Info.OrderShipmentAt; !at.IsZero() {
+			at = at.In(s.location)
+			date = time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, s.location)
+		}
+
+		group, ok := byDate[date]
+		if !ok {
+			group = &slipGroup{Date: date}
+			byDate[date] = group
+			groups = append(groups, group)
+		}
+		group.Items = append(group.Items, item)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Date.IsZero() || groups[j].Date.IsZero() {
+			return !groups[i].Date.IsZero()
+		}
+		return groups[i].Date.Before(groups[j].Date)
+	})
+
+	result := make([]slipGroup, 0, len(groups))
+	for _, group := range groups {
+		sort.SliceStable(group.Items, func(i, j int) bool {
+			return group.Items[i].Info.OrderNumber < group.Items[j].Info.OrderNumber
+		})
+		result = append(result, *group)
+	}
+
+	return result
+}
+
+func (s *Service) newDocument(title string, req Request) *gofpdf.Fpdf {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", fontRegular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", fontBold)
+	pdf.SetTitle(title, true)
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(false, 10)
+	pdf.AliasNbPages("")
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-10)
+		pdf.SetFont(fontFamily, "", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("стр. %d из {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	marketplace := req.Marketplace
+	if marketplace == "" {
+		marketplace = "wb"
+	}
+	if req.Account != "" {
+		marketplace += " / " + req.Account
+	}
+
+	now := time.Now().In(s.location)
+
+	pdf.AddPage()
+	pdf.SetFont(fontFamily, "B", 16)
+	pdf.CellFormat(0, 8, title, "", 1, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "", 10)
+	pdf.CellFormat(0, 6, fmt.Sprintf("%s, %s %s", marketplace, formatDate(now), now.Format("15:04")), "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	return pdf
+}
+
+func (s *Service) tableHeader(pdf *gofpdf.Fpdf, widths []float64, titles []string) {
+	pdf.SetFont(fontFamily, "B", 9)
+	pdf.SetFillColor(230, 230, 230)
+	for i, title := range titles {
+		ln := 0
+		if i == len(titles)-1 {
+			ln = 1
+		}
+		pdf.CellFormat(widths[i], rowHeight, title, "1", ln, "C", true, 0, "")
+	}
+	pdf.SetFont(fontFamily, "", 9)
+}
+
+// pageBreak начинает новую страницу, если строка высотой height не помещается, true - страница добавлена
+func (s *Service) pageBreak(pdf *gofpdf.Fpdf, height float64) bool {
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	if pdf.GetY()+height <= pageHeight-bottom-5 {
+		return false
+	}
+
+	pdf.AddPage()
+
+	return true
+}
+
+// fit обрезает текст по ширине колонки, чтобы длинные названия не налезали на соседние ячейки
+func fit(pdf *gofpdf.Fpdf, text string, width float64) string {
+	const padding = 2
+	if pdf.GetStringWidth(text) <= width-padding {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"…") > width-padding {
+		runes = runes[:len(runes)-1]
+	}
+
+	return string(runes) + "…"
+}
+
+func formatDate(t time.Time) string {
+	return fmt.Sprintf("%d %s %d", t.Day(), utils.DeclensionGenitiveMonth(int32(t.Month())), t.Year())
+}
+
+func output(pdf *gofpdf.Fpdf) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := pdf.Output(&buf); err != nil {
+		return nil, errors.Wrap(err, "pdf.Output")
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,296 @@
+// Package product ведёт мастер-товары: одна модель, продающаяся на WB, Ozon и Яндексе,
+// описывается один раз, а карточки маркетплейсов ссылаются на неё
+package product
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/product"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/domain"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultMinScore = 0.5
+	defaultLimit    = 50
+	maxLimit        = 500
+)
+
+// ErrInvalidRequest запрос не прошёл проверку, текст ошибки можно показать пользователю
+var ErrInvalidRequest = errors.New("invalid product request")
+
+type Store interface {
+	Create(ctx context.Context, product product.Product, cardIDs []uuid.UUID) error
+	Update(ctx context.Context, product product.Product) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	Get(ctx context.Context, id uuid.UUID) (product.Product, error)
+	List(ctx context.Context) ([]product.Product, error)
+	ListCards(ctx context.Context, productIDs []uuid.UUID) ([]product.LinkedCard, error)
+	GetCards(ctx context.Context, cardIDs []uuid.UUID) ([]card.Card, error)
+	Link(ctx context.Context, productID uuid.UUID, cardIDs []uuid.UUID) (int64, error)
+	Unlink(ctx context.Context, cardIDs []uuid.UUID) (int64, error)
+	Suggestions(ctx context.Context, filter product.SuggestionFilter) ([]product.Suggestion, error)
+}
+
+// Item мастер-товар вместе с привязанными карточками
+type Item struct {
+	product.Product
+	Cards []product.LinkedCard `json:"cards"`
+}
+
+// SuggestionRequest MinScore от 0 до 1, по умолчанию 0.5
+type SuggestionRequest struct {
+	Marketplace string  `query:"marketplace"`
+	MinScore    float64 `query:"min_score"`
+	Limit       uint64  `query:"limit"`
+}
+
+type Service struct {
+	store Store
+	log   *slog.Logger
+}
+
+func New(store Store, log *slog.Logger) *Service {
+	return &Service{store: store, log: log}
+}
+
+func (s *Service) List(ctx context.Context) ([]Item, error) {
+	products, err := s.store.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "store.List")
+	}
+
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+
+	cards, err := s.store.ListCards(ctx, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "store.ListCards")
+	}
+
+	byProduct := make(map[uuid.UUID][]product.LinkedCard, len(products))
+	for _, c := range cards {
+		byProduct[c.ProductID] = append(byProduct[c.ProductID], c)
+	}
+
+	result := make([]Item, 0, len(products))
+	for _, p := range products {
+		linked := byProduct[p.ID]
+		if linked == nil {
+			linked = []product.LinkedCard{}
+		}
+		result = append(result, Item{Product: p, Cards: linked})
+	}
+
+	return result, nil
+}
+
+// Create пустые поля мастера заполняются из привязываемых карточек, чтобы не вводить их заново
+func (s *Service) Create(ctx context.Context, req domain.ProductMaster) (uuid.UUID, error) {
+	if len(req.CardIDs) > 0 {
+		cards, err := s.store.GetCards(ctx, req.CardIDs)
+		if err != nil {
+			return uuid.Nil, errors.Wrap(err, "store.GetCards")
+		}
+		if len(cards) != countUnique(req.CardIDs) {
+			return uuid.Nil, errors.Wrap(ErrInvalidRequest, "some cards not found")
+		}
+
+		fillFromCards(&req, cards)
+	}
+
+	if err := validate(&req); err != nil {
+		return uuid.Nil, err
+	}
+
+	item := makeProduct(uuid.New(), req)
+	if err := s.store.Create(ctx, item, req.CardIDs); err != nil {
+		return uuid.Nil, errors.Wrap(err, "store.Create")
+	}
+
+	s.log.InfoContext(ctx, "product created",
+		slog.String(logger.KeyProductID, item.ID.String()),
+		slog.Int("cards", len(req.CardIDs)),
+	)
+
+	return item.ID, nil
+}
+
+// Update заменяет все поля мастера, привязки карточек не меняются
+func (s *Service) Update(ctx context.Context, id string, req domain.ProductMaster) error {
+	productID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	if err = validate(&req); err != nil {
+		return err
+	}
+
+	if err = s.store.Update(ctx, makeProduct(productID, req)); err != nil {
+		return errors.Wrap(err, "store.Update")
+	}
+
+	return nil
+}
+
+// Delete привязанные карточки снова используют собственные значения
+func (s *Service) Delete(ctx context.Context, id string) error {
+	productID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	if err = s.store.Delete(ctx, productID); err != nil {
+		return errors.Wrap(err, "store.Delete")
+	}
+
+	s.log.InfoContext(ctx, "product deleted", slog.String(logger.KeyProductID, id))
+
+	return nil
+}
+
+// Link привязывает карточки к мастеру, возвращает число привязанных карточек
+func (s *Service) Link(ctx context.Context, id string, cardIDs []uuid.UUID) (int64, error) {
+	productID, err := parseID(id)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(cardIDs) == 0 {
+		return 0, errors.Wrap(ErrInvalidRequest, "card_ids is required")
+	}
+
+	if _, err = s.store.Get(ctx, productID); err != nil {
+		return 0, errors.Wrap(err, "store.Get")
+	}
+
+	linked, err := s.store.Link(ctx, productID, cardIDs)
+	if err != nil {
+		return 0, errors.Wrap(err, "store.Link")
+	}
+
+	return linked, nil
+}
+
+func (s *Service) Unlink(ctx context.Context, cardIDs []uuid.UUID) (int64, error) {
+	if len(cardIDs) == 0 {
+		return 0, errors.Wrap(ErrInvalidRequest, "card_ids is required")
+	}
+
+	unlinked, err := s.store.Unlink(ctx, cardIDs)
+	if err != nil {
+		return 0, errors.Wrap(err, "store.Unlink")
+	}
+
+	return unlinked, nil
+}
+
+func (s *Service) Suggestions(ctx context.Context, req SuggestionRequest) ([]product.Suggestion, error) {
+	filter := product.SuggestionFilter{
+		Marketplace: req.Marketplace,
+		MinScore:    req.MinScore,
+		Limit:       req.Limit,
+	}
+
+	if filter.MinScore < 0 || filter.MinScore > 1 {
+		return nil, errors.Wrapf(ErrInvalidRequest, "min_score must be between 0 and 1, got %v", filter.MinScore)
+	}
+	if filter.MinScore == 0 {
+		filter.MinScore = defaultMinScore
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultLimit
+	}
+	filter.Limit = min(filter.Limit, maxLimit)
+
+	suggestions, err := s.store.Suggestions(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "store.Suggestions")
+	}
+
+	return suggestions, nil
+}
+
+func validate(req *domain.ProductMaster) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return errors.Wrap(ErrInvalidRequest, "name is required")
+	}
+
+	if req.FilamentGrams < 0 {
+		return errors.Wrapf(ErrInvalidRequest, "filament_grams must not be negative, got %v", req.FilamentGrams)
+	}
+	if req.PrintHours < 0 {
+		return errors.Wrapf(ErrInvalidRequest, "print_hours must not be negative, got %v", req.PrintHours)
+	}
+
+	for i, article := range req.Articles {
+		req.Articles[i] = strings.TrimSpace(article)
+		if req.Articles[i] == "" {
+			return errors.Wrapf(ErrInvalidRequest, "article #%d is empty", i)
+		}
+	}
+
+	return nil
+}
+
+// fillFromCards берётся первая карточка, у которой поле заполнено
+func fillFromCards(req *domain.ProductMaster, cards []card.Card) {
+	for _, c := range cards {
+		if strings.TrimSpace(req.Name) == "" {
+			req.Name = c.Name
+		}
+		if len(req.Articles) == 0 && len(c.Articles) > 0 {
+			req.Articles = slices.Clone(c.Articles)
+			req.IsComposite = req.IsComposite || c.IsComposite
+		}
+		if len(req.Files) == 0 && len(c.Files) > 0 {
+			req.Files = slices.Clone(c.Files)
+		}
+		if req.FilamentGrams == 0 {
+			req.FilamentGrams = c.FilamentGrams
+		}
+		if req.PrintHours == 0 {
+			req.PrintHours = c.PrintHours
+		}
+	}
+}
+
+func makeProduct(id uuid.UUID, req domain.ProductMaster) product.Product {
+	return product.Product{
+		ID:            id,
+		Name:          req.Name,
+		Articles:      req.Articles,
+		Files:         req.Files,
+		IsComposite:   req.IsComposite,
+		FilamentGrams: req.FilamentGrams,
+		PrintHours:    req.PrintHours,
+	}
+}
+
+func parseID(id string) (uuid.UUID, error) {
+	productID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, errors.Wrapf(ErrInvalidRequest, "invalid product id %q", id)
+	}
+
+	return productID, nil
+}
+
+func countUnique(ids []uuid.UUID) int {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+
+	return len(seen)
+}
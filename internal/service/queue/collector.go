@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/orderqueue"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const collectTimeout = 5 * time.Second
+
+type OpenStatsProvider interface {
+	GetOpenStats(ctx context.Context) ([]orderqueue.OpenStat, error)
+}
+
+// Collector считает открытые позиции очереди в момент опроса /metrics
+type Collector struct {
+	provider OpenStatsProvider
+	log      *slog.Logger
+
+	openItems    *prometheus.Desc
+	overdueItems *prometheus.Desc
+}
+
+func NewCollector(provider OpenStatsProvider, log *slog.Logger) *Collector {
+	return &Collector{
+		provider: provider,
+		log:      log,
+		openItems: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "queue", "open_items"),
+			"Number of open queue items by marketplace and state.",
+			[]string{"marketplace", "state"}, nil,
+		),
+		overdueItems: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "queue", "overdue_items"),
+			"Number of open queue items past their shipment date by marketplace and state.",
+			[]string{"marketplace", "state"}, nil,
+		),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.openItems
+	ch <- c.overdueItems
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	stats, err := c.provider.GetOpenStats(ctx)
+	if err != nil {
+		c.log.ErrorContext(ctx, "provider.GetOpenStats", logger.Err(err))
+		return
+	}
+
+	for _, stat := range stats {
+		state := "new"
+		if stat.IsPrinting {
+			state = "printing"
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.openItems, prometheus.GaugeValue, float64(stat.Total), stat.Marketplace, state)
+		ch <- prometheus.MustNewConstMetric(c.overdueItems, prometheus.GaugeValue, float64(stat.Overdue), stat.Marketplace, state)
+	}
+}
@@ -3,22 +3,26 @@ package queue
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
 	"github.com/alleswebdev/marketplace-3d-factory/internal/db/orderqueue"
 	"github.com/alleswebdev/marketplace-3d-factory/internal/domain"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
 	"github.com/alleswebdev/marketplace-3d-factory/internal/utils"
 	"github.com/pkg/errors"
 )
 
 type (
 	CardProvider interface {
-		GetByArticlesMap(ctx context.Context, articles []string) (map[string]card.Card, error)
+		GetByArticlesMap(ctx context.Context, mp card.Marketplace, account string, articles []string) (map[string]card.Card, error)
+		GetVariantsByArticles(ctx context.Context, mp card.Marketplace, account string, articles []string) ([]card.Variant, error)
 	}
 
 	OrderProvider interface {
 		GetOrders(ctx context.Context, filter orderqueue.ListFilter) ([]orderqueue.Order, error)
+		GetOrderPositions(ctx context.Context, id, marketplace string) ([]orderqueue.Order, error)
 		SetComplete(ctx context.Context, id string, isComplete bool) error
 		SetPrinting(ctx context.Context, id string, isPrinting bool) error
 		SetChildrenComplete(ctx context.Context, id string, isComplete bool) error
@@ -29,13 +33,15 @@ type (
 	Queue struct {
 		cardProvider  CardProvider
 		orderProvider OrderProvider
+		log           *slog.Logger
 	}
 )
 
-func New(cardProvider CardProvider, orderProvider OrderProvider) *Queue {
+func New(cardProvider CardProvider, orderProvider OrderProvider, log *slog.Logger) *Queue {
 	return &Queue{
 		cardProvider:  cardProvider,
 		orderProvider: orderProvider,
+		log:           log,
 	}
 }
 
@@ -44,6 +50,8 @@ func (q Queue) SetComplete(ctx context.Context, id string, state bool) error {
 		return errors.Wrap(err, "orderProvider.SetComplete")
 	}
 
+	q.log.InfoContext(ctx, "queue item updated", slog.String(logger.KeyOrderID, id), slog.Bool("is_complete", state))
+
 	return nil
 }
 
@@ -52,6 +60,8 @@ func (q Queue) SetPrinting(ctx context.Context, id string, state bool) error {
 		return errors.Wrap(err, "orderProvider.SetPrinting")
 	}
 
+	q.log.InfoContext(ctx, "queue item updated", slog.String(logger.KeyOrderID, id), slog.Bool("is_printing", state))
+
 	return nil
 }
 
@@ -60,53 +70,120 @@ func (q Queue) SetChildrenComplete(ctx context.Context, id string, state bool) e
 		return errors.Wrap(err, "orderProvider.SetChildrenComplete")
 	}
 
+	q.log.InfoContext(ctx, "queue item updated", slog.String("item_id", id), slog.Bool("children_complete", state))
+
 	return nil
 }
 
-func (q Queue) ListQueue(ctx context.Context, withParent, withChildren bool, marketplace string) ([]domain.QueueItem, error) {
-	orders, err := q.orderProvider.GetOrders(ctx, orderqueue.ListFilter{
+// ListQueue пустой account показывает заказы всех кабинетов маркетплейса
+func (q Queue) ListQueue(ctx context.Context, withParent, withChildren bool, marketplace, account string) ([]domain.QueueItem, error) {
+	filter := orderqueue.ListFilter{
 		WithParentComplete:   withParent,
 		WithChildrenComplete: withChildren,
 		Marketplace:          marketplace,
-	})
+		Account:              account,
+	}
 
+	orders, err := q.orderProvider.GetOrders(ctx, filter)
 	if err != nil {
 		return nil, errors.Wrap(err, "orderProvider.GetOrders")
 	}
 
-	articles := make([]string, 0, len(orders))
-	for _, item := range orders {
-		articles = append(articles, item.Article)
+	cards, variants, err := q.getCards(ctx, card.Marketplace(filter.GetMarketplace()), orders)
+	if err != nil {
+		return nil, err
 	}
 
-	cards, err := q.cardProvider.GetByArticlesMap(ctx, articles)
+	return makeItems(orders, cards, variants), nil
+}
+
+// GetOrderItems позиции одного заказа, в том числе закрытые, для этикеток
+func (q Queue) GetOrderItems(ctx context.Context, marketplace, id string) ([]domain.QueueItem, error) {
+	orders, err := q.orderProvider.GetOrderPositions(ctx, id, marketplace)
 	if err != nil {
-		return nil, errors.Wrap(err, "cardProvider.GetByArticlesMap")
+		return nil, errors.Wrap(err, "orderProvider.GetOrderPositions")
 	}
 
-	return makeItems(orders, cards), nil
+	cards, variants, err := q.getCards(ctx, card.Marketplace(marketplace), orders)
+	if err != nil {
+		return nil, err
+	}
+
+	return makeItems(orders, cards, variants), nil
 }
 
-func makeItems(orders []orderqueue.Order, cards map[string]card.Card) []domain.QueueItem {
+// getCards карточки и варианты заказов маркетплейса по ключу cardKey
+func (q Queue) getCards(
+	ctx context.Context, mp card.Marketplace, orders []orderqueue.Order,
+) (map[string]card.Card, map[string][]card.Variant, error) {
+	articlesByAccount := make(map[string][]string)
+	for _, item := range orders {
+		articlesByAccount[item.GetAccount()] = append(articlesByAccount[item.GetAccount()], item.Article)
+	}
+
+	cards := make(map[string]card.Card, len(orders))
+	variants := make(map[string][]card.Variant)
+	for orderAccount, articles := range articlesByAccount {
+		accountCards, err := q.cardProvider.GetByArticlesMap(ctx, mp, orderAccount, articles)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cardProvider.GetByArticlesMap")
+		}
+
+		for article, c := range accountCards {
+			cards[cardKey(orderAccount, article)] = c
+		}
+
+		accountVariants, err := q.cardProvider.GetVariantsByArticles(ctx, mp, orderAccount, articles)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cardProvider.GetVariantsByArticles")
+		}
+
+		for _, v := range accountVariants {
+			key := cardKey(orderAccount, v.Article)
+			variants[key] = append(variants[key], v)
+		}
+	}
+
+	return cards, variants, nil
+}
+
+func cardKey(account, article string) string {
+	return account + "/" + article
+}
+
+func makeItems(orders []orderqueue.Order, cards map[string]card.Card, variants map[string][]card.Variant) []domain.QueueItem {
 	if len(orders) <= 0 {
 		return nil
 	}
 
 	result := make([]domain.QueueItem, 0, len(orders))
 	for _, order := range orders {
-		currentCard := cards[order.Article]
+		currentCard := cards[cardKey(order.GetAccount(), order.Article)]
+		variant := pickVariant(variants[cardKey(order.GetAccount(), order.Article)], order.Info.Sku)
+
+		// у ручного заказа карточки может не быть, название и состав тогда берутся из самого заказа
+		name := currentCard.Name
+		if name == "" {
+			name = order.Info.Name
+		}
+
 		result = append(result, domain.QueueItem{
 			ID:             order.ID,
 			OrderID:        order.ID,
-			Name:           currentCard.Name,
+			Code:           domain.ItemCode(card.Marketplace(order.Marketplace), order.GetAccount(), order.ID, order.Article),
+			Name:           name,
 			Article:        order.Article,
-			Marketplace:    currentCard.Marketplace,
+			Marketplace:    card.Marketplace(order.Marketplace),
+			Account:        order.GetAccount(),
 			Photo:          currentCard.Photo,
+			Color:          variant.Color,
+			Material:       variant.Material,
+			Size:           variant.Size,
 			IsPrinting:     order.IsPrinting,
 			IsComplete:     order.IsComplete,
 			TimePassed:     getTimePassed(order.OrderCreatedAt.Time),
 			ShipmentDate:   getShipmentDate(order.Info.OrderShipmentAt),
-			IsComposite:    currentCard.IsComposite,
+			IsComposite:    currentCard.IsComposite || len(order.Items) > 0,
 			Info:           order.Info,
 			CompositeItems: order.Items,
 		})
@@ -115,6 +192,36 @@ func makeItems(orders []orderqueue.Order, cards map[string]card.Card) []domain.Q
 	return result
 }
 
+// pickVariant вариант по sku заказа. Если sku нет, цвет и материал берутся, когда они общие у всех вариантов
+// артикула - как у размеров одной карточки WB, размер тогда неизвестен
+func pickVariant(variants []card.Variant, sku string) card.Variant {
+	if len(variants) == 0 {
+		return card.Variant{}
+	}
+
+	for _, v := range variants {
+		if sku != "" && v.SKU == sku {
+			return v
+		}
+	}
+
+	if len(variants) == 1 {
+		return variants[0]
+	}
+
+	result := card.Variant{Color: variants[0].Color, Material: variants[0].Material}
+	for _, v := range variants[1:] {
+		if v.Color != result.Color {
+			result.Color = ""
+		}
+		if v.Material != result.Material {
+			result.Material = ""
+		}
+	}
+
+	return result
+}
+
 func getTimePassed(orderCreatedAt time.Time) string {
 	diff := time.Since(orderCreatedAt)
 	hours := int(diff.Hours())
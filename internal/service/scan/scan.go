@@ -0,0 +1,133 @@
+// Package scan переводит позиции очереди по статусам сканером на упаковке: QR-код очереди, номер заказа
+// или штрихкод маркетплейса находят открытую позицию и двигают её дальше
+package scan
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/orderqueue"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/domain"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	StateNew      = "new"
+	StatePrinting = "printing"
+	StateComplete = "complete"
+)
+
+var (
+	// ErrEmptyCode сканер прислал пустую строку
+	ErrEmptyCode = errors.New("empty code")
+	// ErrNotFound по коду нет открытой позиции: заказ уже закрыт или код чужой
+	ErrNotFound = errors.New("no open queue item for code")
+)
+
+type (
+	OrderStore interface {
+		FindOpenByCode(ctx context.Context, code string) (orderqueue.Order, error)
+		AdvanceItem(ctx context.Context, id, article, marketplace, account string) (orderqueue.Order, error)
+	}
+
+	CardProvider interface {
+		GetByArticlesMap(ctx context.Context, mp card.Marketplace, account string, articles []string) (map[string]card.Card, error)
+	}
+)
+
+// Result позиция после перевода, From и To - статусы до и после скана
+type Result struct {
+	ID          string           `json:"id"`
+	Article     string           `json:"article"`
+	Marketplace card.Marketplace `json:"marketplace"`
+	Account     string           `json:"account"`
+	Name        string           `json:"name"`
+	Photo       string           `json:"photo"`
+	OrderNumber string           `json:"order_number"`
+	Quantity    int32            `json:"quantity"`
+	Code        string           `json:"code"`
+	From        string           `json:"from"`
+	To          string           `json:"to"`
+}
+
+type Service struct {
+	orders OrderStore
+	cards  CardProvider
+	log    *slog.Logger
+}
+
+func New(orders OrderStore, cards CardProvider, log *slog.Logger) *Service {
+	return &Service{orders: orders, cards: cards, log: log}
+}
+
+// Scan новая позиция уходит в печать, позиция в печати закрывается
+func (s *Service) Scan(ctx context.Context, code string) (Result, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return Result{}, ErrEmptyCode
+	}
+
+	marketplace, account, id, article, ok := domain.ParseItemCode(code)
+	if !ok {
+		order, err := s.orders.FindOpenByCode(ctx, code)
+		if err != nil {
+			if errors.Is(err, orderqueue.ErrNotFound) {
+				return Result{}, ErrNotFound
+			}
+
+			return Result{}, errors.Wrap(err, "orders.FindOpenByCode")
+		}
+
+		marketplace, account, id, article = card.Marketplace(order.Marketplace), order.GetAccount(), order.ID, order.Article
+	}
+
+	order, err := s.orders.AdvanceItem(ctx, id, article, marketplace.String(), account)
+	if err != nil {
+		if errors.Is(err, orderqueue.ErrNotFound) {
+			return Result{}, ErrNotFound
+		}
+
+		return Result{}, errors.Wrap(err, "orders.AdvanceItem")
+	}
+
+	result := Result{
+		ID:          order.ID,
+		Article:     order.Article,
+		Marketplace: marketplace,
+		Account:     order.GetAccount(),
+		Name:        order.Info.Name,
+		OrderNumber: order.Info.OrderNumber,
+		Quantity:    max(order.Info.Quantity, 1),
+		Code:        domain.ItemCode(marketplace, order.GetAccount(), order.ID, order.Article),
+		From:        StateNew,
+		To:          StatePrinting,
+	}
+	if order.IsComplete {
+		result.From, result.To = StatePrinting, StateComplete
+	}
+
+	s.log.InfoContext(ctx, "queue item scanned",
+		slog.String(logger.KeyOrderID, order.ID),
+		slog.String(logger.KeyArticle, order.Article),
+		slog.String("state", result.To),
+	)
+
+	// статус уже сменён, без карточки упаковщик увидит хотя бы артикул и номер заказа
+	cards, err := s.cards.GetByArticlesMap(ctx, marketplace, order.GetAccount(), []string{order.Article})
+	if err != nil {
+		s.log.WarnContext(ctx, "scan card lookup", slog.String(logger.KeyArticle, order.Article), logger.Err(err))
+		return result, nil
+	}
+
+	if c, ok := cards[order.Article]; ok {
+		if c.Name != "" {
+			result.Name = c.Name
+		}
+		result.Photo = c.Photo
+	}
+
+	return result, nil
+}
@@ -0,0 +1,80 @@
+// Package search ищет карточки и открытые заказы по свободному тексту: «синий дракон брелок» или кусок номера заказа
+package search
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/search"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/domain"
+	"github.com/pkg/errors"
+)
+
+const (
+	// minQueryLength по одной букве триграммы совпадают почти со всем
+	minQueryLength = 2
+	defaultLimit   = 20
+	maxLimit       = 100
+)
+
+// ErrInvalidRequest параметры поиска не прошли проверку
+var ErrInvalidRequest = errors.New("invalid search request")
+
+type Store interface {
+	Cards(ctx context.Context, filter search.Filter) ([]search.CardHit, error)
+	Orders(ctx context.Context, filter search.Filter) ([]search.OrderHit, error)
+}
+
+// Request Limit ограничивает карточки и заказы по отдельности
+type Request struct {
+	Query       string `query:"q"`
+	Marketplace string `query:"marketplace"`
+	Limit       uint64 `query:"limit"`
+}
+
+type Result struct {
+	Cards  []search.CardHit  `json:"cards"`
+	Orders []search.OrderHit `json:"orders"`
+}
+
+type Service struct {
+	store Store
+}
+
+func New(store Store) *Service {
+	return &Service{store: store}
+}
+
+func (s *Service) Search(ctx context.Context, req Request) (Result, error) {
+	filter := search.Filter{
+		Query:       strings.TrimSpace(req.Query),
+		Marketplace: req.Marketplace,
+		Limit:       req.Limit,
+	}
+
+	if utf8.RuneCountInString(filter.Query) < minQueryLength {
+		return Result{}, errors.Wrapf(ErrInvalidRequest, "q must be at least %d characters", minQueryLength)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultLimit
+	}
+	filter.Limit = min(filter.Limit, maxLimit)
+
+	cards, err := s.store.Cards(ctx, filter)
+	if err != nil {
+		return Result{}, errors.Wrap(err, "store.Cards")
+	}
+
+	orders, err := s.store.Orders(ctx, filter)
+	if err != nil {
+		return Result{}, errors.Wrap(err, "store.Orders")
+	}
+
+	for i := range orders {
+		orders[i].Code = domain.ItemCode(card.Marketplace(orders[i].Marketplace), orders[i].Account, orders[i].ID, orders[i].Article)
+	}
+
+	return Result{Cards: cards, Orders: orders}, nil
+}
@@ -0,0 +1,240 @@
+package sla
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/orderqueue"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/slaalert"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/pkg/errors"
+)
+
+// JobName задача проверки правил
+const JobName = "sla"
+
+type (
+	OrdersProvider interface {
+		ListOpen(ctx context.Context) ([]orderqueue.Order, error)
+	}
+
+	AlertStore interface {
+		AddAlerts(ctx context.Context, alerts []slaalert.Alert) error
+		ListPending(ctx context.Context) ([]slaalert.Alert, error)
+		ListAlerts(ctx context.Context, filter slaalert.ListFilter) ([]slaalert.Alert, error)
+		ListDelivered(ctx context.Context, ids []int64) (map[int64][]string, error)
+		SetDelivered(ctx context.Context, id int64, notifier string) error
+		SetNotified(ctx context.Context, id int64) error
+		Acknowledge(ctx context.Context, id int64, by string) error
+	}
+
+	// Notifier канал оповещения, указывается в правиле по Name
+	Notifier interface {
+		Name() string
+		Notify(ctx context.Context, alert slaalert.Alert) error
+	}
+)
+
+// Rule срабатывает на открытую позицию, если выполнены все заданные условия:
+// OlderThan - заказ создан раньше, ShipmentWithin - до отгрузки осталось меньше, NotPrinting - ещё не в печати
+type Rule struct {
+	Name           string
+	Marketplace    string
+	OlderThan      time.Duration
+	ShipmentWithin time.Duration
+	NotPrinting    bool
+	Notifiers      []string
+}
+
+// Engine проверяет правила по открытым заказам, каждое срабатывание сохраняется один раз
+// и рассылается в каждый нотификатор правила, пока доставка в него не удастся
+type Engine struct {
+	orders    OrdersProvider
+	alerts    AlertStore
+	rules     map[string]Rule
+	ruleOrder []string
+	notifiers map[string]Notifier
+	log       *slog.Logger
+}
+
+func New(orders OrdersProvider, alerts AlertStore, rules []Rule, notifiers []Notifier, log *slog.Logger) *Engine {
+	e := &Engine{
+		orders:    orders,
+		alerts:    alerts,
+		rules:     make(map[string]Rule, len(rules)),
+		notifiers: make(map[string]Notifier, len(notifiers)),
+		log:       log,
+	}
+
+	for _, rule := range rules {
+		e.rules[rule.Name] = rule
+		e.ruleOrder = append(e.ruleOrder, rule.Name)
+	}
+
+	for _, notifier := range notifiers {
+		e.notifiers[notifier.Name()] = notifier
+	}
+
+	return e
+}
+
+func (e *Engine) Update(ctx context.Context) error {
+	orders, err := e.orders.ListOpen(ctx)
+	if err != nil {
+		return errors.Wrap(err, "orders.ListOpen")
+	}
+
+	now := time.Now()
+	var alerts []slaalert.Alert
+	for _, name := range e.ruleOrder {
+		rule := e.rules[name]
+		for _, order := range orders {
+			if message, ok := rule.match(order, now); ok {
+				alerts = append(alerts, slaalert.Alert{
+					Rule:        rule.Name,
+					OrderID:     order.ID,
+					Article:     order.Article,
+					Marketplace: order.Marketplace,
+					Account:     order.GetAccount(),
+					Message:     message,
+				})
+			}
+		}
+	}
+
+	if err = e.alerts.AddAlerts(ctx, alerts); err != nil {
+		return errors.Wrap(err, "alerts.AddAlerts")
+	}
+
+	return e.notifyPending(ctx)
+}
+
+func (e *Engine) List(ctx context.Context, withAcknowledged bool) ([]slaalert.Alert, error) {
+	alerts, err := e.alerts.ListAlerts(ctx, slaalert.ListFilter{WithAcknowledged: withAcknowledged})
+	if err != nil {
+		return nil, errors.Wrap(err, "alerts.ListAlerts")
+	}
+
+	return alerts, nil
+}
+
+// Acknowledge подтверждённый алерт больше не рассылается и не создаётся заново для той же позиции
+func (e *Engine) Acknowledge(ctx context.Context, id int64, by string) error {
+	if err := e.alerts.Acknowledge(ctx, id, by); err != nil {
+		return errors.Wrap(err, "alerts.Acknowledge")
+	}
+
+	e.log.InfoContext(ctx, "sla alert acknowledged", slog.Int64("alert_id", id), slog.String("by", by))
+
+	return nil
+}
+
+// notifyPending доставка отмечается по каждому нотификатору, на следующем запуске повторяются только неудавшиеся,
+// алерт считается разосланным, когда ушёл во все нотификаторы правила
+func (e *Engine) notifyPending(ctx context.Context) error {
+	pending, err := e.alerts.ListPending(ctx)
+	if err != nil {
+		return errors.Wrap(err, "alerts.ListPending")
+	}
+
+	ids := make([]int64, 0, len(pending))
+	for _, alert := range pending {
+		ids = append(ids, alert.ID)
+	}
+
+	delivered, err := e.alerts.ListDelivered(ctx, ids)
+	if err != nil {
+		return errors.Wrap(err, "alerts.ListDelivered")
+	}
+
+	var result error
+	for _, alert := range pending {
+		var notifyErr error
+		for _, name := range e.rules[alert.Rule].Notifiers {
+			notifier, ok := e.notifiers[name]
+			if !ok || slices.Contains(delivered[alert.ID], name) {
+				continue
+			}
+
+			if err = notifier.Notify(ctx, alert); err != nil {
+				notifyErr = stderrors.Join(notifyErr, errors.Wrapf(err, "notifier %s", name))
+				continue
+			}
+
+			if err = e.alerts.SetDelivered(ctx, alert.ID, name); err != nil {
+				notifyErr = stderrors.Join(notifyErr, errors.Wrap(err, "alerts.SetDelivered"))
+			}
+		}
+
+		if notifyErr != nil {
+			e.log.WarnContext(ctx, "sla alert not delivered",
+				slog.Int64("alert_id", alert.ID),
+				slog.String(logger.KeyOrderID, alert.OrderID),
+				logger.Err(notifyErr),
+			)
+			result = stderrors.Join(result, notifyErr)
+			continue
+		}
+
+		if err = e.alerts.SetNotified(ctx, alert.ID); err != nil {
+			result = stderrors.Join(result, errors.Wrap(err, "alerts.SetNotified"))
+		}
+	}
+
+	return result
+}
+
+func (r Rule) match(order orderqueue.Order, now time.Time) (string, bool) {
+	if r.Marketplace != "" && r.Marketplace != order.Marketplace {
+		return "", false
+	}
+
+	if r.NotPrinting && order.IsPrinting {
+		return "", false
+	}
+
+	var reasons []string
+	if r.OlderThan > 0 {
+		age := now.Sub(order.OrderCreatedAt.Time)
+		if !order.OrderCreatedAt.Valid || age < r.OlderThan {
+			return "", false
+		}
+		reasons = append(reasons, fmt.Sprintf("заказ создан %s назад", formatDuration(age)))
+	}
+
+	if r.ShipmentWithin > 0 {
+		shipmentAt := order.Info.OrderShipmentAt
+		if shipmentAt.IsZero() || shipmentAt.Sub(now) >= r.ShipmentWithin {
+			return "", false
+		}
+
+		if left := shipmentAt.Sub(now); left > 0 {
+			reasons = append(reasons, fmt.Sprintf("до отгрузки %s", formatDuration(left)))
+		} else {
+			reasons = append(reasons, fmt.Sprintf("отгрузка просрочена на %s", formatDuration(-left)))
+		}
+	}
+
+	if len(reasons) == 0 {
+		return "", false
+	}
+
+	if r.NotPrinting {
+		reasons = append(reasons, "не в печати")
+	} else {
+		reasons = append(reasons, "не собран")
+	}
+
+	return strings.Join(reasons, ", "), true
+}
+
+func formatDuration(d time.Duration) string {
+	hours := int(d.Hours())
+
+	return fmt.Sprintf("%d ч. %d мин.", hours, int(d.Minutes())-hours*60)
+}
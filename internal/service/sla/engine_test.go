@@ -0,0 +1,142 @@
+package sla
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/orderqueue"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/slaalert"
+	"github.com/pkg/errors"
+)
+
+type fakeOrders []orderqueue.Order
+
+func (o fakeOrders) ListOpen(context.Context) ([]orderqueue.Order, error) {
+	return o, nil
+}
+
+// fakeAlertStore хранит алерты и доставки в памяти, как таблицы sla_alerts и sla_alert_deliveries
+type fakeAlertStore struct {
+	alerts    []slaalert.Alert
+	delivered map[int64][]string
+}
+
+func (s *fakeAlertStore) AddAlerts(_ context.Context, alerts []slaalert.Alert) error {
+	for _, alert := range alerts {
+		exists := false
+		for _, saved := range s.alerts {
+			exists = exists || (saved.Rule == alert.Rule && saved.OrderID == alert.OrderID && saved.Article == alert.Article)
+		}
+		if !exists {
+			alert.ID = int64(len(s.alerts) + 1)
+			s.alerts = append(s.alerts, alert)
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeAlertStore) ListPending(context.Context) ([]slaalert.Alert, error) {
+	var result []slaalert.Alert
+	for _, alert := range s.alerts {
+		if !alert.NotifiedAt.Valid {
+			result = append(result, alert)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *fakeAlertStore) ListAlerts(context.Context, slaalert.ListFilter) ([]slaalert.Alert, error) {
+	return s.alerts, nil
+}
+
+func (s *fakeAlertStore) ListDelivered(_ context.Context, ids []int64) (map[int64][]string, error) {
+	result := make(map[int64][]string, len(ids))
+	for _, id := range ids {
+		result[id] = s.delivered[id]
+	}
+
+	return result, nil
+}
+
+func (s *fakeAlertStore) SetDelivered(_ context.Context, id int64, notifier string) error {
+	s.delivered[id] = append(s.delivered[id], notifier)
+	return nil
+}
+
+func (s *fakeAlertStore) SetNotified(_ context.Context, id int64) error {
+	s.alerts[id-1].NotifiedAt.Valid = true
+	return nil
+}
+
+func (s *fakeAlertStore) Acknowledge(context.Context, int64, string) error {
+	return nil
+}
+
+type fakeNotifier struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (n *fakeNotifier) Name() string {
+	return n.name
+}
+
+func (n *fakeNotifier) Notify(context.Context, slaalert.Alert) error {
+	n.calls++
+	return n.err
+}
+
+func TestEngine_RetriesOnlyFailedNotifier(t *testing.T) {
+	orders := fakeOrders{{
+		ID:             "order-1",
+		Article:        "dragon",
+		Marketplace:    "ozon",
+		OrderCreatedAt: sql.NullTime{Time: time.Now().Add(-48 * time.Hour), Valid: true},
+	}}
+
+	store := &fakeAlertStore{delivered: make(map[int64][]string)}
+	webhookNotifier := &fakeNotifier{name: NotifierWebhook}
+	telegramNotifier := &fakeNotifier{name: NotifierTelegram, err: errors.New("telegram is down")}
+
+	engine := New(orders, store,
+		[]Rule{{Name: "old", OlderThan: 24 * time.Hour, Notifiers: []string{NotifierWebhook, NotifierTelegram}}},
+		[]Notifier{webhookNotifier, telegramNotifier},
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+
+	for i := 0; i < 2; i++ {
+		if err := engine.Update(context.Background()); err == nil {
+			t.Fatal("expected error while telegram is down")
+		}
+	}
+
+	// webhook уже доставлен и повторно в outbox не пишется
+	if webhookNotifier.calls != 1 {
+		t.Errorf("webhook notified %d times, want 1", webhookNotifier.calls)
+	}
+	if telegramNotifier.calls != 2 {
+		t.Errorf("telegram notified %d times, want 2", telegramNotifier.calls)
+	}
+	if store.alerts[0].NotifiedAt.Valid {
+		t.Error("alert marked notified before telegram delivery")
+	}
+
+	telegramNotifier.err = nil
+	if err := engine.Update(context.Background()); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if webhookNotifier.calls != 1 || telegramNotifier.calls != 3 {
+		t.Errorf("calls webhook %d, telegram %d, want 1 and 3", webhookNotifier.calls, telegramNotifier.calls)
+	}
+	if !store.alerts[0].NotifiedAt.Valid {
+		t.Error("alert not marked notified after all notifiers succeeded")
+	}
+}
@@ -0,0 +1,69 @@
+package sla
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/slaalert"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/webhook"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/pkg/errors"
+)
+
+// имена нотификаторов для поля Notifiers правила
+const (
+	NotifierLog      = "log"
+	NotifierWebhook  = "webhook"
+	NotifierTelegram = "telegram"
+)
+
+type LogNotifier struct {
+	log *slog.Logger
+}
+
+func NewLogNotifier(log *slog.Logger) LogNotifier {
+	return LogNotifier{log: log}
+}
+
+func (n LogNotifier) Name() string {
+	return NotifierLog
+}
+
+func (n LogNotifier) Notify(ctx context.Context, alert slaalert.Alert) error {
+	n.log.WarnContext(ctx, "sla alert",
+		slog.Int64("alert_id", alert.ID),
+		slog.String("rule", alert.Rule),
+		slog.String(logger.KeyMarketplace, alert.Marketplace),
+		slog.String(logger.KeyAccount, alert.Account),
+		slog.String(logger.KeyOrderID, alert.OrderID),
+		slog.String("article", alert.Article),
+		slog.String("message", alert.Message),
+	)
+
+	return nil
+}
+
+type OutboxStore interface {
+	AddOrderEvent(ctx context.Context, eventType, marketplace, account, orderID, article string, extra map[string]any) error
+}
+
+// WebhookNotifier кладёт событие sla.alert в outbox, доставку с подписью и повторами делают получатели вебхуков
+type WebhookNotifier struct {
+	outbox OutboxStore
+}
+
+func NewWebhookNotifier(outbox OutboxStore) WebhookNotifier {
+	return WebhookNotifier{outbox: outbox}
+}
+
+func (n WebhookNotifier) Name() string {
+	return NotifierWebhook
+}
+
+func (n WebhookNotifier) Notify(ctx context.Context, alert slaalert.Alert) error {
+	err := n.outbox.AddOrderEvent(ctx, webhook.EventSLAAlert, alert.Marketplace, alert.Account, alert.OrderID, alert.Article, map[string]any{
+		"alert": alert,
+	})
+
+	return errors.Wrap(err, "outbox.AddOrderEvent")
+}
@@ -0,0 +1,383 @@
+package telegrambot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/telegram"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/orderqueue"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/slaalert"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/webhook"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	// имена задач в Runner, новые заказы приходят через outbox под курсором ConsumerName
+	ConsumerName   = "telegram"
+	UpdatesJobName = "telegram_updates"
+	SummaryJobName = "telegram_summary"
+
+	// pollTimeout секунды long polling getUpdates
+	pollTimeout = 25
+
+	callbackPrinting    = "p:"
+	callbackComplete    = "c:"
+	callbackAcknowledge = "a:"
+)
+
+type (
+	Client interface {
+		SendMessage(ctx context.Context, req telegram.SendMessageRequest) (telegram.Message, error)
+		SendPhoto(ctx context.Context, req telegram.SendPhotoRequest) (telegram.Message, error)
+		GetUpdates(ctx context.Context, req telegram.GetUpdatesRequest) ([]telegram.Update, error)
+		AnswerCallbackQuery(ctx context.Context, req telegram.AnswerCallbackQueryRequest) error
+	}
+
+	QueueService interface {
+		SetComplete(ctx context.Context, id string, state bool) error
+		SetPrinting(ctx context.Context, id string, state bool) error
+	}
+
+	CardProvider interface {
+		GetByArticlesMap(ctx context.Context, mp card.Marketplace, account string, articles []string) (map[string]card.Card, error)
+	}
+
+	StatsProvider interface {
+		GetDueStats(ctx context.Context, dayStart time.Time) ([]orderqueue.DueStat, error)
+	}
+
+	// CursorStore дата последней сводки хранится в базе, чтобы рестарт или смена ведущего не повторяли её
+	CursorStore interface {
+		Get(ctx context.Context, name string) (string, error)
+		Save(ctx context.Context, name, value string) error
+	}
+
+	AlertAcknowledger interface {
+		Acknowledge(ctx context.Context, id int64, by string) error
+	}
+)
+
+// Config SummaryAt время ежедневной сводки от начала суток в часовом поясе Location
+type Config struct {
+	ChatID    int64
+	SummaryAt time.Duration
+	Location  *time.Location
+}
+
+// Bot пишет операторам в один чат и принимает нажатия кнопок только из него
+type Bot struct {
+	client  Client
+	queue   QueueService
+	cards   CardProvider
+	stats   StatsProvider
+	cursors CursorStore
+	alerts  AlertAcknowledger
+	cfg     Config
+	log     *slog.Logger
+
+	// offset меняет только задача UpdatesJobName, она выполняется последовательно
+	offset int64
+}
+
+func New(
+	client Client,
+	queue QueueService,
+	cards CardProvider,
+	stats StatsProvider,
+	cursors CursorStore,
+	cfg Config,
+	log *slog.Logger,
+) *Bot {
+	return &Bot{
+		client:  client,
+		queue:   queue,
+		cards:   cards,
+		stats:   stats,
+		cursors: cursors,
+		cfg:     cfg,
+		log:     log.With(slog.String("component", "telegram")),
+	}
+}
+
+// WithAlerts включает кнопку подтверждения в алертах SLA, движок правил сам использует бота как нотификатор
+func (b *Bot) WithAlerts(alerts AlertAcknowledger) *Bot {
+	b.alerts = alerts
+	return b
+}
+
+// orderPayload заказ в событии outbox, см. webhook_order_payload
+type orderPayload struct {
+	ID             string           `json:"id"`
+	Article        string           `json:"article"`
+	Marketplace    string           `json:"marketplace"`
+	Account        string           `json:"account"`
+	Info           orderqueue.Info  `json:"info"`
+	CompositeItems orderqueue.Items `json:"composite_items"`
+}
+
+func (b *Bot) Name() string {
+	return ConsumerName
+}
+
+func (b *Bot) Subscribed(eventType string) bool {
+	return eventType == webhook.EventOrderCreated || eventType == webhook.EventOrderOverdue
+}
+
+// Deliver публикует новый или просроченный заказ с фото карточки и кнопками
+func (b *Bot) Deliver(ctx context.Context, event webhook.Event) error {
+	var order orderPayload
+	if err := json.Unmarshal(event.Payload, &order); err != nil {
+		return errors.Wrap(err, "json.Unmarshal")
+	}
+
+	cards, err := b.cards.GetByArticlesMap(ctx, card.Marketplace(order.Marketplace), order.Account, []string{order.Article})
+	if err != nil {
+		return errors.Wrap(err, "cards.GetByArticlesMap")
+	}
+	currentCard := cards[order.Article]
+
+	text := orderText(event.Type, order, currentCard, b.cfg.Location)
+	markup := orderKeyboard(order.ID)
+
+	if currentCard.Photo != "" {
+		_, err = b.client.SendPhoto(ctx, telegram.SendPhotoRequest{
+			ChatID:      b.cfg.ChatID,
+			Photo:       currentCard.Photo,
+			Caption:     text,
+			ParseMode:   telegram.ParseModeHTML,
+			ReplyMarkup: markup,
+		})
+
+		// битая ссылка на фото не должна останавливать доставку, такой заказ уходит текстом
+		var apiErr *telegram.APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != 400 {
+			return errors.Wrap(err, "client.SendPhoto")
+		}
+
+		b.log.WarnContext(ctx, "photo rejected, sending text", slog.String(logger.KeyOrderID, order.ID), logger.Err(err))
+	}
+
+	_, err = b.client.SendMessage(ctx, telegram.SendMessageRequest{
+		ChatID:      b.cfg.ChatID,
+		Text:        text,
+		ParseMode:   telegram.ParseModeHTML,
+		ReplyMarkup: markup,
+	})
+
+	return errors.Wrap(err, "client.SendMessage")
+}
+
+// Notify алерт SLA с кнопками очереди и подтверждения
+func (b *Bot) Notify(ctx context.Context, alert slaalert.Alert) error {
+	text := fmt.Sprintf("<b>SLA: %s</b> · %s", html.EscapeString(alert.Rule), html.EscapeString(alert.Marketplace))
+	if alert.Account != card.DefaultAccount {
+		text += "/" + html.EscapeString(alert.Account)
+	}
+	text += fmt.Sprintf("\nАртикул: <code>%s</code>\n%s", html.EscapeString(alert.Article), html.EscapeString(alert.Message))
+
+	markup := orderKeyboard(alert.OrderID)
+	if b.alerts != nil {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, []telegram.InlineKeyboardButton{
+			{Text: "Принято", CallbackData: callbackAcknowledge + strconv.FormatInt(alert.ID, 10)},
+		})
+	}
+
+	_, err := b.client.SendMessage(ctx, telegram.SendMessageRequest{
+		ChatID:      b.cfg.ChatID,
+		Text:        text,
+		ParseMode:   telegram.ParseModeHTML,
+		ReplyMarkup: markup,
+	})
+
+	return errors.Wrap(err, "client.SendMessage")
+}
+
+// PollUpdates один цикл long polling, нажатия кнопок вызывают те же методы очереди, что и веб-интерфейс
+func (b *Bot) PollUpdates(ctx context.Context) error {
+	updates, err := b.client.GetUpdates(ctx, telegram.GetUpdatesRequest{
+		Offset:         b.offset,
+		Timeout:        pollTimeout,
+		AllowedUpdates: []string{"callback_query"},
+	})
+	if err != nil {
+		return errors.Wrap(err, "client.GetUpdates")
+	}
+
+	for _, update := range updates {
+		b.offset = update.UpdateID + 1
+		if update.CallbackQuery == nil {
+			continue
+		}
+
+		if err = b.handleCallback(ctx, *update.CallbackQuery); err != nil {
+			b.log.ErrorContext(ctx, "callback failed", slog.String("data", update.CallbackQuery.Data), logger.Err(err))
+		}
+	}
+
+	return nil
+}
+
+func (b *Bot) handleCallback(ctx context.Context, query telegram.CallbackQuery) error {
+	answer := func(text string) error {
+		return errors.Wrap(b.client.AnswerCallbackQuery(ctx, telegram.AnswerCallbackQueryRequest{
+			CallbackQueryID: query.ID,
+			Text:            text,
+		}), "client.AnswerCallbackQuery")
+	}
+
+	if query.Message == nil || query.Message.Chat.ID != b.cfg.ChatID {
+		return answer("Чат не подключён к очереди")
+	}
+
+	log := b.log.With(slog.Int64("user_id", query.From.ID), slog.String("username", query.From.Username))
+
+	switch {
+	case strings.HasPrefix(query.Data, callbackPrinting):
+		id := strings.TrimPrefix(query.Data, callbackPrinting)
+		if err := b.queue.SetPrinting(ctx, id, true); err != nil {
+			_ = answer("Не удалось отметить, попробуйте ещё раз")
+			return errors.Wrap(err, "queue.SetPrinting")
+		}
+
+		log.InfoContext(ctx, "marked printing from telegram", slog.String(logger.KeyOrderID, id))
+		return answer("В печати")
+	case strings.HasPrefix(query.Data, callbackComplete):
+		id := strings.TrimPrefix(query.Data, callbackComplete)
+		if err := b.queue.SetComplete(ctx, id, true); err != nil {
+			_ = answer("Не удалось отметить, попробуйте ещё раз")
+			return errors.Wrap(err, "queue.SetComplete")
+		}
+
+		log.InfoContext(ctx, "marked complete from telegram", slog.String(logger.KeyOrderID, id))
+		return answer("Готово")
+	case strings.HasPrefix(query.Data, callbackAcknowledge) && b.alerts != nil:
+		id, err := strconv.ParseInt(strings.TrimPrefix(query.Data, callbackAcknowledge), 10, 64)
+		if err != nil {
+			return answer("Неизвестная команда")
+		}
+
+		by := query.From.Username
+		if by == "" {
+			by = strconv.FormatInt(query.From.ID, 10)
+		}
+
+		if err = b.alerts.Acknowledge(ctx, id, "telegram:"+by); err != nil {
+			_ = answer("Не удалось подтвердить, попробуйте ещё раз")
+			return errors.Wrap(err, "alerts.Acknowledge")
+		}
+
+		return answer("Принято")
+	default:
+		return answer("Неизвестная команда")
+	}
+}
+
+// SendSummary задача запускается часто и отправляет сводку один раз в день после SummaryAt
+func (b *Bot) SendSummary(ctx context.Context) error {
+	now := time.Now().In(b.cfg.Location)
+	today := now.Format(time.DateOnly)
+	if time.Duration(now.Hour())*time.Hour+time.Duration(now.Minute())*time.Minute < b.cfg.SummaryAt {
+		return nil
+	}
+
+	lastSummary, err := b.cursors.Get(ctx, SummaryJobName)
+	if err != nil {
+		return errors.Wrap(err, "cursors.Get")
+	}
+	if lastSummary == today {
+		return nil
+	}
+
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, b.cfg.Location)
+	stats, err := b.stats.GetDueStats(ctx, dayStart)
+	if err != nil {
+		return errors.Wrap(err, "stats.GetDueStats")
+	}
+
+	_, err = b.client.SendMessage(ctx, telegram.SendMessageRequest{
+		ChatID:    b.cfg.ChatID,
+		Text:      summaryText(dayStart, stats),
+		ParseMode: telegram.ParseModeHTML,
+	})
+	if err != nil {
+		return errors.Wrap(err, "client.SendMessage")
+	}
+
+	return errors.Wrap(b.cursors.Save(ctx, SummaryJobName, today), "cursors.Save")
+}
+
+func orderText(eventType string, order orderPayload, currentCard card.Card, loc *time.Location) string {
+	name := currentCard.Name
+	if name == "" {
+		name = order.Info.Name
+	}
+
+	title := "Новый заказ"
+	if eventType == webhook.EventOrderOverdue {
+		title = "Просрочена отгрузка"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<b>%s</b> · %s", title, html.EscapeString(order.Marketplace))
+	if order.Account != card.DefaultAccount {
+		fmt.Fprintf(&sb, "/%s", html.EscapeString(order.Account))
+	}
+	fmt.Fprintf(&sb, "\n%s\n", html.EscapeString(name))
+
+	if order.Article != "" {
+		fmt.Fprintf(&sb, "Артикул: <code>%s</code>\n", html.EscapeString(order.Article))
+	}
+	if order.Info.Quantity > 1 {
+		fmt.Fprintf(&sb, "Количество: %d\n", order.Info.Quantity)
+	}
+	if order.Info.OrderNumber != "" {
+		fmt.Fprintf(&sb, "Заказ: %s\n", html.EscapeString(order.Info.OrderNumber))
+	}
+	if !order.Info.OrderShipmentAt.IsZero() {
+		at := order.Info.OrderShipmentAt.In(loc)
+		fmt.Fprintf(&sb, "Отгрузка: %d %s\n", at.Day(), utils.DeclensionGenitiveMonth(int32(at.Month())))
+	}
+	for _, item := range order.CompositeItems {
+		fmt.Fprintf(&sb, "• %s\n", html.EscapeString(item.Name))
+	}
+	if order.Info.Note != "" {
+		fmt.Fprintf(&sb, "<i>%s</i>\n", html.EscapeString(order.Info.Note))
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func orderKeyboard(id string) *telegram.InlineKeyboardMarkup {
+	return &telegram.InlineKeyboardMarkup{InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+		{Text: "В печать", CallbackData: callbackPrinting + id},
+		{Text: "Готово", CallbackData: callbackComplete + id},
+	}}}
+}
+
+func summaryText(dayStart time.Time, stats []orderqueue.DueStat) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<b>Сводка на %d %s</b>\n", dayStart.Day(), utils.DeclensionGenitiveMonth(int32(dayStart.Month())))
+
+	if len(stats) == 0 {
+		sb.WriteString("Открытых заказов нет")
+		return sb.String()
+	}
+
+	for _, stat := range stats {
+		fmt.Fprintf(&sb, "\n%s: сегодня %d, завтра %d", html.EscapeString(stat.Marketplace), stat.Today, stat.Tomorrow)
+		if stat.Overdue > 0 {
+			fmt.Fprintf(&sb, ", просрочено %d", stat.Overdue)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,348 @@
+package telegrambot
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/client/telegram"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/orderqueue"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/webhook"
+)
+
+const (
+	testToken  = "123:secret"
+	testChatID = int64(-100500)
+)
+
+// apiCall запрос к фейковому Bot API
+type apiCall struct {
+	Method string
+	Body   map[string]any
+}
+
+// fakeBotAPI отвечает как Bot API и запоминает все запросы, getUpdates отдаёт updates один раз
+type fakeBotAPI struct {
+	t *testing.T
+
+	mu      sync.Mutex
+	calls   []apiCall
+	updates []telegram.Update
+}
+
+func (a *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix := "/bot" + testToken + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"ok":false,"error_code":401,"description":"Unauthorized"}`))
+		return
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.t.Errorf("decode request: %v", err)
+	}
+
+	method := strings.TrimPrefix(r.URL.Path, prefix)
+
+	a.mu.Lock()
+	a.calls = append(a.calls, apiCall{Method: method, Body: body})
+	var result any = true
+	switch method {
+	case "getUpdates":
+		result = a.updates
+		a.updates = nil
+	case "sendMessage", "sendPhoto":
+		result = telegram.Message{MessageID: int64(len(a.calls)), Chat: telegram.Chat{ID: testChatID}}
+	}
+	a.mu.Unlock()
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+func (a *fakeBotAPI) callsOf(method string) []apiCall {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var result []apiCall
+	for _, call := range a.calls {
+		if call.Method == method {
+			result = append(result, call)
+		}
+	}
+
+	return result
+}
+
+type fakeQueue struct {
+	printing []string
+	complete []string
+}
+
+func (q *fakeQueue) SetComplete(_ context.Context, id string, state bool) error {
+	if state {
+		q.complete = append(q.complete, id)
+	}
+	return nil
+}
+
+func (q *fakeQueue) SetPrinting(_ context.Context, id string, state bool) error {
+	if state {
+		q.printing = append(q.printing, id)
+	}
+	return nil
+}
+
+type fakeCards map[string]card.Card
+
+func (c fakeCards) GetByArticlesMap(_ context.Context, _ card.Marketplace, _ string, articles []string) (map[string]card.Card, error) {
+	result := make(map[string]card.Card, len(articles))
+	for _, article := range articles {
+		if found, ok := c[article]; ok {
+			result[article] = found
+		}
+	}
+
+	return result, nil
+}
+
+type fakeStats []orderqueue.DueStat
+
+func (s fakeStats) GetDueStats(context.Context, time.Time) ([]orderqueue.DueStat, error) {
+	return s, nil
+}
+
+// fakeCursors общий для нескольких ботов, как таблица job_cursors для нескольких экземпляров
+type fakeCursors struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (c *fakeCursors) Get(_ context.Context, name string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.values[name], nil
+}
+
+func (c *fakeCursors) Save(_ context.Context, name, value string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.values == nil {
+		c.values = make(map[string]string)
+	}
+	c.values[name] = value
+
+	return nil
+}
+
+type fakeAlerts struct {
+	acknowledged map[int64]string
+}
+
+func (a *fakeAlerts) Acknowledge(_ context.Context, id int64, by string) error {
+	a.acknowledged[id] = by
+	return nil
+}
+
+type testBot struct {
+	*Bot
+	api     *fakeBotAPI
+	queue   *fakeQueue
+	cursors *fakeCursors
+}
+
+func newTestBot(t *testing.T, summaryAt time.Duration, cursors *fakeCursors) testBot {
+	t.Helper()
+
+	api := &fakeBotAPI{t: t}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	queue := &fakeQueue{}
+	cards := fakeCards{"dragon": {Name: "Дракон", Article: "dragon", Photo: srv.URL + "/dragon.jpg"}}
+	stats := fakeStats{{Marketplace: "ozon", Overdue: 1, Today: 3, Tomorrow: 2}}
+
+	bot := New(
+		telegram.NewClient(srv.URL, testToken, log),
+		queue, cards, stats, cursors,
+		Config{ChatID: testChatID, SummaryAt: summaryAt, Location: time.UTC},
+		log,
+	)
+
+	return testBot{Bot: bot, api: api, queue: queue, cursors: cursors}
+}
+
+func callback(updateID int64, chatID int64, data string) telegram.Update {
+	return telegram.Update{
+		UpdateID: updateID,
+		CallbackQuery: &telegram.CallbackQuery{
+			ID:      "cb" + data,
+			From:    telegram.User{ID: 42, Username: "operator"},
+			Message: &telegram.Message{MessageID: 1, Chat: telegram.Chat{ID: chatID}},
+			Data:    data,
+		},
+	}
+}
+
+func TestBot_PollUpdatesCommands(t *testing.T) {
+	bot := newTestBot(t, 0, &fakeCursors{})
+	alerts := &fakeAlerts{acknowledged: make(map[int64]string)}
+	bot.WithAlerts(alerts)
+
+	bot.api.updates = []telegram.Update{
+		callback(10, testChatID, callbackPrinting+"order-1"),
+		callback(11, testChatID, callbackComplete+"order-2"),
+		callback(12, testChatID, callbackAcknowledge+"7"),
+		callback(13, 12345, callbackComplete+"order-3"),
+		callback(14, testChatID, "x:unknown"),
+	}
+
+	if err := bot.PollUpdates(context.Background()); err != nil {
+		t.Fatalf("PollUpdates: %v", err)
+	}
+
+	if len(bot.queue.printing) != 1 || bot.queue.printing[0] != "order-1" {
+		t.Errorf("printing = %v, want [order-1]", bot.queue.printing)
+	}
+	// нажатие из чужого чата не меняет очередь
+	if len(bot.queue.complete) != 1 || bot.queue.complete[0] != "order-2" {
+		t.Errorf("complete = %v, want [order-2]", bot.queue.complete)
+	}
+	if by := alerts.acknowledged[7]; by != "telegram:operator" {
+		t.Errorf("alert 7 acknowledged by %q", by)
+	}
+
+	answers := bot.api.callsOf("answerCallbackQuery")
+	want := []string{"В печати", "Готово", "Принято", "Чат не подключён к очереди", "Неизвестная команда"}
+	if len(answers) != len(want) {
+		t.Fatalf("got %d answers, want %d", len(answers), len(want))
+	}
+	for i, answer := range answers {
+		if answer.Body["text"] != want[i] {
+			t.Errorf("answer #%d = %v, want %q", i, answer.Body["text"], want[i])
+		}
+	}
+
+	// следующий опрос подтверждает полученные обновления через offset
+	if err := bot.PollUpdates(context.Background()); err != nil {
+		t.Fatalf("PollUpdates: %v", err)
+	}
+	polls := bot.api.callsOf("getUpdates")
+	if offset := polls[len(polls)-1].Body["offset"]; offset != float64(15) {
+		t.Errorf("offset = %v, want 15", offset)
+	}
+}
+
+func TestBot_DeliverNewOrder(t *testing.T) {
+	bot := newTestBot(t, 0, &fakeCursors{})
+
+	payload, _ := json.Marshal(orderPayload{
+		ID:          "order-1",
+		Article:     "dragon",
+		Marketplace: "ozon",
+		Account:     card.DefaultAccount,
+		Info:        orderqueue.Info{Quantity: 2, OrderNumber: "12345-0001"},
+	})
+
+	err := bot.Deliver(context.Background(), webhook.Event{ID: 1, Type: webhook.EventOrderCreated, Payload: payload})
+	if err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+
+	photos := bot.api.callsOf("sendPhoto")
+	if len(photos) != 1 {
+		t.Fatalf("got %d sendPhoto, want 1", len(photos))
+	}
+
+	caption, _ := photos[0].Body["caption"].(string)
+	for _, part := range []string{"Новый заказ", "Дракон", "Количество: 2", "12345-0001"} {
+		if !strings.Contains(caption, part) {
+			t.Errorf("caption %q does not contain %q", caption, part)
+		}
+	}
+	if !strings.Contains(string(mustJSON(t, photos[0].Body["reply_markup"])), callbackPrinting+"order-1") {
+		t.Errorf("reply_markup without printing button: %v", photos[0].Body["reply_markup"])
+	}
+}
+
+func TestBot_SendSummaryOncePerDay(t *testing.T) {
+	cursors := &fakeCursors{}
+	bot := newTestBot(t, 0, cursors)
+
+	for i := 0; i < 2; i++ {
+		if err := bot.SendSummary(context.Background()); err != nil {
+			t.Fatalf("SendSummary: %v", err)
+		}
+	}
+
+	messages := bot.api.callsOf("sendMessage")
+	if len(messages) != 1 {
+		t.Fatalf("got %d summaries, want 1", len(messages))
+	}
+	if text, _ := messages[0].Body["text"].(string); !strings.Contains(text, "ozon: сегодня 3, завтра 2, просрочено 1") {
+		t.Errorf("summary text = %q", text)
+	}
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	if saved, _ := cursors.Get(context.Background(), SummaryJobName); saved != today {
+		t.Errorf("saved summary date = %q, want %q", saved, today)
+	}
+
+	// после рестарта или смены ведущего новый экземпляр видит сохранённую дату и сводку не повторяет
+	restarted := newTestBot(t, 0, cursors)
+	if err := restarted.SendSummary(context.Background()); err != nil {
+		t.Fatalf("SendSummary after restart: %v", err)
+	}
+	if calls := restarted.api.callsOf("sendMessage"); len(calls) != 0 {
+		t.Errorf("summary sent again after restart: %d messages", len(calls))
+	}
+}
+
+func TestBot_SendSummaryBeforeTime(t *testing.T) {
+	// сутки от полуночи позже любого времени суток
+	bot := newTestBot(t, 24*time.Hour, &fakeCursors{})
+
+	if err := bot.SendSummary(context.Background()); err != nil {
+		t.Fatalf("SendSummary: %v", err)
+	}
+
+	if calls := bot.api.callsOf("sendMessage"); len(calls) != 0 {
+		t.Errorf("summary sent before SummaryAt: %d messages", len(calls))
+	}
+}
+
+func TestBot_SendSummaryRetriesAfterFailure(t *testing.T) {
+	cursors := &fakeCursors{}
+	bot := newTestBot(t, 0, cursors)
+	bot.client = telegram.NewClient("http://127.0.0.1:0", testToken, bot.log)
+
+	if err := bot.SendSummary(context.Background()); err == nil {
+		t.Fatal("expected error when Bot API is unreachable")
+	}
+
+	if saved, _ := cursors.Get(context.Background(), SummaryJobName); saved != "" {
+		t.Errorf("summary date saved after failed send: %q", saved)
+	}
+}
+
+func mustJSON(t *testing.T, value any) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+
+	return data
+}
@@ -0,0 +1,248 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/webhook"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	// JobName задача доставки и уборки outbox
+	JobName = "webhooks"
+
+	// заголовки запроса, подпись считается как HMAC-SHA256(secret, timestamp + "." + body)
+	HeaderEvent     = "X-Factory-Event"
+	HeaderDelivery  = "X-Factory-Delivery"
+	HeaderTimestamp = "X-Factory-Timestamp"
+	HeaderSignature = "X-Factory-Signature"
+
+	batchSize      = 100
+	requestTimeout = 10 * time.Second
+	// retention доставленные всем получателям события хранятся для разбора инцидентов
+	retention  = 7 * 24 * time.Hour
+	minBackoff = 10 * time.Second
+	maxBackoff = time.Hour
+)
+
+type Store interface {
+	ListEvents(ctx context.Context, cursor webhook.Cursor, limit uint64) ([]webhook.Event, error)
+	GetCursor(ctx context.Context, endpoint string) (webhook.Cursor, error)
+	SaveCursor(ctx context.Context, cursor webhook.Cursor) error
+	AddOverdueEvents(ctx context.Context) (int64, error)
+	DeleteDelivered(ctx context.Context, before time.Time, endpoints []string) (int64, error)
+}
+
+// Consumer получатель событий outbox, у каждого свой курсор по Name
+type Consumer interface {
+	Name() string
+	Subscribed(eventType string) bool
+	Deliver(ctx context.Context, event webhook.Event) error
+}
+
+// Endpoint получатель вебхуков, пустой Events - все события
+type Endpoint struct {
+	Name   string
+	URL    string
+	Secret string
+	Events []string
+}
+
+// HTTPConsumer отправляет события POST запросом с подписью
+type HTTPConsumer struct {
+	endpoint   Endpoint
+	httpClient *http.Client
+}
+
+func NewHTTPConsumer(endpoint Endpoint) HTTPConsumer {
+	return HTTPConsumer{endpoint: endpoint, httpClient: &http.Client{Timeout: requestTimeout}}
+}
+
+func (c HTTPConsumer) Name() string {
+	return c.endpoint.Name
+}
+
+func (c HTTPConsumer) Subscribed(eventType string) bool {
+	return len(c.endpoint.Events) == 0 || slices.Contains(c.endpoint.Events, eventType)
+}
+
+// Payload тело запроса к получателю
+type Payload struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Order     json.RawMessage `json:"order"`
+}
+
+// Dispatcher доставляет события каждому получателю строго по порядку записавших их транзакций:
+// пока событие не принято, следующие ждут, повтор с экспоненциальной задержкой, события не пропускаются
+type Dispatcher struct {
+	store     Store
+	consumers []Consumer
+	log       *slog.Logger
+}
+
+func NewDispatcher(store Store, consumers []Consumer, log *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		store:     store,
+		consumers: consumers,
+		log:       log,
+	}
+}
+
+func (d *Dispatcher) Update(ctx context.Context) error {
+	var result error
+
+	if len(d.consumers) > 0 {
+		added, err := d.store.AddOverdueEvents(ctx)
+		if err != nil {
+			result = stderrors.Join(result, errors.Wrap(err, "store.AddOverdueEvents"))
+		} else if added > 0 {
+			d.log.InfoContext(ctx, "overdue webhook events added", slog.Int64("count", added))
+		}
+	}
+
+	names := make([]string, 0, len(d.consumers))
+	for _, consumer := range d.consumers {
+		names = append(names, consumer.Name())
+		if err := d.deliver(ctx, consumer); err != nil {
+			result = stderrors.Join(result, errors.Wrapf(err, "webhook %s", consumer.Name()))
+		}
+	}
+
+	if _, err := d.store.DeleteDelivered(ctx, time.Now().Add(-retention), names); err != nil {
+		result = stderrors.Join(result, errors.Wrap(err, "store.DeleteDelivered"))
+	}
+
+	return result
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, consumer Consumer) error {
+	cursor, err := d.store.GetCursor(ctx, consumer.Name())
+	if errors.Is(err, webhook.ErrNotFound) {
+		if cursor, err = d.initCursor(ctx, consumer); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return errors.Wrap(err, "store.GetCursor")
+	}
+
+	if time.Now().Before(cursor.NextAttemptAt) {
+		return nil
+	}
+
+	events, err := d.store.ListEvents(ctx, cursor, batchSize)
+	if err != nil {
+		return errors.Wrap(err, "store.ListEvents")
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	log := d.log.With(slog.String("endpoint", consumer.Name()))
+	for _, event := range events {
+		if consumer.Subscribed(event.Type) {
+			if sendErr := consumer.Deliver(ctx, event); sendErr != nil {
+				cursor.Attempts++
+				cursor.NextAttemptAt = time.Now().Add(backoff(cursor.Attempts))
+				cursor.LastError = sendErr.Error()
+
+				log.WarnContext(ctx, "webhook delivery failed",
+					slog.Int64("event_id", event.ID),
+					slog.Int("attempts", int(cursor.Attempts)),
+					slog.Time("next_attempt_at", cursor.NextAttemptAt),
+					logger.Err(sendErr),
+				)
+
+				break
+			}
+
+			log.DebugContext(ctx, "webhook delivered", slog.Int64("event_id", event.ID), slog.String("event", event.Type))
+		}
+
+		cursor.LastTxID = event.TxID
+		cursor.LastEventID = event.ID
+		cursor.Attempts = 0
+		cursor.LastError = ""
+	}
+
+	return errors.Wrap(d.store.SaveCursor(ctx, cursor), "store.SaveCursor")
+}
+
+// initCursor новый получатель получает все события, которые ещё хранятся в outbox:
+// пока у него не было курсора, DeleteDelivered ничего не удалял
+func (d *Dispatcher) initCursor(ctx context.Context, consumer Consumer) (webhook.Cursor, error) {
+	cursor := webhook.Cursor{Endpoint: consumer.Name(), NextAttemptAt: time.Now()}
+	if err := d.store.SaveCursor(ctx, cursor); err != nil {
+		return webhook.Cursor{}, errors.Wrap(err, "store.SaveCursor")
+	}
+
+	d.log.InfoContext(ctx, "webhook endpoint registered", slog.String("endpoint", consumer.Name()))
+
+	return cursor, nil
+}
+
+func (c HTTPConsumer) Deliver(ctx context.Context, event webhook.Event) error {
+	body, err := json.Marshal(Payload{ID: event.ID, Type: event.Type, CreatedAt: event.CreatedAt, Order: event.Payload})
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "http.NewRequestWithContext")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, event.Type)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(event.ID, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(c.endpoint.Secret, timestamp, body))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "httpClient.Do")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("http status:%d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Sign hex HMAC-SHA256 от timestamp и тела, получатель сверяет его с X-Factory-Signature
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func backoff(attempts int32) time.Duration {
+	delay := minBackoff
+	for i := int32(1); i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxBackoff)
+}
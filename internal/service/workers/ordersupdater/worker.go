@@ -0,0 +1,166 @@
+package ordersupdater
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/db/orderqueue"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/marketplace"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type (
+	OrdersStore interface {
+		AddOrders(ctx context.Context, orders []orderqueue.Order) error
+		SetCompleteByOrderIDs(ctx context.Context, marketplace, account string, orderIDs []string) error
+	}
+	CardsStore interface {
+		GetByArticlesMap(ctx context.Context, mp card.Marketplace, account string, articles []string) (map[string]card.Card, error)
+	}
+)
+
+// Worker переносит новые заказы одного кабинета маркетплейса в очередь печати
+type Worker struct {
+	marketplace marketplace.Marketplace
+	ordersStore OrdersStore
+	cardsStore  CardsStore
+	log         *slog.Logger
+}
+
+func NewWorker(mp marketplace.Marketplace, ordersStore OrdersStore, cardsStore CardsStore, log *slog.Logger) Worker {
+	return Worker{
+		marketplace: mp,
+		ordersStore: ordersStore,
+		cardsStore:  cardsStore,
+		log: log.With(
+			slog.String(logger.KeyMarketplace, mp.Name().String()),
+			slog.String(logger.KeyAccount, mp.Account()),
+		),
+	}
+}
+
+// JobName у кабинета по умолчанию прежнее имя задачи, чтобы не менять паузы и дашборды
+func JobName(mp card.Marketplace, account string) string {
+	if account == card.DefaultAccount {
+		return mp.String() + "_orders"
+	}
+
+	return mp.String() + "_orders_" + account
+}
+
+func (w Worker) Marketplace() marketplace.Marketplace {
+	return w.marketplace
+}
+
+// Update забирает новые заказы и добавляет их в очередь
+func (w Worker) Update(ctx context.Context) error {
+	orders, err := w.marketplace.ListNewOrders(ctx)
+	if err != nil {
+		return errors.Wrap(err, "marketplace.ListNewOrders")
+	}
+
+	return w.addOrders(ctx, orders)
+}
+
+// Backfill добавляет в очередь заказы, созданные начиная с since, и сразу закрывает уже собранные и отменённые
+func (w Worker) Backfill(ctx context.Context, since time.Time) error {
+	backfiller, ok := w.marketplace.(marketplace.Backfiller)
+	if !ok {
+		return errors.Errorf("backfill is not supported for %s", w.marketplace.Name())
+	}
+
+	err := backfiller.ListOrdersSince(ctx, since, func(ctx context.Context, orders []marketplace.Order, finishedIDs []string) error {
+		if err := w.addOrders(ctx, orders); err != nil {
+			return err
+		}
+
+		if len(finishedIDs) == 0 {
+			return nil
+		}
+
+		err := w.ordersStore.SetCompleteByOrderIDs(ctx, w.marketplace.Name().String(), w.marketplace.Account(), finishedIDs)
+
+		return errors.Wrap(err, "ordersStore.SetCompleteByOrderIDs")
+	})
+
+	return errors.Wrap(err, "marketplace.ListOrdersSince")
+}
+
+func (w Worker) addOrders(ctx context.Context, orders []marketplace.Order) error {
+	if len(orders) <= 0 {
+		return nil
+	}
+
+	ordersArticles := make([]string, 0, len(orders))
+	for _, order := range orders {
+		ordersArticles = append(ordersArticles, order.Article)
+	}
+
+	cards, err := w.cardsStore.GetByArticlesMap(ctx, w.marketplace.Name(), w.marketplace.Account(), ordersArticles)
+	if err != nil {
+		return errors.Wrap(err, "cardsStore.GetByArticlesMap")
+	}
+
+	if err = w.ordersStore.AddOrders(ctx, w.convertOrders(ctx, orders, cards)); err != nil {
+		return errors.Wrap(err, "ordersStore.AddOrders")
+	}
+
+	return nil
+}
+
+func (w Worker) convertOrders(ctx context.Context, orders []marketplace.Order, cards map[string]card.Card) []orderqueue.Order {
+	result := make([]orderqueue.Order, 0, len(orders))
+	for _, order := range orders {
+		c, ok := cards[order.Article]
+		if !ok {
+			w.log.WarnContext(ctx, "card not found, order skipped",
+				slog.String(logger.KeyOrderID, order.ID),
+				slog.String(logger.KeyArticle, order.Article),
+			)
+			continue
+		}
+
+		result = append(result, orderqueue.Order{
+			ID:             order.ID,
+			Article:        order.Article,
+			Marketplace:    w.marketplace.Name().String(),
+			Account:        w.marketplace.Account(),
+			Items:          makeItems(c),
+			OrderCreatedAt: sql.NullTime{Time: order.CreatedAt, Valid: true},
+			Price:          order.Price,
+			Commission:     order.Commission,
+			Currency:       order.Currency,
+			Info: orderqueue.Info{
+				OrderNumber:     order.Number,
+				OrderShipmentAt: order.ShipmentAt,
+				Quantity:        order.Quantity,
+				Barcodes:        order.Barcodes,
+				Sku:             order.SKU,
+			},
+		})
+	}
+
+	return result
+}
+
+func makeItems(c card.Card) []orderqueue.Item {
+	if !c.IsComposite {
+		return []orderqueue.Item{}
+	}
+
+	result := make([]orderqueue.Item, 0, len(c.Articles))
+	for _, art := range c.Articles {
+		result = append(result, orderqueue.Item{
+			ID:         uuid.NewString(),
+			Name:       art,
+			IsComplete: false,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,401 @@
+// Package workers запускает фоновые задачи по расписанию
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alleswebdev/marketplace-3d-factory/internal/logger"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/metrics"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// broadcastTimeout ограничение на передачу ручного запуска другим экземплярам
+const broadcastTimeout = 5 * time.Second
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrNotLeader   = errors.New("job is run by another instance")
+)
+
+type (
+	// Locker решает, какой из экземпляров приложения выполняет задачу
+	Locker interface {
+		TryLock(ctx context.Context, name string) (bool, error)
+	}
+
+	// Broadcaster передаёт ручной запуск задачи всем экземплярам приложения
+	Broadcaster interface {
+		Notify(ctx context.Context, name string) error
+	}
+
+	// JobFunc выполняет один проход задачи
+	JobFunc func(ctx context.Context) error
+
+	JobOptions struct {
+		// Interval пауза между успешными запусками
+		Interval time.Duration
+		// Jitter доля интервала, на которую случайно сдвигается следующий запуск, например 0.1
+		Jitter float64
+		// Timeout ограничение на один запуск, 0 - без ограничения
+		Timeout time.Duration
+		// MaxBackoff верхняя граница паузы после ошибок, 0 - без экспоненциального роста
+		MaxBackoff time.Duration
+	}
+
+	JobState struct {
+		Name          string    `json:"name"`
+		Interval      string    `json:"interval"`
+		Paused        bool      `json:"paused"`
+		Leader        bool      `json:"leader"`
+		Running       bool      `json:"running"`
+		Failures      int       `json:"failures"`
+		LastRunAt     time.Time `json:"last_run_at"`
+		LastSuccessAt time.Time `json:"last_success_at"`
+		LastError     string    `json:"last_error"`
+		NextRunAt     time.Time `json:"next_run_at"`
+	}
+)
+
+type job struct {
+	name    string
+	fn      JobFunc
+	opts    JobOptions
+	trigger chan struct{}
+	log     *slog.Logger
+
+	mu    sync.Mutex
+	state JobState
+	// lockChecked блокировка задачи уже хотя бы раз проверялась, до этого Leader ничего не значит
+	lockChecked bool
+}
+
+type Runner struct {
+	mu          sync.RWMutex
+	jobs        map[string]*job
+	locker      Locker
+	broadcaster Broadcaster
+	log         *slog.Logger
+
+	wg sync.WaitGroup
+	// runCtx живёт дольше контекста Start, чтобы начатые запуски успели завершиться при остановке
+	runCtx   context.Context
+	abortRun context.CancelFunc
+}
+
+func NewRunner(log *slog.Logger) *Runner {
+	runCtx, abortRun := context.WithCancel(context.Background())
+
+	return &Runner{
+		log:      log,
+		jobs:     make(map[string]*job),
+		runCtx:   runCtx,
+		abortRun: abortRun,
+	}
+}
+
+// WithLocker включает выбор ведущего: задача запускается только на экземпляре, захватившем её блокировку
+func (r *Runner) WithLocker(locker Locker) *Runner {
+	r.locker = locker
+	return r
+}
+
+// WithBroadcaster ручной запуск на экземпляре без блокировки задачи передаётся ведущему
+func (r *Runner) WithBroadcaster(broadcaster Broadcaster) *Runner {
+	r.broadcaster = broadcaster
+	return r
+}
+
+// Register добавляет задачу, вызывать до Start
+func (r *Runner) Register(name string, fn JobFunc, opts JobOptions) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs[name] = &job{
+		name:    name,
+		fn:      fn,
+		opts:    opts,
+		trigger: make(chan struct{}, 1),
+		log:     r.log,
+		state: JobState{
+			Name:     name,
+			Interval: opts.Interval.String(),
+			Leader:   r.locker == nil,
+		},
+	}
+}
+
+// Start запускает все зарегистрированные задачи, после отмены ctx новые запуски не планируются
+func (r *Runner) Start(ctx context.Context) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, j := range r.jobs {
+		r.wg.Add(1)
+		go func(j *job) {
+			defer r.wg.Done()
+			r.loop(ctx, j)
+		}(j)
+	}
+}
+
+// Shutdown ждёт завершения начатых запусков, а по истечении ctx прерывает их
+func (r *Runner) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		r.abortRun()
+		return errors.Wrap(ctx.Err(), "jobs are still running")
+	}
+}
+
+func (r *Runner) Pause(name string) error {
+	j, err := r.get(name)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	j.state.Paused = true
+	j.mu.Unlock()
+
+	return nil
+}
+
+func (r *Runner) Resume(name string) error {
+	j, err := r.get(name)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	j.state.Paused = false
+	j.mu.Unlock()
+
+	return nil
+}
+
+// Trigger запускает задачу вне расписания, в том числе поставленную на паузу.
+// Если задачей владеет другой экземпляр, запуск передаётся ему через Broadcaster
+func (r *Runner) Trigger(name string) error {
+	j, err := r.get(name)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	leader, checked := j.state.Leader, j.lockChecked
+	j.mu.Unlock()
+
+	if leader {
+		j.enqueue()
+		return nil
+	}
+
+	// до первой проверки блокировки запуск ставится локально: loop сначала попробует её захватить
+	if !checked {
+		j.enqueue()
+	}
+
+	if r.broadcaster == nil {
+		if checked {
+			return errors.Wrap(ErrNotLeader, name)
+		}
+
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.runCtx, broadcastTimeout)
+	defer cancel()
+
+	return errors.Wrap(r.broadcaster.Notify(ctx, name), "broadcaster.Notify")
+}
+
+// HandleTrigger запуск, переданный другим экземпляром, выполняется только на ведущем
+func (r *Runner) HandleTrigger(name string) {
+	j, err := r.get(name)
+	if err != nil {
+		return
+	}
+
+	j.mu.Lock()
+	leader := j.state.Leader
+	j.mu.Unlock()
+
+	if leader {
+		j.enqueue()
+	}
+}
+
+func (r *Runner) Jobs() []JobState {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]JobState, 0, len(r.jobs))
+	for _, j := range r.jobs {
+		j.mu.Lock()
+		result = append(result, j.state)
+		j.mu.Unlock()
+	}
+
+	sort.Slice(result, func(i, k int) bool {
+		return result[i].Name < result[k].Name
+	})
+
+	return result
+}
+
+func (r *Runner) get(name string) (*job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	j, ok := r.jobs[name]
+	if !ok {
+		return nil, errors.Wrap(ErrJobNotFound, name)
+	}
+
+	return j, nil
+}
+
+func (r *Runner) loop(ctx context.Context, j *job) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		manual := false
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-j.trigger:
+			manual = true
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		}
+
+		j.mu.Lock()
+		paused := j.state.Paused
+		j.mu.Unlock()
+
+		if (!paused || manual) && r.isLeader(ctx, j) {
+			j.run(r.runCtx)
+		}
+
+		timer.Reset(j.scheduleNext())
+	}
+}
+
+func (r *Runner) isLeader(ctx context.Context, j *job) bool {
+	if r.locker == nil {
+		return true
+	}
+
+	leader, err := r.locker.TryLock(ctx, j.name)
+	if err != nil {
+		j.log.ErrorContext(ctx, "locker.TryLock", slog.String(logger.KeyJob, j.name), logger.Err(err))
+	}
+
+	j.mu.Lock()
+	j.state.Leader = leader
+	j.lockChecked = true
+	j.mu.Unlock()
+
+	return leader
+}
+
+// enqueue повторные запуски до начала выполнения схлопываются в один
+func (j *job) enqueue() {
+	select {
+	case j.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (j *job) run(ctx context.Context) {
+	startedAt := time.Now()
+
+	j.mu.Lock()
+	j.state.Running = true
+	j.state.LastRunAt = startedAt
+	j.mu.Unlock()
+
+	runCtx := logger.WithAttrs(ctx, slog.String(logger.KeyJob, j.name), slog.String(logger.KeyRunID, uuid.NewString()))
+	j.log.DebugContext(runCtx, "job started")
+
+	if j.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(runCtx, j.opts.Timeout)
+		defer cancel()
+	}
+
+	err := j.fn(runCtx)
+	metrics.ObserveJobRun(j.name, time.Since(startedAt), err)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.state.Running = false
+	if err != nil {
+		j.log.ErrorContext(runCtx, "job failed", logger.Err(err), slog.Int("failures", j.state.Failures+1))
+		j.state.Failures++
+		j.state.LastError = err.Error()
+
+		return
+	}
+
+	j.log.DebugContext(runCtx, "job finished", slog.Duration("duration", time.Since(startedAt)))
+	j.state.Failures = 0
+	j.state.LastError = ""
+	j.state.LastSuccessAt = time.Now()
+}
+
+func (j *job) scheduleNext() time.Duration {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	delay := nextDelay(j.opts, j.state.Failures)
+	j.state.NextRunAt = time.Now().Add(delay)
+
+	return delay
+}
+
+// nextDelay удваивает интервал на каждую ошибку подряд, но не больше MaxBackoff, и добавляет jitter
+func nextDelay(opts JobOptions, failures int) time.Duration {
+	delay := opts.Interval
+	if opts.MaxBackoff > 0 {
+		for i := 0; i < failures && delay < opts.MaxBackoff; i++ {
+			delay *= 2
+		}
+
+		if delay > opts.MaxBackoff {
+			delay = opts.MaxBackoff
+		}
+	}
+
+	if opts.Jitter > 0 {
+		// nolint:gosec
+		delay += time.Duration((rand.Float64()*2 - 1) * opts.Jitter * float64(delay))
+	}
+
+	if delay < 0 {
+		return 0
+	}
+
+	return delay
+}
@@ -1,179 +1,129 @@
-// Package suppliesupdater закрывает собранные поставки
 package suppliesupdater
 
 import (
 	"context"
-	"log"
-	"strconv"
+	stderrors "errors"
+	"log/slog"
 	"time"
 
 	"github.com/alleswebdev/marketplace-3d-factory/internal/db/card"
 	"github.com/alleswebdev/marketplace-3d-factory/internal/db/orderqueue"
+	"github.com/alleswebdev/marketplace-3d-factory/internal/service/marketplace"
 	"github.com/pkg/errors"
-
-	"github.com/alleswebdev/marketplace-3d-factory/internal/client/ozon"
-	"github.com/alleswebdev/marketplace-3d-factory/internal/client/wb"
 )
 
-const delayInterval = 5 * time.Second
-const StatusDeclinedByClient = "declined_by_client"
+const (
+	// JobName задача закрытия заказов одна на все кабинеты
+	JobName = "supplies"
+
+	updateTimeout = 30 * time.Second
+)
 
 type OrdersStore interface {
 	GetOrders(ctx context.Context, filter orderqueue.ListFilter) ([]orderqueue.Order, error)
-	SetCompleteByOrderIDs(ctx context.Context, orderIDs []string) error
+	SetCompleteByOrderIDs(ctx context.Context, marketplace, account string, orderIDs []string) error
+	SetCancelledByOrderIDs(ctx context.Context, marketplace, account string, orderIDs []string) error
 }
 
+// Worker закрывает в очереди заказы, которые маркетплейс уже считает собранными или отменёнными
 type Worker struct {
-	wbClient         wb.Client
-	ozonClient       ozon.Client
+	marketplaces     []marketplace.Marketplace
 	ordersQueueStore OrdersStore
+	log              *slog.Logger
 }
 
-func NewWorker(wbClient wb.Client, ozonClient ozon.Client, ordersQueueStore OrdersStore) Worker {
+func NewWorker(marketplaces []marketplace.Marketplace, ordersQueueStore OrdersStore, log *slog.Logger) Worker {
 	return Worker{
-		wbClient:         wbClient,
-		ozonClient:       ozonClient,
+		marketplaces:     marketplaces,
 		ordersQueueStore: ordersQueueStore,
+		log:              log,
 	}
 }
 
-func (w Worker) Run(ctx context.Context) {
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-			wbCtxTimeout, wbCancel := context.WithTimeout(ctx, time.Second*30)
-			if err := w.updateWb(wbCtxTimeout); err != nil {
-				log.Printf("wb_supplies_updater:%s\n", err)
-			}
-
-			if err := w.updateWbCancelled(wbCtxTimeout); err != nil {
-				log.Printf("wb_supplies_updater_cancelled:%s\n", err)
-			}
-
-			wbCancel()
-
-			ozonCtxTimeout, ozonCancel := context.WithTimeout(ctx, time.Second*30)
-			if ozonErr := w.updateOzon(ozonCtxTimeout); ozonErr != nil {
-				log.Printf("ozon_supplies_updater:%s\n", ozonErr)
-			}
-			ozonCancel()
-
-			time.Sleep(delayInterval)
-		}
+// Update закрывает заказы из собранных поставок и отменённые покупателем
+func (w Worker) Update(ctx context.Context) error {
+	var result error
+	for _, mp := range w.marketplaces {
+		result = stderrors.Join(result, w.update(ctx, mp))
 	}
-}
 
-func (w Worker) updateWb(ctx context.Context) error {
-	next := 1
-	suppliesIDs := make([]string, 0)
+	return result
+}
 
-	for {
-		if next <= 0 {
-			break
+func (w Worker) UpdateMarketplace(ctx context.Context, name card.Marketplace) error {
+	var result error
+	for _, mp := range w.marketplaces {
+		if mp.Name() == name {
+			result = stderrors.Join(result, w.update(ctx, mp))
 		}
+	}
 
-		resp, err := w.wbClient.GetSupplies(ctx, next)
-		if err != nil {
-			return errors.Wrap(err, "wbClient.GetSupplies")
-		}
+	return result
+}
 
-		if len(resp.Supplies) <= 0 {
-			break
-		}
-		next = resp.Next
+func (w Worker) update(ctx context.Context, mp marketplace.Marketplace) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
 
-		for _, supply := range resp.Supplies {
-			if supply.Done {
-				continue
-			}
+	var result error
+	if err := w.completeShipped(ctxTimeout, mp); err != nil {
+		result = stderrors.Join(result, errors.Wrapf(err, "%s_supplies_updater %s", mp.Name(), mp.Account()))
+	}
 
-			suppliesIDs = append(suppliesIDs, supply.ID)
-		}
+	if err := w.completeCancelled(ctxTimeout, mp); err != nil {
+		result = stderrors.Join(result, errors.Wrapf(err, "%s_supplies_updater_cancelled %s", mp.Name(), mp.Account()))
 	}
 
-	orderIDs := make([]string, 0)
+	return result
+}
 
-	for _, supplyID := range suppliesIDs {
-		resp, err := w.wbClient.GetSupplyOrders(ctx, supplyID)
-		if err != nil {
-			log.Println(errors.Wrap(err, "wbClient.GetSupplyOrders").Error())
-			continue
-		}
+func (w Worker) completeShipped(ctx context.Context, mp marketplace.Marketplace) error {
+	orderIDs, err := mp.ListShippedOrders(ctx)
+	if err != nil {
+		return errors.Wrap(err, "marketplace.ListShippedOrders")
+	}
 
-		for _, order := range resp.Orders {
-			orderIDs = append(orderIDs, strconv.Itoa(int(order.ID)))
-		}
+	if len(orderIDs) == 0 {
+		return nil
 	}
 
-	if err := w.ordersQueueStore.SetCompleteByOrderIDs(ctx, orderIDs); err != nil {
-		return errors.Wrap(err, "queueStore.SetCompleteByOrderIDs")
+	if err = w.ordersQueueStore.SetCompleteByOrderIDs(ctx, mp.Name().String(), mp.Account(), orderIDs); err != nil {
+		return errors.Wrap(err, "ordersQueueStore.SetCompleteByOrderIDs")
 	}
 
 	return nil
 }
 
-func (w Worker) updateWbCancelled(ctx context.Context) error {
+func (w Worker) completeCancelled(ctx context.Context, mp marketplace.Marketplace) error {
 	orders, err := w.ordersQueueStore.GetOrders(ctx, orderqueue.ListFilter{
 		WithParentComplete: false,
-		Marketplace:        string(card.MpWb),
+		Marketplace:        mp.Name().String(),
+		Account:            mp.Account(),
 	})
-
 	if err != nil {
 		return errors.Wrap(err, "ordersQueueStore.GetOrders")
 	}
 
-	ids := make([]uint64, 0, len(orders))
-	for _, order := range orders {
-		id, _ := strconv.ParseUint(order.ID, 10, 64)
-		ids = append(ids, id)
-	}
-
-	if len(ids) == 0 {
-		return nil
-	}
-
-	resp, err := w.wbClient.GetOrdersStatus(ctx, ids)
-	if err != nil {
-		return errors.Wrap(err, "wbClient.GetOrdersStatus")
-	}
-
-	var cancelledIDs []string
-	for _, order := range resp.Orders {
-		if order.SupplierStatus == StatusDeclinedByClient {
-			cancelledIDs = append(cancelledIDs, strconv.Itoa(int(order.ID)))
-		}
-	}
-
-	if len(cancelledIDs) == 0 {
+	if len(orders) == 0 {
 		return nil
 	}
 
-	if err := w.ordersQueueStore.SetCompleteByOrderIDs(ctx, cancelledIDs); err != nil {
-		return errors.Wrap(err, "SetCompleteByOrderIDs")
+	openIDs := make([]string, 0, len(orders))
+	for _, order := range orders {
+		openIDs = append(openIDs, order.ID)
 	}
 
-	return nil
-}
-
-func (w Worker) updateOzon(ctx context.Context) error {
-	resp, err := w.ozonClient.GetUnfulfilledList(ctx, ozon.StatusDelivering)
+	cancelledIDs, err := mp.ListCancelled(ctx, openIDs)
 	if err != nil {
-		return errors.Wrap(err, "ordersClient.GetUnfulfilledList")
+		return errors.Wrap(err, "marketplace.ListCancelled")
 	}
 
-	if len(resp.Result.Postings) <= 0 {
+	if len(cancelledIDs) == 0 {
 		return nil
 	}
 
-	orderIDs := make([]string, 0, len(resp.Result.Postings))
-	for _, item := range resp.Result.Postings {
-		orderIDs = append(orderIDs, item.PostingNumber)
-	}
-
-	if err = w.ordersQueueStore.SetCompleteByOrderIDs(ctx, orderIDs); err != nil {
-		return errors.Wrap(err, "ordersQueueStore.SetCompleteByOrderIDs")
+	if err = w.ordersQueueStore.SetCancelledByOrderIDs(ctx, mp.Name().String(), mp.Account(), cancelledIDs); err != nil {
+		return errors.Wrap(err, "ordersQueueStore.SetCancelledByOrderIDs")
 	}
 
 	return nil
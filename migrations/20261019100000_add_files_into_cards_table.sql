@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE cards
+    ADD COLUMN IF NOT EXISTS files TEXT[] DEFAULT '{}'::text[];
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE cards
+    DROP COLUMN IF EXISTS files;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE cards
+    ADD COLUMN IF NOT EXISTS account TEXT NOT NULL DEFAULT 'default';
+DROP INDEX IF EXISTS cards_article_marketplace;
+CREATE UNIQUE INDEX cards_article_marketplace_account ON cards (article, marketplace, account);
+
+ALTER TABLE orders_queue
+    ADD COLUMN IF NOT EXISTS account TEXT NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS orders_queue_marketplace_account ON orders_queue (marketplace, account);
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS orders_queue_marketplace_account;
+ALTER TABLE orders_queue
+    DROP COLUMN IF EXISTS account;
+
+DROP INDEX IF EXISTS cards_article_marketplace_account;
+DELETE FROM cards WHERE account <> 'default';
+CREATE UNIQUE INDEX cards_article_marketplace ON cards (article, marketplace);
+ALTER TABLE cards
+    DROP COLUMN IF EXISTS account;
+-- +goose StatementEnd
@@ -0,0 +1,84 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS webhook_outbox (
+    id         BIGSERIAL PRIMARY KEY,
+    event_type TEXT        NOT NULL,
+    order_id   TEXT        NOT NULL,
+    article    TEXT        NOT NULL,
+    payload    JSONB       NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- просрочка по заказу отправляется один раз
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_outbox_overdue ON webhook_outbox (order_id, article)
+    WHERE event_type = 'order.overdue';
+
+-- webhook_cursors позиция доставки для каждого получателя из конфига
+CREATE TABLE IF NOT EXISTS webhook_cursors (
+    endpoint        TEXT PRIMARY KEY,
+    last_event_id   BIGINT      NOT NULL DEFAULT 0,
+    attempts        INT         NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error      TEXT        NOT NULL DEFAULT '',
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION webhook_order_payload(o orders_queue) RETURNS JSONB AS
+$$
+SELECT jsonb_build_object(
+    'id', o.id,
+    'article', o.article,
+    'marketplace', o.marketplace,
+    'account', o.account,
+    'order_created_at', o.order_created_at,
+    'is_complete', o.is_complete,
+    'is_printing', o.is_printing,
+    'info', o.info,
+    'composite_items', o.order_composite_items
+)
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- события пишутся триггером в той же транзакции, что и изменение очереди, поэтому не теряются
+-- независимо от того, какой воркер или ручка API поменяли заказ
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION orders_queue_webhook_events() RETURNS TRIGGER AS
+$$
+DECLARE
+    event TEXT;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        event := 'order.created';
+    ELSIF COALESCE((NEW.info ->> 'is_cancelled')::BOOLEAN, FALSE)
+        AND NOT COALESCE((OLD.info ->> 'is_cancelled')::BOOLEAN, FALSE) THEN
+        event := 'order.cancelled';
+    ELSIF NEW.is_complete IS DISTINCT FROM OLD.is_complete
+        OR NEW.is_printing IS DISTINCT FROM OLD.is_printing
+        OR NEW.order_composite_items IS DISTINCT FROM OLD.order_composite_items THEN
+        event := 'order.state_changed';
+    ELSE
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO webhook_outbox (event_type, order_id, article, payload)
+    VALUES (event, NEW.id, NEW.article, webhook_order_payload(NEW));
+
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER orders_queue_webhook_events
+    AFTER INSERT OR UPDATE
+    ON orders_queue
+    FOR EACH ROW
+EXECUTE FUNCTION orders_queue_webhook_events();
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS orders_queue_webhook_events ON orders_queue;
+DROP FUNCTION IF EXISTS orders_queue_webhook_events();
+DROP FUNCTION IF EXISTS webhook_order_payload(orders_queue);
+DROP TABLE IF EXISTS webhook_cursors;
+DROP TABLE IF EXISTS webhook_outbox;
+-- +goose StatementEnd
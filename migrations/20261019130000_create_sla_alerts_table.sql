@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS sla_alerts (
+    id              BIGSERIAL PRIMARY KEY,
+    rule            TEXT        NOT NULL,
+    order_id        TEXT        NOT NULL,
+    article         TEXT        NOT NULL,
+    marketplace     TEXT        NOT NULL,
+    account         TEXT        NOT NULL,
+    message         TEXT        NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    notified_at     TIMESTAMPTZ NULL,
+    acknowledged_at TIMESTAMPTZ NULL,
+    acknowledged_by TEXT        NOT NULL DEFAULT ''
+);
+
+-- одно срабатывание правила на позицию заказа, после подтверждения правило по ней молчит
+CREATE UNIQUE INDEX IF NOT EXISTS sla_alerts_rule_order ON sla_alerts (rule, order_id, article);
+CREATE INDEX IF NOT EXISTS sla_alerts_pending ON sla_alerts (id) WHERE notified_at IS NULL AND acknowledged_at IS NULL;
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS sla_alerts;
+-- +goose StatementEnd
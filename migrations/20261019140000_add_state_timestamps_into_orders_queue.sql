@@ -0,0 +1,42 @@
+-- +goose Up
+ALTER TABLE orders_queue
+    ADD COLUMN IF NOT EXISTS printing_at  TIMESTAMPTZ NULL,
+    ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ NULL;
+CREATE INDEX IF NOT EXISTS orders_queue_order_created_at ON orders_queue (order_created_at);
+
+-- +goose StatementBegin
+-- время первого перехода в печать и последнего закрытия проставляется при любом изменении статуса,
+-- кто бы его ни менял: воркеры, API или бот
+CREATE OR REPLACE FUNCTION orders_queue_state_timestamps() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF NEW.is_printing AND NEW.printing_at IS NULL THEN
+        NEW.printing_at := now();
+    END IF;
+
+    IF NOT NEW.is_complete THEN
+        NEW.completed_at := NULL;
+    ELSIF TG_OP = 'INSERT' OR NOT COALESCE(OLD.is_complete, FALSE) THEN
+        NEW.completed_at := now();
+    END IF;
+
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER orders_queue_state_timestamps
+    BEFORE INSERT OR UPDATE
+    ON orders_queue
+    FOR EACH ROW
+EXECUTE FUNCTION orders_queue_state_timestamps();
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS orders_queue_state_timestamps ON orders_queue;
+DROP FUNCTION IF EXISTS orders_queue_state_timestamps();
+DROP INDEX IF EXISTS orders_queue_order_created_at;
+ALTER TABLE orders_queue
+    DROP COLUMN IF EXISTS printing_at,
+    DROP COLUMN IF EXISTS completed_at;
+-- +goose StatementEnd
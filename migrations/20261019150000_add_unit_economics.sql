@@ -0,0 +1,22 @@
+-- +goose Up
+-- суммы в копейках: price - выручка позиции с учётом количества, commission - удержание маркетплейса
+ALTER TABLE orders_queue
+    ADD COLUMN IF NOT EXISTS price      BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS commission BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS currency   TEXT   NOT NULL DEFAULT '';
+
+-- модель себестоимости одной штуки: граммы филамента и часы печати
+ALTER TABLE cards
+    ADD COLUMN IF NOT EXISTS filament_grams NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS print_hours    NUMERIC(10, 2) NOT NULL DEFAULT 0;
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE cards
+    DROP COLUMN IF EXISTS filament_grams,
+    DROP COLUMN IF EXISTS print_hours;
+ALTER TABLE orders_queue
+    DROP COLUMN IF EXISTS price,
+    DROP COLUMN IF EXISTS commission,
+    DROP COLUMN IF EXISTS currency;
+-- +goose StatementEnd
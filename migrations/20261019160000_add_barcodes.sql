@@ -0,0 +1,16 @@
+-- +goose Up
+-- штрихкоды товара из каталога маркетплейса: WB skus, баркоды Ozon и Яндекса
+ALTER TABLE cards
+    ADD COLUMN IF NOT EXISTS barcodes TEXT[] NOT NULL DEFAULT '{}'::text[];
+
+CREATE INDEX IF NOT EXISTS cards_barcodes ON cards USING gin (barcodes);
+-- штрихкоды самого заказа (skus WB, баркоды отправления Ozon) лежат в info->'barcodes', ищутся только среди открытых
+CREATE INDEX IF NOT EXISTS orders_queue_open_barcodes ON orders_queue USING gin ((info -> 'barcodes')) WHERE NOT is_complete;
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS orders_queue_open_barcodes;
+DROP INDEX IF EXISTS cards_barcodes;
+ALTER TABLE cards
+    DROP COLUMN IF EXISTS barcodes;
+-- +goose StatementEnd
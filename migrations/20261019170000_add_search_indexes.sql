@@ -0,0 +1,44 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- текст для поиска по карточке: название, артикул и названия частей составной карточки.
+-- array_to_string формально STABLE, обёртка IMMUTABLE нужна, чтобы построить по ней индекс
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION cards_search_text(name TEXT, article TEXT, articles TEXT[]) RETURNS TEXT AS
+$$
+SELECT coalesce(name, '') || ' ' || coalesce(article, '') || ' ' || coalesce(array_to_string(articles, ' '), '')
+$$ LANGUAGE sql IMMUTABLE;
+-- +goose StatementEnd
+
+-- текст для поиска по заказу: id, артикул, номер, название ручного заказа и названия частей
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION orders_queue_search_text(id TEXT, article TEXT, info JSONB, items JSONB) RETURNS TEXT AS
+$$
+SELECT concat_ws(' ', id, article, info ->> 'order_number', info ->> 'name',
+                 jsonb_path_query_array(items, '$[*].name')::text)
+$$ LANGUAGE sql IMMUTABLE;
+-- +goose StatementEnd
+
+-- russian - стемминг для полнотекстового поиска, триграммы - опечатки и куски артикулов
+CREATE INDEX IF NOT EXISTS cards_search_fts ON cards
+    USING gin (to_tsvector('russian', cards_search_text(name, article, articles)));
+CREATE INDEX IF NOT EXISTS cards_search_trgm ON cards
+    USING gin (cards_search_text(name, article, articles) gin_trgm_ops);
+
+-- в поиске только открытые заказы, закрытых за годы накапливается на порядки больше
+CREATE INDEX IF NOT EXISTS orders_queue_search_fts ON orders_queue
+    USING gin (to_tsvector('russian', orders_queue_search_text(id, article, info, order_composite_items)))
+    WHERE NOT is_complete;
+CREATE INDEX IF NOT EXISTS orders_queue_search_trgm ON orders_queue
+    USING gin (orders_queue_search_text(id, article, info, order_composite_items) gin_trgm_ops)
+    WHERE NOT is_complete;
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS orders_queue_search_trgm;
+DROP INDEX IF EXISTS orders_queue_search_fts;
+DROP INDEX IF EXISTS cards_search_trgm;
+DROP INDEX IF EXISTS cards_search_fts;
+DROP FUNCTION IF EXISTS orders_queue_search_text(TEXT, TEXT, JSONB, JSONB);
+DROP FUNCTION IF EXISTS cards_search_text(TEXT, TEXT, TEXT[]);
+-- +goose StatementEnd
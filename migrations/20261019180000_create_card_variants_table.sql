@@ -0,0 +1,24 @@
+-- +goose Up
+-- card_variants вариант товара, который продаётся отдельным sku: цвет, размер и материал.
+-- Заменяет колонки color и size, удалённые из cards миграцией 20250814200846:
+-- у одного артикула WB несколько размеров, а варианты одной модели лежат в разных карточках
+CREATE TABLE IF NOT EXISTS card_variants (
+    marketplace TEXT        NOT NULL,
+    account     TEXT        NOT NULL DEFAULT 'default',
+    sku         TEXT        NOT NULL,
+    article     TEXT        NOT NULL,
+    -- model общая модель вариантов: imtID WB, model_id Ozon, marketModelId Яндекса
+    model       TEXT        NOT NULL DEFAULT '',
+    size        TEXT        NOT NULL DEFAULT '',
+    color       TEXT        NOT NULL DEFAULT '',
+    material    TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (marketplace, account, sku)
+);
+
+CREATE INDEX IF NOT EXISTS card_variants_article ON card_variants (marketplace, account, article);
+CREATE INDEX IF NOT EXISTS card_variants_model ON card_variants (marketplace, account, model) WHERE model <> '';
+
+-- +goose Down
+DROP TABLE IF EXISTS card_variants;
@@ -0,0 +1,30 @@
+-- +goose Up
+-- products мастер-товар: одна физическая модель, к которой привязаны карточки разных маркетплейсов.
+-- Состав, файлы моделей и оценка себестоимости задаются здесь и перекрывают значения привязанных карточек
+CREATE TABLE IF NOT EXISTS products (
+    id             UUID PRIMARY KEY,
+    name           TEXT           NOT NULL,
+    articles       TEXT[]         NOT NULL DEFAULT '{}'::text[],
+    files          TEXT[]         NOT NULL DEFAULT '{}'::text[],
+    is_composite   BOOL           NOT NULL DEFAULT false,
+    filament_grams NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    print_hours    NUMERIC(10, 2) NOT NULL DEFAULT 0
+);
+
+SELECT add_time_fields('products');
+
+ALTER TABLE cards
+    ADD COLUMN IF NOT EXISTS product_id UUID REFERENCES products (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS cards_product_id ON cards (product_id) WHERE product_id IS NOT NULL;
+-- подсказки связей сравнивают названия карточек разных маркетплейсов триграммами
+CREATE INDEX IF NOT EXISTS cards_name_trgm ON cards USING gin (name gin_trgm_ops);
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS cards_name_trgm;
+DROP INDEX IF EXISTS cards_product_id;
+ALTER TABLE cards
+    DROP COLUMN IF EXISTS product_id;
+DROP TABLE IF EXISTS products;
+-- +goose StatementEnd
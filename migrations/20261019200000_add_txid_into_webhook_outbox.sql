@@ -0,0 +1,20 @@
+-- +goose Up
+-- id выдаётся при вставке, а видна строка после коммита, поэтому курсор по id мог проскочить событие
+-- долгой транзакции. txid - номер транзакции, записавшей событие: всё, что младше pg_snapshot_xmin,
+-- уже зафиксировано или откатилось, и новых событий с таким txid не появится
+ALTER TABLE webhook_outbox
+    ADD COLUMN IF NOT EXISTS txid BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE webhook_outbox
+    ALTER COLUMN txid SET DEFAULT pg_current_xact_id()::TEXT::BIGINT;
+CREATE INDEX IF NOT EXISTS webhook_outbox_txid_id ON webhook_outbox (txid, id);
+
+-- старые события остаются с txid = 0 и доставляются по id, как раньше
+ALTER TABLE webhook_cursors
+    ADD COLUMN IF NOT EXISTS last_txid BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE webhook_cursors
+    DROP COLUMN IF EXISTS last_txid;
+DROP INDEX IF EXISTS webhook_outbox_txid_id;
+ALTER TABLE webhook_outbox
+    DROP COLUMN IF EXISTS txid;
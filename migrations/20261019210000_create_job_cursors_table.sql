@@ -0,0 +1,10 @@
+-- +goose Up
+-- job_cursors состояние фоновых задач, которое должно пережить рестарт и смену ведущего экземпляра
+CREATE TABLE IF NOT EXISTS job_cursors (
+    name       TEXT PRIMARY KEY,
+    value      TEXT        NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS job_cursors;
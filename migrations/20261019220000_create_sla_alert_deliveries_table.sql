@@ -0,0 +1,13 @@
+-- +goose Up
+-- доставка алерта отмечается по каждому нотификатору, после сбоя повторяется только неудавшийся
+CREATE TABLE IF NOT EXISTS sla_alert_deliveries (
+    alert_id     BIGINT      NOT NULL REFERENCES sla_alerts (id) ON DELETE CASCADE,
+    notifier     TEXT        NOT NULL,
+    delivered_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (alert_id, notifier)
+);
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS sla_alert_deliveries;
+-- +goose StatementEnd
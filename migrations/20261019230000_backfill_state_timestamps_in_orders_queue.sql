@@ -0,0 +1,24 @@
+-- +goose Up
+-- закрытые до появления триггера позиции получают время из updated_at. SetComplete его не обновляет,
+-- поэтому заполняются только строки, менявшиеся после вставки, остальные в отчёты по срокам не попадают.
+-- Время начала печати у уже закрытых позиций неизвестно и остаётся пустым
+ALTER TABLE orders_queue DISABLE TRIGGER orders_queue_state_timestamps;
+
+UPDATE orders_queue
+SET completed_at = updated_at
+WHERE is_complete
+  AND completed_at IS NULL
+  AND updated_at > created_at;
+
+UPDATE orders_queue
+SET printing_at = updated_at
+WHERE is_printing
+  AND NOT is_complete
+  AND printing_at IS NULL
+  AND updated_at > created_at;
+
+ALTER TABLE orders_queue ENABLE TRIGGER orders_queue_state_timestamps;
+
+-- +goose Down
+-- восстановленные значения не отличить от проставленных триггером, откат ничего не меняет
+SELECT 1;
@@ -0,0 +1,48 @@
+-- +goose Up
+-- незаполненное поле мастер-товара хранится как NULL, тогда COALESCE берёт значение привязанной карточки.
+-- is_composite относится к составу и без articles тоже берётся из карточки
+UPDATE products
+SET articles       = NULLIF(articles, '{}'),
+    files          = NULLIF(files, '{}'),
+    is_composite   = CASE WHEN articles = '{}' THEN NULL ELSE is_composite END,
+    filament_grams = NULLIF(filament_grams, 0),
+    print_hours    = NULLIF(print_hours, 0);
+
+ALTER TABLE products
+    ALTER COLUMN articles DROP NOT NULL,
+    ALTER COLUMN articles DROP DEFAULT,
+    ALTER COLUMN files DROP NOT NULL,
+    ALTER COLUMN files DROP DEFAULT,
+    ALTER COLUMN is_composite DROP NOT NULL,
+    ALTER COLUMN is_composite DROP DEFAULT,
+    ALTER COLUMN filament_grams DROP NOT NULL,
+    ALTER COLUMN filament_grams DROP DEFAULT,
+    ALTER COLUMN print_hours DROP NOT NULL,
+    ALTER COLUMN print_hours DROP DEFAULT;
+
+-- подсказки связей подбирают пары по индексам: равные без учёта регистра и похожие триграммами артикулы
+CREATE INDEX IF NOT EXISTS cards_lower_article ON cards (lower(article));
+CREATE INDEX IF NOT EXISTS cards_article_trgm ON cards USING gin (article gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS cards_article_trgm;
+DROP INDEX IF EXISTS cards_lower_article;
+
+UPDATE products
+SET articles       = COALESCE(articles, '{}'),
+    files          = COALESCE(files, '{}'),
+    is_composite   = COALESCE(is_composite, false),
+    filament_grams = COALESCE(filament_grams, 0),
+    print_hours    = COALESCE(print_hours, 0);
+
+ALTER TABLE products
+    ALTER COLUMN articles SET DEFAULT '{}'::text[],
+    ALTER COLUMN articles SET NOT NULL,
+    ALTER COLUMN files SET DEFAULT '{}'::text[],
+    ALTER COLUMN files SET NOT NULL,
+    ALTER COLUMN is_composite SET DEFAULT false,
+    ALTER COLUMN is_composite SET NOT NULL,
+    ALTER COLUMN filament_grams SET DEFAULT 0,
+    ALTER COLUMN filament_grams SET NOT NULL,
+    ALTER COLUMN print_hours SET DEFAULT 0,
+    ALTER COLUMN print_hours SET NOT NULL;
@@ -0,0 +1,42 @@
+-- +goose Up
+-- id заказа уникален только в пределах маркетплейса и кабинета, ключ позиции очереди включает оба
+UPDATE orders_queue SET marketplace = 'wb' WHERE marketplace IS NULL;
+ALTER TABLE orders_queue
+    ALTER COLUMN marketplace SET NOT NULL;
+
+DROP INDEX IF EXISTS orders_queue_article_id;
+CREATE UNIQUE INDEX IF NOT EXISTS orders_queue_marketplace_account_article_id
+    ON orders_queue (marketplace, account, article, id);
+
+DROP INDEX IF EXISTS sla_alerts_rule_order;
+CREATE UNIQUE INDEX IF NOT EXISTS sla_alerts_rule_order
+    ON sla_alerts (rule, marketplace, account, order_id, article);
+
+DROP INDEX IF EXISTS webhook_outbox_overdue;
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_outbox_overdue
+    ON webhook_outbox (order_id, article, (payload ->> 'marketplace'), (payload ->> 'account'))
+    WHERE event_type = 'order.overdue';
+
+-- +goose Down
+DROP INDEX IF EXISTS webhook_outbox_overdue;
+DELETE FROM webhook_outbox o
+    USING webhook_outbox d
+WHERE o.event_type = 'order.overdue'
+  AND d.event_type = 'order.overdue'
+  AND (o.order_id, o.article) = (d.order_id, d.article)
+  AND o.id > d.id;
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_outbox_overdue ON webhook_outbox (order_id, article)
+    WHERE event_type = 'order.overdue';
+
+DROP INDEX IF EXISTS sla_alerts_rule_order;
+DELETE FROM sla_alerts a
+    USING sla_alerts d
+WHERE (a.rule, a.order_id, a.article) = (d.rule, d.order_id, d.article)
+  AND a.id > d.id;
+CREATE UNIQUE INDEX IF NOT EXISTS sla_alerts_rule_order ON sla_alerts (rule, order_id, article);
+
+DROP INDEX IF EXISTS orders_queue_marketplace_account_article_id;
+DELETE FROM orders_queue WHERE account <> 'default';
+CREATE UNIQUE INDEX IF NOT EXISTS orders_queue_article_id ON orders_queue (article, id);
+ALTER TABLE orders_queue
+    ALTER COLUMN marketplace DROP NOT NULL;